@@ -22,7 +22,7 @@ func main() {
 	// Place a market order using IOC (Immediate or Cancel) order type
 	// This simulates a market order by using slippage protection
 	slippage := 0.03 // 3% slippage
-	orderResult, err := exchange.MarketOrder(
+	orderResult, _, err := exchange.MarketOrder(
 		"ETH",     // coin
 		true,      // isBuy
 		0.1,       // size
@@ -44,7 +44,7 @@ func main() {
 	// First, place a position order to have something to set TP/SL for
 	// Place a small market buy order
 	slippage := 0.02
-	positionResult, err := exchange.MarketOrder(
+	positionResult, _, err := exchange.MarketOrder(
 		"ETH",             // coin
 		true,              // isBuy
 		0.05,              // small size
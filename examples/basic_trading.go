@@ -107,7 +107,7 @@ func main() {
 	slippage := 0.01 // 1% slippage
 	cloid2 := GenerateCloid()
 	
-	result, err = exchange.MarketOrder(
+	result, _, err = exchange.MarketOrder(
 		"ETH",      // coin
 		false,      // isBuy (sell)
 		0.005,      // size (0.005 ETH)
@@ -2,10 +2,12 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"hyperliquid-go-sdk/pkg/utils"
@@ -16,10 +18,41 @@ type API struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	timeout    time.Duration
+	userAgent  string
+	headers    http.Header
+}
+
+// APIOption configures optional API behavior
+type APIOption func(*API)
+
+// WithUserAgent sets the User-Agent sent with every request, e.g. to identify a bot
+func WithUserAgent(userAgent string) APIOption {
+	return func(a *API) {
+		a.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header sent with every request, e.g. for an authenticating proxy
+// Content-Type and User-Agent are managed by the client and cannot be overridden here.
+func WithHeader(key string, value string) APIOption {
+	return func(a *API) {
+		if a.headers == nil {
+			a.headers = make(http.Header)
+		}
+		a.headers.Add(key, value)
+	}
+}
+
+// Apply applies options to an existing client; call it before the client is used concurrently.
+// Exchange.WithAPIOptions also applies them to the exchange's internal Info client.
+func (a *API) Apply(opts ...APIOption) {
+	for _, opt := range opts {
+		opt(a)
+	}
 }
 
 // NewAPI creates a new API client
-func NewAPI(baseURL string, timeout *time.Duration) *API {
+func NewAPI(baseURL string, timeout *time.Duration, opts ...APIOption) *API {
 	if baseURL == "" {
 		baseURL = utils.MainnetAPIURL
 	}
@@ -29,60 +62,154 @@ func NewAPI(baseURL string, timeout *time.Duration) *API {
 		clientTimeout = *timeout
 	}
 
-	return &API{
+	api := &API{
 		BaseURL: baseURL,
 		HTTPClient: &http.Client{
 			Timeout: clientTimeout,
 		},
-		timeout: clientTimeout,
+		timeout:   clientTimeout,
+		userAgent: utils.DefaultUserAgent,
 	}
+	api.Apply(opts...)
+
+	return api
 }
 
 // Post makes a POST request to the API
+// A bare string body, either plain text or a JSON string, is what the exchange returns for some
+// malformed requests; it is returned as an *utils.APIError carrying the raw text.
 func (a *API) Post(urlPath string, payload interface{}) (map[string]interface{}, error) {
+	body, statusCode, err := a.postRaw(urlPath, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if message, isString := plainStringBody(body); isString {
+		return nil, utils.NewAPIError(statusCode, message)
+	}
+
+	// Parse JSON response
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, decodeError(statusCode, body, err)
+	}
+
+	return result, nil
+}
+
+// plainStringBody returns the message of a body that is a JSON string literal, or plain text
+// that does not start like an HTML page or a JSON object or array. Anything else, including
+// null, a truncated object or a proxy's HTML page, is left to the caller's decoder.
+func plainStringBody(body []byte) (string, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return "", false
+	}
+
+	switch trimmed[0] {
+	case '"':
+		var message string
+		if err := json.Unmarshal(trimmed, &message); err != nil {
+			return "", false
+		}
+		return message, true
+	case '<', '{', '[':
+		return "", false
+	}
+
+	if json.Valid(trimmed) {
+		return "", false
+	}
+	return truncateBody(trimmed), true
+}
+
+// maxErrorBodyLen caps how much of a response body is quoted in error messages
+const maxErrorBodyLen = 256
+
+// truncateBody returns the trimmed body, cut to maxErrorBodyLen bytes for use in error messages
+func truncateBody(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) <= maxErrorBodyLen {
+		return text
+	}
+	return text[:maxErrorBodyLen] + "...(truncated)"
+}
+
+// decodeError wraps a JSON decode failure with the HTTP status and the start of the body
+// so that an HTML page from a proxy is easy to tell apart from a real parse bug
+func decodeError(statusCode int, body []byte, err error) error {
+	return fmt.Errorf("failed to decode response (status %d, body %q): %w", statusCode, truncateBody(body), err)
+}
+
+// postInto makes a POST request and decodes the JSON response into v
+// Used for endpoints whose response is not a JSON object
+func (a *API) postInto(urlPath string, payload interface{}, v interface{}) error {
+	return a.postIntoContext(context.Background(), urlPath, payload, v)
+}
+
+// postIntoContext is postInto with a context that cancels the HTTP request
+func (a *API) postIntoContext(ctx context.Context, urlPath string, payload interface{}, v interface{}) error {
+	body, statusCode, err := a.doPost(ctx, a.BaseURL+urlPath, payload)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return decodeError(statusCode, body, err)
+	}
+
+	return nil
+}
+
+// postRaw makes a POST request and returns the raw response body and HTTP status code
+func (a *API) postRaw(urlPath string, payload interface{}) ([]byte, int, error) {
+	return a.doPost(context.Background(), a.BaseURL+urlPath, payload)
+}
+
+// doPost sends the request and returns the raw response body and HTTP status code
+// HTTP error statuses are converted to errors by handleException.
+func (a *API) doPost(ctx context.Context, url string, payload interface{}) ([]byte, int, error) {
 	if payload == nil {
 		payload = map[string]interface{}{}
 	}
 
-	url := a.BaseURL + urlPath
-
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, 0, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	for key, values := range a.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	req.Header.Set("Content-Type", "application/json")
+	if a.userAgent != "" {
+		req.Header.Set("User-Agent", a.userAgent)
+	}
 
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Handle HTTP errors
 	if err := a.handleException(resp, body); err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
 	}
 
-	// Parse JSON response
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return map[string]interface{}{
-			"error": fmt.Sprintf("Could not parse JSON: %s", string(body)),
-		}, nil
-	}
-
-	return result, nil
+	return body, resp.StatusCode, nil
 }
 
 // handleException handles HTTP errors and creates appropriate error types
@@ -95,11 +222,11 @@ func (a *API) handleException(resp *http.Response, body []byte) error {
 	if statusCode >= 400 && statusCode < 500 {
 		var errResp map[string]interface{}
 		if err := json.Unmarshal(body, &errResp); err != nil {
-			return utils.NewClientError(statusCode, nil, string(body), resp.Header, nil)
+			return utils.NewClientError(statusCode, nil, truncateBody(body), resp.Header, nil)
 		}
 
 		if errResp == nil {
-			return utils.NewClientError(statusCode, nil, string(body), resp.Header, nil)
+			return utils.NewClientError(statusCode, nil, truncateBody(body), resp.Header, nil)
 		}
 
 		var code *string
@@ -113,7 +240,7 @@ func (a *API) handleException(resp *http.Response, body []byte) error {
 		if msgVal, ok := errResp["msg"].(string); ok {
 			msg = msgVal
 		} else {
-			msg = string(body)
+			msg = truncateBody(body)
 		}
 
 		if dataVal, ok := errResp["data"]; ok {
@@ -123,7 +250,31 @@ func (a *API) handleException(resp *http.Response, body []byte) error {
 		return utils.NewClientError(statusCode, code, msg, resp.Header, data)
 	}
 
-	return utils.NewServerError(statusCode, string(body))
+	return utils.NewServerError(statusCode, truncateBody(body))
+}
+
+// ExplorerURL returns the explorer RPC endpoint matching the API base URL
+func (a *API) ExplorerURL() string {
+	switch a.BaseURL {
+	case utils.MainnetAPIURL:
+		return utils.MainnetExplorerURL
+	case utils.TestnetAPIURL:
+		return utils.TestnetExplorerURL
+	default:
+		return a.BaseURL + "/explorer"
+	}
+}
+
+// EVMRPCURL returns the HyperEVM JSON-RPC endpoint matching the API base URL
+func (a *API) EVMRPCURL() string {
+	switch a.BaseURL {
+	case utils.MainnetAPIURL:
+		return utils.MainnetEVMRPCURL
+	case utils.TestnetAPIURL:
+		return utils.TestnetEVMRPCURL
+	default:
+		return a.BaseURL + "/evm"
+	}
 }
 
 // IsMainnet returns true if the client is connected to mainnet
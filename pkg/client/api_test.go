@@ -0,0 +1,191 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hyperliquid-go-sdk/pkg/utils"
+)
+
+func TestPostReturnsPlainTextBodiesAsAPIErrors(t *testing.T) {
+	server := newMockServer(t)
+	api := NewAPI(server.URL, nil)
+
+	// A plain-text 400 keeps the server's reason rather than a JSON decode failure
+	const reason = "Failed to deserialize the JSON body into the target type: missing field `nonce`"
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return rawResponse{status: 400, body: reason}
+	})
+	_, err := api.Post("/exchange", map[string]interface{}{"action": map[string]interface{}{}})
+	var clientErr *utils.ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected a ClientError, got %v", err)
+	}
+	if clientErr.StatusCode != 400 || clientErr.Message != reason {
+		t.Fatalf("ClientError = %d %q, want 400 %q", clientErr.StatusCode, clientErr.Message, reason)
+	}
+
+	// A long 4xx body is quoted truncated
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return rawResponse{status: 403, body: "<html>" + strings.Repeat("forbidden ", 100) + "</html>"}
+	})
+	_, err = api.Post("/exchange", nil)
+	if !errors.As(err, &clientErr) || clientErr.StatusCode != 403 {
+		t.Fatalf("expected a 403 ClientError, got %v", err)
+	}
+	if !strings.HasSuffix(clientErr.Message, "...(truncated)") || len(clientErr.Message) > maxErrorBodyLen+len("...(truncated)") {
+		t.Errorf("ClientError message = %q, want the truncated body", clientErr.Message)
+	}
+
+	// The same rejection with a success status, as plain text or a JSON string
+	for body, want := range map[string]string{
+		"User or API Wallet does not exist":   "User or API Wallet does not exist",
+		`"User or API Wallet does not exist"`: "User or API Wallet does not exist",
+	} {
+		server.handleExchange(func(map[string]interface{}) interface{} {
+			return rawResponse{status: 200, body: body}
+		})
+		_, err := api.Post("/exchange", nil)
+		var apiErr *utils.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Post with body %s: expected an APIError, got %v", body, err)
+		}
+		if apiErr.StatusCode != 200 || apiErr.Message != want {
+			t.Errorf("Post with body %s: APIError = %d %q, want 200 %q", body, apiErr.StatusCode, apiErr.Message, want)
+		}
+	}
+
+	// null is valid JSON and not a rejection message
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return rawResponse{status: 200, body: "null"}
+	})
+	result, err := api.Post("/exchange", nil)
+	var apiErr *utils.APIError
+	if errors.As(err, &apiErr) {
+		t.Fatalf("null body reported as an APIError: %v", err)
+	}
+	if err != nil || result != nil {
+		t.Fatalf("Post with null body = %v, %v; want nil, nil", result, err)
+	}
+}
+
+func TestDecodeErrorsIncludeStatusAndBodySnippet(t *testing.T) {
+	server := newMockServer(t)
+	api := NewAPI(server.URL, nil)
+	metaRequest := map[string]interface{}{"type": "meta"}
+	page := "<html><head><title>502 Bad Gateway</title></head><body>" + strings.Repeat("cloudflare ", 100) + "</body></html>"
+
+	// A proxy error page is reported with its status and the start of the page
+	server.handleInfo("meta", func(map[string]interface{}) interface{} {
+		return rawResponse{status: 502, body: page}
+	})
+	_, err := api.Post("/info", metaRequest)
+	var serverErr *utils.ServerError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != 502 {
+		t.Fatalf("expected a 502 ServerError, got %v", err)
+	}
+	if !strings.HasPrefix(serverErr.Message, "<html><head><title>502 Bad Gateway") || !strings.HasSuffix(serverErr.Message, "...(truncated)") {
+		t.Errorf("ServerError message = %q, want the truncated page", serverErr.Message)
+	}
+	if len(serverErr.Message) > maxErrorBodyLen+len("...(truncated)") {
+		t.Errorf("ServerError message is %d bytes, want at most %d", len(serverErr.Message), maxErrorBodyLen+len("...(truncated)"))
+	}
+
+	// Valid JSON of the wrong shape, an HTML page or truncated JSON with a success status
+	// is a decode error rather than an APIError
+	for _, body := range []string{`[1, 2, 3]`, `42`, `true`, `<html><body>maintenance</body></html>`, `{"status": "ok", "resp`, `[{"name": "BTC"`} {
+		server.handleInfo("meta", func(map[string]interface{}) interface{} {
+			return rawResponse{status: 200, body: body}
+		})
+		_, err := api.Post("/info", metaRequest)
+		if err == nil {
+			t.Fatalf("Post with body %s succeeded", body)
+		}
+		var apiErr *utils.APIError
+		if errors.As(err, &apiErr) {
+			t.Errorf("Post with body %s: got an APIError %v, want a decode error", body, err)
+		}
+		for _, part := range []string{"failed to decode response", "status 200", strconv.Quote(body)} {
+			if !strings.Contains(err.Error(), part) {
+				t.Errorf("Post with body %s: error %q does not contain %q", body, err, part)
+			}
+		}
+	}
+
+	// Typed decoding reports the same context
+	server.handleInfo("meta", func(map[string]interface{}) interface{} {
+		return rawResponse{status: 200, body: `{"universe": "not a list"}`}
+	})
+	var meta struct {
+		Universe []interface{} `json:"universe"`
+	}
+	err = api.postInto("/info", metaRequest, &meta)
+	if err == nil || !strings.Contains(err.Error(), "status 200") || !strings.Contains(err.Error(), "not a list") {
+		t.Fatalf("postInto error = %v, want the status and body", err)
+	}
+}
+
+func TestAPIOptionsSendUserAgentAndHeaders(t *testing.T) {
+	server := newMockServer(t)
+	metaRequest := map[string]interface{}{"type": "meta"}
+	server.respondInfo("meta", map[string]interface{}{"universe": []interface{}{}})
+	lastHeader := func(path string) http.Header {
+		t.Helper()
+		requests := server.recorded(path)
+		if len(requests) == 0 {
+			t.Fatalf("no request to %s", path)
+		}
+		return requests[len(requests)-1].Header
+	}
+
+	if _, err := NewAPI(server.URL, nil).Post("/info", metaRequest); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if got := lastHeader("/info").Get("User-Agent"); got != "hyperliquid-go-sdk/"+utils.SDKVersion {
+		t.Fatalf("default User-Agent = %q", got)
+	}
+
+	// Custom headers are sent as given, but cannot replace the JSON content type
+	api := NewAPI(server.URL, nil,
+		WithUserAgent("market-maker/2.3"),
+		WithHeader("X-Proxy-Token", "secret"),
+		WithHeader("X-Tag", "a"),
+		WithHeader("X-Tag", "b"),
+		WithHeader("Content-Type", "text/plain"),
+	)
+	if _, err := api.Post("/info", metaRequest); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	header := lastHeader("/info")
+	if got := header.Get("User-Agent"); got != "market-maker/2.3" {
+		t.Errorf("User-Agent = %q, want market-maker/2.3", got)
+	}
+	if got := header.Get("X-Proxy-Token"); got != "secret" {
+		t.Errorf("X-Proxy-Token = %q, want secret", got)
+	}
+	if got := header.Values("X-Tag"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("X-Tag = %v, want [a b]", got)
+	}
+	if got := header.Values("Content-Type"); !reflect.DeepEqual(got, []string{"application/json"}) {
+		t.Errorf("Content-Type = %v, want application/json", got)
+	}
+
+	// Exchange options also reach the requests of its internal Info client
+	exchange := newTestExchange(t, server).WithAPIOptions(WithUserAgent("exchange-bot/1.0"), WithHeader("X-Proxy-Token", "other"))
+	if _, err := exchange.ScheduleCancel(nil); err != nil {
+		t.Fatalf("ScheduleCancel: %v", err)
+	}
+	if _, err := exchange.info.Post("/info", metaRequest); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	for _, path := range []string{"/exchange", "/info"} {
+		header := lastHeader(path)
+		if header.Get("User-Agent") != "exchange-bot/1.0" || header.Get("X-Proxy-Token") != "other" {
+			t.Errorf("%s request headers = %v", path, header)
+		}
+	}
+}
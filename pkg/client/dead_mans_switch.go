@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ConnectionStatus reports whether a connection is up; both Info and WebsocketManager implement it
+type ConnectionStatus interface {
+	IsConnected() bool
+}
+
+// DeadMansSwitch is an HTTP keep-alive for a scheduled cancel-all, gated on a connection's status
+// While conn reports connected, a scheduleCancel window into the future is re-posted over HTTP
+// every half window. Once conn reports disconnected the keep-alive pauses, so the exchange cancels
+// all open orders when the window runs out; it resumes when conn comes back. The exchange knows
+// nothing of the connection: the cancel also fires if this process dies or its HTTP requests fail.
+type DeadMansSwitch struct {
+	exchange          *Exchange
+	conn              ConnectionStatus
+	window            time.Duration
+	keepAliveInterval time.Duration
+	mutex             sync.Mutex
+	stopCh            chan struct{}
+	armed             bool
+}
+
+// NewDeadMansSwitch creates a DeadMansSwitch that cancels orders window after conn drops
+// The window must be longer than the exchange's 5 second minimum scheduled cancel delay.
+func NewDeadMansSwitch(exchange *Exchange, conn ConnectionStatus, window time.Duration) (*DeadMansSwitch, error) {
+	if window <= minScheduleCancelDelay {
+		return nil, fmt.Errorf("dead man's switch window must be longer than %s", minScheduleCancelDelay)
+	}
+
+	return &DeadMansSwitch{
+		exchange:          exchange,
+		conn:              conn,
+		window:            window,
+		keepAliveInterval: window / 2,
+	}, nil
+}
+
+// Start registers the scheduled cancel and keeps it alive while the connection is up
+func (d *DeadMansSwitch) Start() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.stopCh != nil {
+		return fmt.Errorf("dead man's switch already started")
+	}
+	if !d.conn.IsConnected() {
+		return fmt.Errorf("connection is not up")
+	}
+
+	if err := d.keepAlive(); err != nil {
+		return err
+	}
+
+	d.stopCh = make(chan struct{})
+	go d.run(d.stopCh)
+
+	return nil
+}
+
+// Stop stops the keep-alive and removes the scheduled cancel so open orders are left in place
+func (d *DeadMansSwitch) Stop() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.stopCh == nil {
+		return nil
+	}
+	close(d.stopCh)
+	d.stopCh = nil
+
+	if _, err := d.exchange.ClearScheduledCancel(); err != nil {
+		return fmt.Errorf("failed to clear scheduled cancel: %w", err)
+	}
+	d.armed = false
+
+	return nil
+}
+
+// Armed returns true if a scheduled cancel is currently registered and being kept alive
+func (d *DeadMansSwitch) Armed() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.armed
+}
+
+// run re-posts the scheduled cancel every keepAliveInterval (half the window) while the connection is up
+func (d *DeadMansSwitch) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(d.keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			d.mutex.Lock()
+			if d.stopCh != stopCh {
+				// Stopped while waiting for the lock
+				d.mutex.Unlock()
+				return
+			}
+			if d.conn.IsConnected() {
+				if err := d.keepAlive(); err != nil {
+					log.Printf("Failed to keep scheduled cancel alive: %v", err)
+				}
+			} else if d.armed {
+				log.Printf("Connection lost, letting scheduled cancel fire")
+				d.armed = false
+			}
+			d.mutex.Unlock()
+		}
+	}
+}
+
+// keepAlive posts a scheduleCancel window into the future over HTTP; the caller must hold the mutex
+func (d *DeadMansSwitch) keepAlive() error {
+	cancelTime := d.exchange.timestampMS() + d.window.Milliseconds()
+	if _, err := d.exchange.ScheduleCancel(&cancelTime); err != nil {
+		return fmt.Errorf("failed to schedule cancel: %w", err)
+	}
+	d.armed = true
+	return nil
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeConnection struct {
+	connected atomic.Bool
+}
+
+func (c *fakeConnection) IsConnected() bool {
+	return c.connected.Load()
+}
+
+func TestDeadMansSwitchKeepAlivePausesWhileDisconnected(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server).WithClock(fixedClock(time.UnixMilli(1700000000000)))
+
+	conn := &fakeConnection{}
+	if _, err := NewDeadMansSwitch(exchange, conn, 5*time.Second); err == nil {
+		t.Fatal("expected an error for a window at the 5 second minimum")
+	}
+	deadMansSwitch, err := NewDeadMansSwitch(exchange, conn, 6*time.Second)
+	if err != nil {
+		t.Fatalf("NewDeadMansSwitch: %v", err)
+	}
+	deadMansSwitch.keepAliveInterval = 5 * time.Millisecond
+
+	if err := deadMansSwitch.Start(); err == nil {
+		t.Fatal("expected Start to fail while disconnected")
+	}
+	conn.connected.Store(true)
+	if err := deadMansSwitch.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { deadMansSwitch.Stop() })
+
+	// The scheduled cancel is registered window into the future
+	payload := server.lastExchangePayload()
+	if got := jsonField(t, payload["action"], "type"); got != "scheduleCancel" {
+		t.Fatalf("action type = %v, want scheduleCancel", got)
+	}
+	if got := jsonField(t, payload["action"], "time"); got != 1700000006000.0 {
+		t.Fatalf("cancel time = %v, want 1700000006000", got)
+	}
+	if !deadMansSwitch.Armed() {
+		t.Fatal("switch is not armed after Start")
+	}
+
+	waitFor := func(what string, condition func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			runtime.Gosched()
+			time.Sleep(time.Millisecond)
+		}
+	}
+	registered := func() int { return len(server.recorded("/exchange")) }
+
+	waitFor("a keep-alive", func() bool { return registered() >= 3 })
+
+	// Once the connection drops the cancel is left to fire
+	conn.connected.Store(false)
+	waitFor("the switch to disarm", func() bool { return !deadMansSwitch.Armed() })
+	sent := registered()
+	time.Sleep(30 * time.Millisecond)
+	if got := registered(); got != sent {
+		t.Fatalf("scheduled cancel re-posted %d times while disconnected", got-sent)
+	}
+
+	conn.connected.Store(true)
+	waitFor("the switch to rearm", deadMansSwitch.Armed)
+
+	// Stopping removes the scheduled cancel
+	if err := deadMansSwitch.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	action := server.lastExchangePayload()["action"].(map[string]interface{})
+	if _, ok := action["time"]; ok || action["type"] != "scheduleCancel" {
+		t.Fatalf("Stop sent %v, want a scheduleCancel without time", action)
+	}
+	if deadMansSwitch.Armed() {
+		t.Fatal("switch is armed after Stop")
+	}
+}
@@ -3,8 +3,9 @@ package client
 import (
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"math"
 	"strconv"
 	"strings"
@@ -24,8 +25,30 @@ type Exchange struct {
 	accountAddress *string
 	info           *Info
 	expiresAfter   *int64
+	debugWriter    io.Writer
+	redactDebug    bool
+	checkTrading   bool
+	checkSigner    bool
+	isAgent        bool
+	clock          utils.Clock
+	isFrontend     bool
+	checkBuilder   bool
+	normalizeNames bool
+	minNotional    MinNotionalPolicy
 }
 
+// MinNotionalPolicy controls how order methods treat orders below the minimum notional
+type MinNotionalPolicy int
+
+const (
+	// MinNotionalIgnore sends orders as-is and lets the exchange reject sub-minimum ones
+	MinNotionalIgnore MinNotionalPolicy = iota
+	// MinNotionalReject rejects sub-minimum orders with a ValidationError before signing
+	MinNotionalReject
+	// MinNotionalBump raises the size of sub-minimum orders to the smallest size meeting the minimum
+	MinNotionalBump
+)
+
 // NewExchange creates a new Exchange client
 func NewExchange(
 	privateKey *ecdsa.PrivateKey,
@@ -59,18 +82,275 @@ func (e *Exchange) SetExpiresAfter(expiresAfter *int64) {
 	e.expiresAfter = expiresAfter
 }
 
-// postAction posts an action to the exchange
-// postAction posts an action to the exchange - corrected to match Python reference exactly
-func (e *Exchange) postAction(action map[string]interface{}, signature interface{}, nonce int64) (map[string]interface{}, error) {
-	// Convert signature to map format if it's a SignatureResult
-	var vaultAddress *string
+// WithClock sets the clock used for this exchange's nonces and timestamps
+// Passing nil uses the package clock (see utils.SetClock). Intended for deterministic tests.
+func (e *Exchange) WithClock(clock utils.Clock) *Exchange {
+	e.clock = clock
+	return e
+}
+
+// timestampMS returns the current time in milliseconds from the exchange's clock
+func (e *Exchange) timestampMS() int64 {
+	if e.clock != nil {
+		return e.clock.Now().UnixMilli()
+	}
+	return utils.GetTimestampMS()
+}
+
+// frontendActions are the action types that carry the isFrontend envelope flag
+var frontendActions = map[string]bool{
+	"order":         true,
+	"cancel":        true,
+	"cancelByCloid": true,
+	"modify":        true,
+	"batchModify":   true,
+}
+
+// WithFrontend adds isFrontend: true to the envelope of order, cancel and modify actions
+// This marks requests as coming from a UI, which the exchange uses for frontend semantics such
+// as reporting market orders with the FrontendMarket tif. It is not part of the signed hash.
+func (e *Exchange) WithFrontend(enabled bool) *Exchange {
+	e.isFrontend = enabled
+	return e
+}
+
+// WithDebugPayloads writes the exact JSON body of every /exchange request to w, pretty-printed
+// Useful for diffing payloads against reference SDKs. Pass nil to disable.
+func (e *Exchange) WithDebugPayloads(w io.Writer) *Exchange {
+	e.debugWriter = w
+	return e
+}
+
+// WithDebugRedaction replaces the signature r and s values with a placeholder in debug payloads
+// so they can be written to log files safely. The payload sent to the exchange is unchanged.
+func (e *Exchange) WithDebugRedaction(enabled bool) *Exchange {
+	e.redactDebug = enabled
+	return e
+}
+
+// WithTradingCheck enables a preflight in BulkOrders that rejects orders on delisted or halted
+// assets before signing. Off by default since it costs an extra metaAndAssetCtxs request.
+func (e *Exchange) WithTradingCheck(enabled bool) *Exchange {
+	e.checkTrading = enabled
+	return e
+}
+
+// WithBuilderFeeCheck enables a preflight in BulkOrders that rejects orders whose builder fee
+// exceeds the max fee the account has approved for that builder. Off by default since it costs
+// an extra maxBuilderFee request per order batch.
+func (e *Exchange) WithBuilderFeeCheck(enabled bool) *Exchange {
+	e.checkBuilder = enabled
+	return e
+}
+
+// WithSignerCheck enables a preflight in postAction that recovers the signer of each L1 action
+// and rejects it client-side if, outside agent mode, it doesn't match the account address, or if
+// an agent has no account address set. Exchanges returned by NewAgentExchange are in agent mode.
+func (e *Exchange) WithSignerCheck(enabled bool) *Exchange {
+	e.checkSigner = enabled
+	return e
+}
+
+// WithAPIOptions applies API options such as WithUserAgent or WithHeader to the exchange's
+// requests and to those of its internal Info client
+func (e *Exchange) WithAPIOptions(opts ...APIOption) *Exchange {
+	e.API.Apply(opts...)
+	e.info.API.Apply(opts...)
+	return e
+}
+
+// WithCoinNameNormalization lets order methods accept coin names that differ from the canonical
+// name only in case or surrounding whitespace, e.g. " eth " for "ETH". Off by default since
+// spot names are case-sensitive; a name that folds to more than one coin is still rejected.
+func (e *Exchange) WithCoinNameNormalization(enabled bool) *Exchange {
+	e.normalizeNames = enabled
+	return e
+}
+
+// WithMinNotionalPolicy sets how BulkOrders treats orders below the coin's minimum notional
+// The minimum is utils.DefaultMinNotional unless overridden with Info.SetMinNotional.
+// Reduce-only orders are exempt since the exchange allows them to close any size.
+func (e *Exchange) WithMinNotionalPolicy(policy MinNotionalPolicy) *Exchange {
+	e.minNotional = policy
+	return e
+}
+
+// applyMinNotional enforces the min notional policy, returning a copy of orderRequests
+// with any bumped sizes so the caller's slice is left untouched
+func (e *Exchange) applyMinNotional(orderRequests []types.OrderRequest) ([]types.OrderRequest, error) {
+	if e.minNotional == MinNotionalIgnore {
+		return orderRequests, nil
+	}
+
+	adjusted := make([]types.OrderRequest, len(orderRequests))
+	copy(adjusted, orderRequests)
+
+	for idx, order := range adjusted {
+		if order.ReduceOnly {
+			continue
+		}
+
+		coin, exists := e.coinForName(order.Coin)
+		if !exists {
+			return nil, fmt.Errorf("coin not found: %s", order.Coin)
+		}
+		minNotional, err := e.info.MinNotional(coin)
+		if err != nil {
+			return nil, err
+		}
+		if utils.MeetsMinNotional(order.Sz, order.LimitPx, minNotional) {
+			continue
+		}
+
+		if e.minNotional == MinNotionalReject {
+			return nil, utils.NewValidationError("sz", fmt.Sprintf("%s order notional %.2f is below the minimum of %.2f", order.Coin, order.Notional(), minNotional))
+		}
+
+		szDecimals, err := e.info.SzDecimals(coin)
+		if err != nil {
+			return nil, err
+		}
+		sz, err := utils.MinSizeForNotional(order.LimitPx, minNotional, szDecimals)
+		if err != nil {
+			return nil, utils.NewValidationError("limitPx", err.Error())
+		}
+		adjusted[idx].Sz = sz
+	}
+
+	return adjusted, nil
+}
+
+// resolveName maps name to its canonical form when coin name normalization is enabled
+func (e *Exchange) resolveName(name string) string {
+	if !e.normalizeNames {
+		return name
+	}
+	if canonical, exists := e.info.normalizeName(name); exists {
+		return canonical
+	}
+	return name
+}
+
+// nameToAsset resolves a coin name to its asset ID, honoring coin name normalization
+func (e *Exchange) nameToAsset(name string) (int, error) {
+	return e.info.NameToAsset(e.resolveName(name))
+}
+
+// coinForName resolves a coin name to its coin, honoring coin name normalization
+func (e *Exchange) coinForName(name string) (string, bool) {
+	return e.info.coinForName(e.resolveName(name))
+}
+
+// userAddress returns the address whose state the exchange acts on:
+// the vault if set, otherwise the account address, otherwise the signer address
+func (e *Exchange) userAddress() string {
+	if e.vaultAddress != nil {
+		return *e.vaultAddress
+	}
+	if e.accountAddress != nil {
+		return *e.accountAddress
+	}
+	return utils.GetAddressFromPrivateKey(e.privateKey)
+}
+
+// vaultlessActions lists action types sent without a vaultAddress by default.
+// L1 actions (orders, cancels, leverage, etc.) accept a vaultAddress and are signed with it;
+// these user-signed transfers carry the source account in the signed action itself and the
+// API rejects a vaultAddress on them (matches Python reference). Pass WithVault to
+// UsdClassTransfer or SendAsset to send one anyway.
+var vaultlessActions = map[string]bool{
+	"usdClassTransfer": true,
+	"sendAsset":        true,
+}
+
+// TransferOption configures optional UsdClassTransfer and SendAsset behavior
+type TransferOption func(*transferConfig)
+
+// transferConfig holds the options applied to a single transfer call
+type transferConfig struct {
+	vaultAddress *string
+}
+
+// WithVault sends the transfer with vaultAddress set to address instead of the default null.
+// The source account is still taken from the exchange's own vault, if any.
+func WithVault(address string) TransferOption {
+	return func(c *transferConfig) {
+		lower := strings.ToLower(address)
+		c.vaultAddress = &lower
+	}
+}
+
+// hyperliquidChain returns the chain name user-signed actions carry for this exchange's network
+func (e *Exchange) hyperliquidChain() string {
+	if e.IsMainnet() {
+		return utils.MainnetChainName
+	}
+	return utils.TestnetChainName
+}
+
+// postTransfer posts a signed usdClassTransfer or sendAsset action, applying opts to the vaultAddress
+func (e *Exchange) postTransfer(action map[string]interface{}, signature map[string]interface{}, nonce int64, opts []TransferOption) (map[string]interface{}, error) {
+	config := transferConfig{vaultAddress: e.defaultVaultAddress(action)}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	action["signatureChainId"] = utils.SignatureChainID
+	action["hyperliquidChain"] = e.hyperliquidChain()
+
+	result, err := e.postActionWithVault(action, signature, nonce, config.vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult(action["type"].(string), result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UserAddress returns the address whose state this exchange acts on: the vault if set,
+// otherwise the account address, otherwise the signer address
+func (e *Exchange) UserAddress() string {
+	return e.userAddress()
+}
+
+// UserState retrieves the perp state of the account this exchange acts on
+// For a vault-acting exchange this is the vault's state, not the signer's.
+func (e *Exchange) UserState(dex string) (*types.ClearinghouseState, error) {
+	return e.info.UserStateTyped(e.userAddress(), dex)
+}
+
+// OpenOrders retrieves the open orders of the account this exchange acts on
+func (e *Exchange) OpenOrders(dex string) ([]types.OpenOrder, error) {
+	return e.info.OpenOrdersTyped(e.userAddress(), dex)
+}
+
+// UserFills retrieves the recent fills of the account this exchange acts on
+func (e *Exchange) UserFills(dex string) ([]types.Fill, error) {
+	return e.info.UserFillsTyped(e.userAddress(), dex)
+}
+
+// defaultVaultAddress returns the vaultAddress postAction includes for an action type
+func (e *Exchange) defaultVaultAddress(action map[string]interface{}) *string {
 	actionType, ok := action["type"].(string)
-	if ok && actionType != "usdClassTransfer" && actionType != "sendAsset" {
-		vaultAddress = e.vaultAddress
-	} else {
-		vaultAddress = nil
+	if !ok || vaultlessActions[actionType] {
+		return nil
 	}
+	return e.vaultAddress
+}
 
+// postAction posts an action to the exchange - corrected to match Python reference exactly
+// The vaultAddress is chosen by action type; see vaultlessActions
+func (e *Exchange) postAction(action map[string]interface{}, signature interface{}, nonce int64) (map[string]interface{}, error) {
+	return e.postActionWithVault(action, signature, nonce, e.defaultVaultAddress(action))
+}
+
+// postActionWithVault posts an action to the exchange with an explicit vaultAddress (nil for none)
+// The vaultAddress must match the one used when signing L1 actions
+func (e *Exchange) postActionWithVault(action map[string]interface{}, signature interface{}, nonce int64, vaultAddress *string) (map[string]interface{}, error) {
+	// Convert signature to map format if it's a SignatureResult
 	var sigMap map[string]interface{}
 	switch sig := signature.(type) {
 	case utils.SignatureResult:
@@ -85,6 +365,12 @@ func (e *Exchange) postAction(action map[string]interface{}, signature interface
 		return nil, fmt.Errorf("unsupported signature type")
 	}
 
+	if e.checkSigner {
+		if err := e.verifySigner(action, sigMap, nonce, vaultAddress); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build payload matching Python reference exactly
 	payload := map[string]interface{}{
 		"action":       action,
@@ -94,17 +380,151 @@ func (e *Exchange) postAction(action map[string]interface{}, signature interface
 		"expiresAfter": e.expiresAfter, // Always include, can be nil
 	}
 
+	if e.isFrontend {
+		if actionType, _ := action["type"].(string); frontendActions[actionType] {
+			payload["isFrontend"] = true
+		}
+	}
+
 	// Note: user field should not be included in payload per API requirements
 
-	// Debug: print the actual JSON payload
-	jsonPayload, _ := json.MarshalIndent(payload, "", "  ")
-	log.Printf("Payload JSON:\n%s\n", string(jsonPayload))
+	return e.postExchange(payload)
+}
+
+// verifySigner recovers the signer of an L1 action and checks it against the account it acts for
+// Outside agent mode the signer must be the account address, if one is set; in agent mode an
+// account address must be set. The signer is not compared with the exchange's own key: a
+// signature made over the wrong nonce, vault or network recovers to an unrelated address and
+// is caught by the account check.
+// User-signed actions carry their own chain fields and are not L1 signed, so they are skipped.
+func (e *Exchange) verifySigner(action map[string]interface{}, sigMap map[string]interface{}, nonce int64, vaultAddress *string) error {
+	if _, userSigned := action["hyperliquidChain"]; userSigned {
+		return nil
+	}
+
+	digest, err := utils.L1ActionDigest(action, vaultAddress, nonce, e.expiresAfter, e.IsMainnet())
+	if err != nil {
+		return fmt.Errorf("signer check failed: %w", err)
+	}
+
+	signer, err := utils.RecoverSigner(digest, sigMap)
+	if err != nil {
+		return fmt.Errorf("signer check failed: %w", err)
+	}
+
+	if e.isAgent {
+		if e.accountAddress == nil {
+			return fmt.Errorf("signer check failed: agent %s has no account address set", signer)
+		}
+		return nil
+	}
+
+	if e.accountAddress != nil && !strings.EqualFold(signer, *e.accountAddress) {
+		return fmt.Errorf("signer check failed: signer %s does not match account %s; use an agent exchange to sign for another account", signer, *e.accountAddress)
+	}
+
+	return nil
+}
+
+// postExchange posts a payload to the /exchange endpoint, writing it to the debug writer if set
+func (e *Exchange) postExchange(payload map[string]interface{}) (map[string]interface{}, error) {
+	if e.debugWriter != nil {
+		debugPayload := payload
+		if e.redactDebug {
+			debugPayload = redactSignature(payload)
+		}
+		jsonPayload, err := json.MarshalIndent(debugPayload, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal debug payload: %w", err)
+		}
+		fmt.Fprintf(e.debugWriter, "%s\n", jsonPayload)
+	}
+
 	return e.Post("/exchange", payload)
 }
 
+// redactedValue replaces sensitive values in redacted debug payloads
+const redactedValue = "[REDACTED]"
+
+// redactSignature returns a shallow copy of payload with the signature r and s values redacted
+func redactSignature(payload map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		redacted[k] = v
+	}
+
+	switch sig := payload["signature"].(type) {
+	case map[string]interface{}:
+		sigMap := make(map[string]interface{}, len(sig))
+		for k, v := range sig {
+			sigMap[k] = v
+		}
+		sigMap["r"] = redactedValue
+		sigMap["s"] = redactedValue
+		redacted["signature"] = sigMap
+	case utils.SignatureResult:
+		redacted["signature"] = utils.SignatureResult{R: redactedValue, S: redactedValue, V: sig.V}
+	}
+
+	return redacted
+}
+
+// vwapPrice calculates a limit price for a taker of sz from the current book
+// filled is false when the book is too thin to fill sz, in which case no price is returned.
+func (e *Exchange) vwapPrice(name string, isBuy bool, sz float64, buffer float64) (limitPx float64, filled bool, err error) {
+	coin, exists := e.coinForName(name)
+	if !exists {
+		return 0, false, fmt.Errorf("coin not found: %s", name)
+	}
+	asset, exists := e.info.assetForCoin(coin)
+	if !exists {
+		return 0, false, fmt.Errorf("asset not found for coin: %s", coin)
+	}
+
+	book, err := e.info.L2BookTyped(coin, "")
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get book: %w", err)
+	}
+
+	avgPx, filledSz := utils.BookFillPrice(*book, isBuy, sz)
+	if filledSz < sz {
+		return 0, false, nil
+	}
+
+	bids, asks := utils.BookDepth(*book)
+	levels := bids
+	if isBuy {
+		levels = asks
+	}
+	var worstPx float64
+	for _, level := range levels {
+		worstPx = level.Px
+		if level.CumSz >= sz {
+			break
+		}
+	}
+
+	if isBuy {
+		limitPx = math.Max(avgPx*(1+buffer), worstPx)
+	} else {
+		limitPx = math.Min(avgPx*(1-buffer), worstPx)
+	}
+
+	// Round away from the book so rounding never drops the last level
+	rounded := e.info.roundPrice(asset, limitPx)
+	tick := e.info.priceTick(asset, rounded)
+	if isBuy && rounded < limitPx {
+		rounded = e.info.roundPrice(asset, rounded+tick)
+	} else if !isBuy && rounded > limitPx {
+		rounded = e.info.roundPrice(asset, rounded-tick)
+	}
+
+	return rounded, true, nil
+}
+
 // slippagePrice calculates the price with slippage
 func (e *Exchange) slippagePrice(name string, isBuy bool, slippage float64, px *float64) (float64, error) {
-	coin, exists := e.info.nameToCoin[name]
+	coin, exists := e.coinForName(name)
 	if !exists {
 		return 0, fmt.Errorf("coin not found: %s", name)
 	}
@@ -130,14 +550,11 @@ func (e *Exchange) slippagePrice(name string, isBuy bool, slippage float64, px *
 		}
 	}
 
-	asset, exists := e.info.coinToAsset[coin]
+	asset, exists := e.info.assetForCoin(coin)
 	if !exists {
 		return 0, fmt.Errorf("asset not found for coin: %s", coin)
 	}
 
-	// spot assets start at 10000
-	isSpot := asset >= 10000
-
 	// Calculate slippage
 	if isBuy {
 		price *= (1 + slippage)
@@ -145,31 +562,7 @@ func (e *Exchange) slippagePrice(name string, isBuy bool, slippage float64, px *
 		price *= (1 - slippage)
 	}
 
-	// Round to appropriate decimal places
-	var decimals int
-	if isSpot {
-		szDecimals, exists := e.info.assetToSzDecimals[asset]
-		if exists {
-			decimals = 8 - szDecimals
-		} else {
-			decimals = 8
-		}
-	} else {
-		szDecimals, exists := e.info.assetToSzDecimals[asset]
-		if exists {
-			decimals = 6 - szDecimals
-		} else {
-			decimals = 6
-		}
-	}
-
-	// Round to 5 significant figures and appropriate decimal places
-	sigFigs := 5
-	magnitude := math.Log10(math.Abs(price))
-	roundTo := math.Max(float64(decimals), float64(sigFigs)-magnitude-1)
-
-	multiplier := math.Pow(10, roundTo)
-	return math.Round(price*multiplier) / multiplier, nil
+	return e.info.roundPrice(asset, price), nil
 }
 
 // Order places a single order
@@ -198,10 +591,26 @@ func (e *Exchange) Order(
 
 // BulkOrders places multiple orders in a single transaction
 func (e *Exchange) BulkOrders(orderRequests []types.OrderRequest, builder *types.BuilderInfo) (map[string]interface{}, error) {
+	return e.bulkOrdersWithGrouping(orderRequests, builder, types.GroupingNa)
+}
+
+// bulkOrdersWithGrouping places multiple orders submitted with the given grouping
+func (e *Exchange) bulkOrdersWithGrouping(orderRequests []types.OrderRequest, builder *types.BuilderInfo, grouping types.Grouping) (map[string]interface{}, error) {
+	if e.checkTrading {
+		if err := e.checkAssetsTrading(orderRequests); err != nil {
+			return nil, err
+		}
+	}
+
+	orderRequests, err := e.applyMinNotional(orderRequests)
+	if err != nil {
+		return nil, err
+	}
+
 	var orderWires []types.OrderWire
 
 	for _, order := range orderRequests {
-		asset, err := e.info.NameToAsset(order.Coin)
+		asset, err := e.nameToAsset(order.Coin)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get asset for coin %s: %w", order.Coin, err)
 		}
@@ -214,14 +623,24 @@ func (e *Exchange) BulkOrders(orderRequests []types.OrderRequest, builder *types
 		orderWires = append(orderWires, orderWire)
 	}
 
-	timestamp := utils.GetTimestampMS()
+	timestamp := e.timestampMS()
 
 	// Normalize builder address to lowercase (matching Python reference)
 	if builder != nil {
 		builder.B = strings.ToLower(builder.B)
+
+		if e.checkBuilder {
+			maxFee, err := e.info.MaxBuilderFee(e.userAddress(), builder.B)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get approved builder fee: %w", err)
+			}
+			if builder.F > maxFee {
+				return nil, utils.NewValidationError("builder", fmt.Sprintf("fee %d exceeds the approved max of %d tenths of a basis point for %s", builder.F, maxFee, builder.B))
+			}
+		}
 	}
 
-	orderAction := utils.OrderWiresToOrderAction(orderWires, builder)
+	orderAction := utils.OrderWiresToOrderActionWithGrouping(orderWires, builder, grouping)
 
 	// Use SignL1Action (as you requested) - postAction handles the signature format
 	signature, err := utils.SignL1Action(
@@ -239,22 +658,119 @@ func (e *Exchange) BulkOrders(orderRequests []types.OrderRequest, builder *types
 	return e.postAction(orderAction, signature, timestamp)
 }
 
+// checkAssetsTrading rejects orders on perp assets marked delisted or without a mark price.
+// Spot assets are not covered by metaAndAssetCtxs and are skipped.
+func (e *Exchange) checkAssetsTrading(orderRequests []types.OrderRequest) error {
+	coinsByDex := make(map[string][]string)
+	for _, order := range orderRequests {
+		coin, exists := e.coinForName(order.Coin)
+		if !exists {
+			continue
+		}
+		asset, exists := e.info.assetForCoin(coin)
+		if !exists || !utils.IsPerpAsset(asset) {
+			continue
+		}
+
+		dex := ""
+		if idx := strings.Index(coin, ":"); idx != -1 {
+			dex = coin[:idx]
+		}
+		coinsByDex[dex] = append(coinsByDex[dex], coin)
+	}
+
+	for dex, coins := range coinsByDex {
+		meta, ctxs, err := e.info.MetaAndAssetCtxs(dex)
+		if err != nil {
+			return fmt.Errorf("failed to get asset contexts: %w", err)
+		}
+
+		for _, coin := range coins {
+			for idx, assetInfo := range meta.Universe {
+				if assetInfo.Name != coin {
+					continue
+				}
+				if assetInfo.IsDelisted {
+					return fmt.Errorf("%w: %s is delisted", utils.ErrAssetNotTrading, coin)
+				}
+				if idx < len(ctxs) {
+					markPx, err := strconv.ParseFloat(ctxs[idx].MarkPx, 64)
+					if err != nil || markPx <= 0 {
+						return fmt.Errorf("%w: %s is halted", utils.ErrAssetNotTrading, coin)
+					}
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// MarketOrderOption configures optional MarketOrder behavior
+type MarketOrderOption func(*marketOrderConfig)
+
+// marketOrderConfig holds the options applied to a single MarketOrder call
+type marketOrderConfig struct {
+	useVWAP    bool
+	vwapBuffer float64
+}
+
+// WithVWAPLimit derives the limit price from the current book instead of a flat slippage off the mid:
+// the volume-weighted average price for sz plus buffer (e.g. 0.002 for 0.2%), but never tighter than
+// the last level needed to fill sz so the IOC can take the whole size. Falls back to the flat
+// slippage when the book cannot fill sz.
+func WithVWAPLimit(buffer float64) MarketOrderOption {
+	return func(c *marketOrderConfig) {
+		c.useVWAP = true
+		c.vwapBuffer = buffer
+	}
+}
+
 // MarketOrder places a market order with slippage protection
+// If cloid is nil a random one is generated. The cloid sent with the order is returned
+// so fills can be matched back to it.
 func (e *Exchange) MarketOrder(
 	name string,
 	isBuy bool,
 	sz float64,
 	slippage *float64,
 	cloid *types.Cloid,
-) (map[string]interface{}, error) {
+	opts ...MarketOrderOption,
+) (map[string]interface{}, *types.Cloid, error) {
 	if slippage == nil {
 		defaultSlippage := DefaultSlippage
 		slippage = &defaultSlippage
 	}
 
-	limitPx, err := e.slippagePrice(name, isBuy, *slippage, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate slippage price: %w", err)
+	var config marketOrderConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if cloid == nil {
+		generated, err := types.NewRandomCloid()
+		if err != nil {
+			return nil, nil, err
+		}
+		cloid = generated
+	}
+
+	var limitPx float64
+	var filled bool
+	if config.useVWAP {
+		var err error
+		limitPx, filled, err = e.vwapPrice(name, isBuy, sz, config.vwapBuffer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to calculate vwap price: %w", err)
+		}
+	}
+	if !filled {
+		var err error
+		limitPx, err = e.slippagePrice(name, isBuy, *slippage, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to calculate slippage price: %w", err)
+		}
 	}
 
 	orderType := types.OrderType{
@@ -263,7 +779,48 @@ func (e *Exchange) MarketOrder(
 		},
 	}
 
-	return e.Order(name, isBuy, sz, limitPx, orderType, false, cloid, nil)
+	result, err := e.Order(name, isBuy, sz, limitPx, orderType, false, cloid, nil)
+	return result, cloid, err
+}
+
+// OrderSliced splits a large limit order into child orders no larger than maxChildSz
+// and submits them as a single batch to avoid max trade size rejections
+func (e *Exchange) OrderSliced(
+	coin string,
+	isBuy bool,
+	totalSz float64,
+	limitPx float64,
+	tif types.Tif,
+	maxChildSz float64,
+) (map[string]interface{}, error) {
+	asset, err := e.nameToAsset(coin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+	}
+
+	szDecimals, exists := e.info.szDecimalsForAsset(asset)
+	if !exists {
+		return nil, fmt.Errorf("size decimals not found for coin %s", coin)
+	}
+	childSizes, err := utils.SplitSize(totalSz, maxChildSz, szDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split order: %w", err)
+	}
+
+	orderRequests := make([]types.OrderRequest, 0, len(childSizes))
+	for _, sz := range childSizes {
+		orderRequests = append(orderRequests, types.OrderRequest{
+			Coin:    coin,
+			IsBuy:   isBuy,
+			Sz:      sz,
+			LimitPx: limitPx,
+			OrderType: types.OrderType{
+				Limit: &types.LimitOrderType{Tif: tif},
+			},
+		})
+	}
+
+	return e.BulkOrders(orderRequests, nil)
 }
 
 // LimitOrder places a limit order
@@ -285,6 +842,71 @@ func (e *Exchange) LimitOrder(
 	return e.Order(name, isBuy, sz, limitPx, orderType, reduceOnly, cloid, nil)
 }
 
+// PostOnlyOrder places an add-liquidity-only (ALO) limit order
+// If the order would have crossed the book, the returned error matches utils.ErrPostOnlyWouldMatch
+// via errors.Is so callers can re-price and retry.
+func (e *Exchange) PostOnlyOrder(
+	name string,
+	isBuy bool,
+	sz float64,
+	px float64,
+	cloid *types.Cloid,
+) (map[string]interface{}, error) {
+	result, err := e.LimitOrder(name, isBuy, sz, px, types.TifAlo, false, cloid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckOrderResponse(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// PostOnlyOrderWithReprice places an ALO order and, if it would cross the book, reprices it one tick
+// inside the spread from the current book and retries, up to maxRetries times.
+// Returns the order result and the price of the final attempt.
+func (e *Exchange) PostOnlyOrderWithReprice(coin string, isBuy bool, sz float64, px float64, maxRetries int) (map[string]interface{}, float64, error) {
+	asset, err := e.nameToAsset(coin)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+	}
+
+	px = e.info.roundPrice(asset, px)
+	for attempt := 0; ; attempt++ {
+		result, err := e.PostOnlyOrder(coin, isBuy, sz, px, nil)
+		if err == nil || !errors.Is(err, utils.ErrPostOnlyWouldMatch) || attempt >= maxRetries {
+			return result, px, err
+		}
+
+		book, err := e.info.L2BookTyped(e.resolveName(coin), "")
+		if err != nil {
+			return nil, px, fmt.Errorf("failed to get book for reprice: %w", err)
+		}
+
+		// Rest one tick behind the opposite side's best level
+		side := book.Levels[1]
+		if !isBuy {
+			side = book.Levels[0]
+		}
+		if len(side) == 0 {
+			return nil, px, fmt.Errorf("no opposite side liquidity to reprice against for %s", coin)
+		}
+		best, err := strconv.ParseFloat(side[0].Px, 64)
+		if err != nil {
+			return nil, px, fmt.Errorf("failed to parse book price: %w", err)
+		}
+
+		tick := e.info.priceTick(asset, best)
+		if isBuy {
+			px = e.info.roundPrice(asset, best-tick)
+		} else {
+			px = e.info.roundPrice(asset, best+tick)
+		}
+	}
+}
+
 // TriggerOrder places a trigger order (stop loss or take profit)
 func (e *Exchange) TriggerOrder(
 	name string,
@@ -318,6 +940,173 @@ func (e *Exchange) TriggerOrder(
 	return e.Order(name, isBuy, sz, limitPx, orderType, reduceOnly, cloid, nil)
 }
 
+// PositionTpSl places take profit and/or stop loss market trigger orders for the whole position in coin
+// The orders are reduce-only, submitted with GroupingPositionTpsl and sized at sz, and close the
+// position's current direction. At least one of takeProfitPx and stopLossPx must be set.
+func (e *Exchange) PositionTpSl(coin string, takeProfitPx, stopLossPx *float64, sz float64) (map[string]interface{}, error) {
+	if takeProfitPx == nil && stopLossPx == nil {
+		return nil, fmt.Errorf("at least one of take profit or stop loss price is required")
+	}
+
+	state, err := e.UserState("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user state: %w", err)
+	}
+
+	var szi float64
+	found := false
+	for _, assetPosition := range state.AssetPositions {
+		if assetPosition.Position.Coin == coin {
+			szi, err = strconv.ParseFloat(assetPosition.Position.Szi, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse position size: %w", err)
+			}
+			found = true
+			break
+		}
+	}
+	if !found || szi == 0 {
+		return nil, fmt.Errorf("no open position for %s", coin)
+	}
+
+	// Closing orders trade against the position
+	isBuy := szi < 0
+
+	var orderRequests []types.OrderRequest
+	for _, leg := range []struct {
+		triggerPx *float64
+		tpsl      types.Tpsl
+	}{
+		{takeProfitPx, types.TpslTp},
+		{stopLossPx, types.TpslSl},
+	} {
+		if leg.triggerPx == nil {
+			continue
+		}
+
+		limitPx, err := e.slippagePrice(coin, isBuy, DefaultSlippage, leg.triggerPx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate trigger slippage price: %w", err)
+		}
+
+		orderRequests = append(orderRequests, types.OrderRequest{
+			Coin:    coin,
+			IsBuy:   isBuy,
+			Sz:      sz,
+			LimitPx: limitPx,
+			OrderType: types.OrderType{
+				Trigger: &types.TriggerOrderType{
+					TriggerPx: *leg.triggerPx,
+					IsMarket:  true,
+					Tpsl:      leg.tpsl,
+				},
+			},
+			ReduceOnly: true,
+		})
+	}
+
+	return e.bulkOrdersWithGrouping(orderRequests, nil, types.GroupingPositionTpsl)
+}
+
+// Replace cancels the resting order oid and places newOrder in its place
+// Unlike Modify this works across any change, e.g. of tif or order type. If the new order is
+// rejected after the cancel went through, the original order's remaining size is placed again
+// with its price, tif, reduce-only flag and cloid; it gets a new oid and loses queue priority.
+func (e *Exchange) Replace(oid int, newOrder types.OrderRequest) (*types.ReplaceResult, error) {
+	status, err := e.info.OrderStatusTyped(e.userAddress(), oid, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %d: %w", oid, err)
+	}
+	if !status.Found() || status.Order.Status != "open" {
+		return nil, fmt.Errorf("order %d is not open", oid)
+	}
+	original, err := orderRequestFromFrontend(status.Order.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.ReplaceResult{}
+
+	result.Cancel, err = e.Cancel(original.Coin, oid)
+	if err != nil {
+		return result, fmt.Errorf("failed to cancel order %d: %w", oid, err)
+	}
+	if err := utils.CheckCancelResponse(result.Cancel); err != nil {
+		return result, err
+	}
+
+	result.Order, err = e.BulkOrders([]types.OrderRequest{newOrder}, nil)
+	if err == nil {
+		err = utils.CheckOrderResponse(result.Order)
+	}
+	if err == nil {
+		return result, nil
+	}
+	placeErr := fmt.Errorf("failed to place replacement for order %d: %w", oid, err)
+
+	result.Rollback, err = e.BulkOrders([]types.OrderRequest{original}, nil)
+	if err == nil {
+		err = utils.CheckOrderResponse(result.Rollback)
+	}
+	if err != nil {
+		return result, errors.Join(placeErr, fmt.Errorf("failed to restore order %d: %w", oid, err))
+	}
+
+	return result, placeErr
+}
+
+// orderRequestFromFrontend rebuilds the order request for the remaining size of an open order
+func orderRequestFromFrontend(order types.FrontendOpenOrder) (types.OrderRequest, error) {
+	sz, err := strconv.ParseFloat(order.Sz, 64)
+	if err != nil {
+		return types.OrderRequest{}, fmt.Errorf("failed to parse order size: %w", err)
+	}
+	limitPx, err := strconv.ParseFloat(order.LimitPx, 64)
+	if err != nil {
+		return types.OrderRequest{}, fmt.Errorf("failed to parse order price: %w", err)
+	}
+
+	request := types.OrderRequest{
+		Coin:       order.Coin,
+		IsBuy:      order.Side == "B",
+		Sz:         sz,
+		LimitPx:    limitPx,
+		ReduceOnly: order.ReduceOnly,
+	}
+
+	if order.Cloid != nil {
+		cloid, err := types.NewCloid(*order.Cloid)
+		if err != nil {
+			return types.OrderRequest{}, err
+		}
+		request.Cloid = cloid
+	}
+
+	if order.IsTrigger && order.Tpsl != nil {
+		triggerPx, err := strconv.ParseFloat(order.TriggerPx, 64)
+		if err != nil {
+			return types.OrderRequest{}, fmt.Errorf("failed to parse trigger price: %w", err)
+		}
+		request.OrderType = types.OrderType{
+			Trigger: &types.TriggerOrderType{
+				TriggerPx: triggerPx,
+				IsMarket:  strings.Contains(order.OrderType, "Market"),
+				Tpsl:      *order.Tpsl,
+			},
+		}
+		return request, nil
+	}
+
+	// Frontend-only tifs such as FrontendMarket cannot be sent; rest the order instead
+	tif := types.TifGtc
+	if order.Tif != nil && (*order.Tif == types.TifAlo || *order.Tif == types.TifIoc) {
+		tif = *order.Tif
+	}
+	request.OrderType = types.OrderType{Limit: &types.LimitOrderType{Tif: tif}}
+
+	return request, nil
+}
+
 // Cancel cancels an order by order ID
 func (e *Exchange) Cancel(coin string, oid int) (map[string]interface{}, error) {
 	return e.BulkCancel([]types.CancelRequest{{Coin: coin, Oid: oid}})
@@ -328,7 +1117,7 @@ func (e *Exchange) BulkCancel(requests []types.CancelRequest) (map[string]interf
 	var cancels []map[string]interface{}
 
 	for _, req := range requests {
-		asset, err := e.info.NameToAsset(req.Coin)
+		asset, err := e.nameToAsset(req.Coin)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get asset for coin %s: %w", req.Coin, err)
 		}
@@ -339,7 +1128,7 @@ func (e *Exchange) BulkCancel(requests []types.CancelRequest) (map[string]interf
 		})
 	}
 
-	timestamp := utils.GetTimestampMS()
+	timestamp := e.timestampMS()
 
 	action := map[string]interface{}{
 		"type":    "cancel",
@@ -372,7 +1161,7 @@ func (e *Exchange) BulkCancelByCloid(requests []types.CancelByCloidRequest) (map
 	var cancels []map[string]interface{}
 
 	for _, req := range requests {
-		asset, err := e.info.NameToAsset(req.Coin)
+		asset, err := e.nameToAsset(req.Coin)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get asset for coin %s: %w", req.Coin, err)
 		}
@@ -383,11 +1172,228 @@ func (e *Exchange) BulkCancelByCloid(requests []types.CancelByCloidRequest) (map
 		})
 	}
 
-	timestamp := utils.GetTimestampMS()
+	timestamp := e.timestampMS()
+
+	action := map[string]interface{}{
+		"type":    "cancelByCloid",
+		"cancels": cancels,
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign cancel by cloid action: %w", err)
+	}
+
+	return e.postAction(action, signature, timestamp)
+}
+
+// Modify modifies an existing order
+func (e *Exchange) Modify(oid int, orderRequest types.OrderRequest) (map[string]interface{}, error) {
+	asset, err := e.nameToAsset(orderRequest.Coin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset for coin %s: %w", orderRequest.Coin, err)
+	}
+
+	orderWire, err := utils.OrderRequestToOrderWire(orderRequest, asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert order to wire format: %w", err)
+	}
+
+	timestamp := e.timestampMS()
+
+	// Convert OrderWire to map for proper JSON serialization
+	orderMap := map[string]interface{}{
+		"a": orderWire.A,
+		"b": orderWire.B,
+		"p": orderWire.P,
+		"s": orderWire.S,
+		"r": orderWire.R,
+		"t": utils.ConvertOrderTypeWireToMap(orderWire.T),
+	}
+	if orderWire.C != nil {
+		orderMap["c"] = *orderWire.C
+	}
+
+	action := map[string]interface{}{
+		"type": "modify",
+		"modifies": []map[string]interface{}{
+			{
+				"oid":   oid,
+				"order": orderMap,
+			},
+		},
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign modify action: %w", err)
+	}
+
+	return e.postAction(action, signature, timestamp)
+}
+
+// minScheduleCancelDelay is how far in the future a scheduled cancel must be
+const minScheduleCancelDelay = 5 * time.Second
+
+// ScheduleCancel schedules a cancel of all open orders at time (unix milliseconds)
+// The time must be at least 5 seconds in the future. Passing nil removes the scheduled cancel.
+func (e *Exchange) ScheduleCancel(cancelTime *int64) (map[string]interface{}, error) {
+	timestamp := e.timestampMS()
+
+	if cancelTime != nil && *cancelTime < timestamp+minScheduleCancelDelay.Milliseconds() {
+		return nil, fmt.Errorf("scheduled cancel time must be at least %s in the future", minScheduleCancelDelay)
+	}
+
+	action := map[string]interface{}{
+		"type": "scheduleCancel",
+	}
+	if cancelTime != nil {
+		action["time"] = *cancelTime
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign schedule cancel action: %w", err)
+	}
+
+	result, err := e.postAction(action, signature, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, utils.CheckActionResult("scheduleCancel", result)
+}
+
+// ClearScheduledCancel removes any scheduled cancel by sending scheduleCancel without a time
+func (e *Exchange) ClearScheduledCancel() (map[string]interface{}, error) {
+	return e.ScheduleCancel(nil)
+}
+
+// TwapCancel cancels a running TWAP order on coin
+// Returns an error unwrapping to utils.ErrTwapNotRunning if the TWAP already finished or was canceled.
+func (e *Exchange) TwapCancel(coin string, twapId int) (map[string]interface{}, error) {
+	asset, err := e.nameToAsset(coin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+	}
+
+	timestamp := e.timestampMS()
+
+	action := map[string]interface{}{
+		"type": "twapCancel",
+		"a":    asset,
+		"t":    twapId,
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign twap cancel action: %w", err)
+	}
+
+	result, err := e.postAction(action, signature, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, utils.CheckTwapCancelResponse(result)
+}
+
+// CancelAll cancels all open orders
+func (e *Exchange) CancelAll() (map[string]interface{}, error) {
+	timestamp := e.timestampMS()
+
+	action := map[string]interface{}{
+		"type": "cancelAll",
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign cancel all action: %w", err)
+	}
+
+	return e.postAction(action, signature, timestamp)
+}
+
+// UpdateLeverage updates the leverage for a coin
+func (e *Exchange) UpdateLeverage(coin string, isCross bool, leverage int) (map[string]interface{}, error) {
+	asset, err := e.nameToAsset(coin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+	}
+
+	timestamp := e.timestampMS()
+
+	action := map[string]interface{}{
+		"type":     "updateLeverage",
+		"asset":    asset,
+		"isCross":  isCross,
+		"leverage": leverage,
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign update leverage action: %w", err)
+	}
+
+	return e.postAction(action, signature, timestamp)
+}
+
+// UpdateIsolatedMargin updates the isolated margin for a coin
+func (e *Exchange) UpdateIsolatedMargin(coin string, isBuy bool, ntli int64) (map[string]interface{}, error) {
+	asset, err := e.nameToAsset(coin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+	}
+
+	timestamp := e.timestampMS()
 
 	action := map[string]interface{}{
-		"type":    "cancelByCloid",
-		"cancels": cancels,
+		"type":  "updateIsolatedMargin",
+		"asset": asset,
+		"isBuy": isBuy,
+		"ntli":  ntli,
 	}
 
 	signature, err := utils.SignL1Action(
@@ -399,47 +1405,129 @@ func (e *Exchange) BulkCancelByCloid(requests []types.CancelByCloidRequest) (map
 		e.IsMainnet(),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign cancel by cloid action: %w", err)
+		return nil, fmt.Errorf("failed to sign update isolated margin action: %w", err)
 	}
 
 	return e.postAction(action, signature, timestamp)
 }
 
-// Modify modifies an existing order
-func (e *Exchange) Modify(oid int, orderRequest types.OrderRequest) (map[string]interface{}, error) {
-	asset, err := e.info.NameToAsset(orderRequest.Coin)
+// OpenIsolated places a GTC limit order for an isolated position that commits about margin USDC
+// The leverage is set to the lowest whole leverage at which sz at px needs no more than margin,
+// so the committed margin is at most margin. Fails before signing if that leverage exceeds the
+// coin's max leverage for the notional.
+func (e *Exchange) OpenIsolated(coin string, isBuy bool, sz float64, px float64, margin float64) (map[string]interface{}, error) {
+	name, exists := e.coinForName(coin)
+	if !exists {
+		return nil, fmt.Errorf("coin not found: %s", coin)
+	}
+
+	notional := sz * px
+	leverage, err := utils.IsolatedLeverageForMargin(notional, margin)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get asset for coin %s: %w", orderRequest.Coin, err)
+		return nil, utils.NewValidationError("margin", err.Error())
 	}
 
-	orderWire, err := utils.OrderRequestToOrderWire(orderRequest, asset)
+	maxLeverage, err := e.info.MaxLeverage(name, notional)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert order to wire format: %w", err)
+		return nil, err
+	}
+	if maxLeverage > 0 && leverage > maxLeverage {
+		minMargin := notional / float64(maxLeverage)
+		return nil, utils.NewValidationError("margin", fmt.Sprintf("%.2f is too little for a %.2f notional %s position at max leverage %dx; need at least %.2f", margin, notional, name, maxLeverage, minMargin))
 	}
 
-	timestamp := utils.GetTimestampMS()
+	result, err := e.UpdateLeverage(coin, false, leverage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set isolated leverage: %w", err)
+	}
+	if err := utils.CheckActionResult("updateLeverage", result); err != nil {
+		return nil, err
+	}
 
-	// Convert OrderWire to map for proper JSON serialization
-	orderMap := map[string]interface{}{
-		"a": orderWire.A,
-		"b": orderWire.B,
-		"p": orderWire.P,
-		"s": orderWire.S,
-		"r": orderWire.R,
-		"t": utils.ConvertOrderTypeWireToMap(orderWire.T),
+	orderType := types.OrderType{
+		Limit: &types.LimitOrderType{Tif: types.TifGtc},
 	}
-	if orderWire.C != nil {
-		orderMap["c"] = *orderWire.C
+
+	return e.Order(coin, isBuy, sz, px, orderType, false, nil, nil)
+}
+
+// AddIsolatedMargin adds usd of margin to an isolated position
+func (e *Exchange) AddIsolatedMargin(coin string, usd float64) (map[string]interface{}, error) {
+	if usd <= 0 {
+		return nil, fmt.Errorf("margin to add must be positive: %f", usd)
+	}
+
+	ntli, err := utils.FloatToUSDInt(usd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert margin amount: %w", err)
+	}
+
+	// The sign of ntli selects add vs remove; isBuy is always true (matches Python reference)
+	return e.UpdateIsolatedMargin(coin, true, ntli)
+}
+
+// RemoveIsolatedMargin removes usd of margin from an isolated position
+// Fails without sending if usd exceeds the margin currently allocated to the position
+func (e *Exchange) RemoveIsolatedMargin(coin string, usd float64) (map[string]interface{}, error) {
+	if usd <= 0 {
+		return nil, fmt.Errorf("margin to remove must be positive: %f", usd)
+	}
+
+	ntli, err := utils.FloatToUSDInt(usd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert margin amount: %w", err)
+	}
+
+	state, err := e.info.UserStateTyped(e.userAddress(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user state: %w", err)
+	}
+
+	var position *types.Position
+	for idx := range state.AssetPositions {
+		if state.AssetPositions[idx].Position.Coin == coin {
+			position = &state.AssetPositions[idx].Position
+			break
+		}
+	}
+	if position == nil {
+		return nil, fmt.Errorf("no open position for %s", coin)
+	}
+	if !position.Leverage.IsIsolated() {
+		return nil, fmt.Errorf("position for %s is not isolated", coin)
+	}
+
+	marginUsed, err := strconv.ParseFloat(position.MarginUsed, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse margin used: %w", err)
+	}
+	if usd > marginUsed {
+		return nil, fmt.Errorf("cannot remove %f margin from %s: only %f allocated", usd, coin, marginUsed)
+	}
+
+	return e.UpdateIsolatedMargin(coin, true, -ntli)
+}
+
+// maxDisplayNameLength is the longest display name the exchange accepts
+const maxDisplayNameLength = 20
+
+// SetDisplayName sets the account display name shown on the leaderboard
+// An empty name clears the current display name
+func (e *Exchange) SetDisplayName(name string) (map[string]interface{}, error) {
+	if len(name) > maxDisplayNameLength {
+		return nil, fmt.Errorf("display name must be at most %d characters, got %d", maxDisplayNameLength, len(name))
+	}
+	for _, r := range name {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("display name must contain only printable ASCII characters, got %q", r)
+		}
 	}
 
+	timestamp := e.timestampMS()
+
 	action := map[string]interface{}{
-		"type": "modify",
-		"modifies": []map[string]interface{}{
-			{
-				"oid":   oid,
-				"order": orderMap,
-			},
-		},
+		"type":        "setDisplayName",
+		"displayName": name,
 	}
 
 	signature, err := utils.SignL1Action(
@@ -451,18 +1539,20 @@ func (e *Exchange) Modify(oid int, orderRequest types.OrderRequest) (map[string]
 		e.IsMainnet(),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign modify action: %w", err)
+		return nil, fmt.Errorf("failed to sign set display name action: %w", err)
 	}
 
 	return e.postAction(action, signature, timestamp)
 }
 
-// CancelAll cancels all open orders
-func (e *Exchange) CancelAll() (map[string]interface{}, error) {
-	timestamp := utils.GetTimestampMS()
+// Noop submits a signed action that does nothing but consume a nonce
+// Useful to check connectivity and that the exchange accepts the signer, or to invalidate
+// an in-flight action signed with an earlier nonce.
+func (e *Exchange) Noop() (map[string]interface{}, error) {
+	timestamp := e.timestampMS()
 
 	action := map[string]interface{}{
-		"type": "cancelAll",
+		"type": "noop",
 	}
 
 	signature, err := utils.SignL1Action(
@@ -474,26 +1564,32 @@ func (e *Exchange) CancelAll() (map[string]interface{}, error) {
 		e.IsMainnet(),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign cancel all action: %w", err)
+		return nil, fmt.Errorf("failed to sign noop action: %w", err)
 	}
 
 	return e.postAction(action, signature, timestamp)
 }
 
-// UpdateLeverage updates the leverage for a coin
-func (e *Exchange) UpdateLeverage(coin string, isCross bool, leverage int) (map[string]interface{}, error) {
-	asset, err := e.info.NameToAsset(coin)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
+// maxReferralCodeLength is the longest referral code the exchange accepts
+const maxReferralCodeLength = 20
+
+// CreateReferralCode registers code as the account's own referral code
+// Codes must be 1 to 20 ASCII letters or digits.
+func (e *Exchange) CreateReferralCode(code string) (map[string]interface{}, error) {
+	if len(code) == 0 || len(code) > maxReferralCodeLength {
+		return nil, utils.NewValidationError("code", fmt.Sprintf("must be 1 to %d characters, got %d", maxReferralCodeLength, len(code)))
+	}
+	for _, r := range code {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return nil, utils.NewValidationError("code", fmt.Sprintf("must contain only letters and digits, got %q", r))
+		}
 	}
 
-	timestamp := utils.GetTimestampMS()
+	timestamp := e.timestampMS()
 
 	action := map[string]interface{}{
-		"type":     "updateLeverage",
-		"asset":    asset,
-		"isCross":  isCross,
-		"leverage": leverage,
+		"type": "registerReferrer",
+		"code": code,
 	}
 
 	signature, err := utils.SignL1Action(
@@ -505,26 +1601,20 @@ func (e *Exchange) UpdateLeverage(coin string, isCross bool, leverage int) (map[
 		e.IsMainnet(),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign update leverage action: %w", err)
+		return nil, fmt.Errorf("failed to sign register referrer action: %w", err)
 	}
 
 	return e.postAction(action, signature, timestamp)
 }
 
-// UpdateIsolatedMargin updates the isolated margin for a coin
-func (e *Exchange) UpdateIsolatedMargin(coin string, isBuy bool, ntli int64) (map[string]interface{}, error) {
-	asset, err := e.info.NameToAsset(coin)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get asset for coin %s: %w", coin, err)
-	}
-
-	timestamp := utils.GetTimestampMS()
+// EvmUserModify toggles whether the account's HyperEVM transactions go into big blocks
+// Big blocks allow larger transactions such as contract deployments, but are produced less often.
+func (e *Exchange) EvmUserModify(usingBigBlocks bool) (map[string]interface{}, error) {
+	timestamp := e.timestampMS()
 
 	action := map[string]interface{}{
-		"type":  "updateIsolatedMargin",
-		"asset": asset,
-		"isBuy": isBuy,
-		"ntli":  ntli,
+		"type":           "evmUserModify",
+		"usingBigBlocks": usingBigBlocks,
 	}
 
 	signature, err := utils.SignL1Action(
@@ -536,7 +1626,7 @@ func (e *Exchange) UpdateIsolatedMargin(coin string, isBuy bool, ntli int64) (ma
 		e.IsMainnet(),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign update isolated margin action: %w", err)
+		return nil, fmt.Errorf("failed to sign evm user modify action: %w", err)
 	}
 
 	return e.postAction(action, signature, timestamp)
@@ -544,7 +1634,11 @@ func (e *Exchange) UpdateIsolatedMargin(coin string, isBuy bool, ntli int64) (ma
 
 // UsdTransfer transfers USD to another address
 func (e *Exchange) UsdTransfer(destination string, amount string) (map[string]interface{}, error) {
-	timestamp := utils.GetTimestampMS()
+	if err := utils.ValidateDecimalAmount(amount, utils.USDDecimals); err != nil {
+		return nil, err
+	}
+
+	timestamp := e.timestampMS()
 
 	// Create action for signing (without type field)
 	signAction := map[string]interface{}{
@@ -567,12 +1661,36 @@ func (e *Exchange) UsdTransfer(destination string, amount string) (map[string]in
 		"signature":   signature,
 	}
 
-	return e.Post("/exchange", payload)
+	result, err := e.postExchange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult("usdSend", result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateTokenAmount checks amount against the precision of a spot token (name or "NAME:tokenId")
+func (e *Exchange) validateTokenAmount(token string, amount string) error {
+	weiDecimals, err := e.info.tokenWeiDecimals(token)
+	if err != nil {
+		return err
+	}
+
+	return utils.ValidateDecimalAmount(amount, weiDecimals)
 }
 
 // SpotTransfer transfers spot assets to another address
+// amount may have at most as many decimals as the token's weiDecimals.
 func (e *Exchange) SpotTransfer(destination string, token string, amount string) (map[string]interface{}, error) {
-	timestamp := utils.GetTimestampMS()
+	if err := e.validateTokenAmount(token, amount); err != nil {
+		return nil, err
+	}
+
+	timestamp := e.timestampMS()
 
 	// Create action for signing (EIP712 expects time as string)
 	signAction := map[string]interface{}{
@@ -597,12 +1715,104 @@ func (e *Exchange) SpotTransfer(destination string, token string, amount string)
 		"signature":   signature,
 	}
 
-	return e.Post("/exchange", payload)
+	result, err := e.postExchange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult("spotSend", result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SpotTransferFloat transfers a float amount of a spot token, converting it with the token's weiDecimals
+// Fails without sending if the amount is more precise than the token supports
+func (e *Exchange) SpotTransferFloat(destination string, token string, amount float64) (map[string]interface{}, error) {
+	weiDecimals, err := e.info.tokenWeiDecimals(token)
+	if err != nil {
+		return nil, err
+	}
+
+	amountStr, err := utils.TokenAmountToString(amount, weiDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert amount: %w", err)
+	}
+
+	return e.SpotTransfer(destination, token, amountStr)
+}
+
+// UsdClassTransfer moves USDC between the perp and spot balances (toPerp true moves spot to perp)
+// For a vault-acting exchange the vault is the source account. The action is sent without a
+// vaultAddress unless WithVault is given.
+func (e *Exchange) UsdClassTransfer(amount string, toPerp bool, opts ...TransferOption) (map[string]interface{}, error) {
+	if err := utils.ValidateDecimalAmount(amount, utils.USDDecimals); err != nil {
+		return nil, err
+	}
+
+	nonce := e.timestampMS()
+
+	if e.vaultAddress != nil {
+		amount += " subaccount:" + *e.vaultAddress
+	}
+
+	action := map[string]interface{}{
+		"type":   "usdClassTransfer",
+		"amount": amount,
+		"toPerp": toPerp,
+		"nonce":  nonce,
+	}
+
+	signature, err := utils.SignUSDClassTransferAction(e.privateKey, action, e.IsMainnet())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign usd class transfer action: %w", err)
+	}
+
+	return e.postTransfer(action, signature, nonce, opts)
+}
+
+// SendAsset sends a token between dexes and accounts ("" is the perp dex, "spot" the spot balance)
+// For a vault-acting exchange the vault is the source account. The action is sent without a
+// vaultAddress unless WithVault is given.
+func (e *Exchange) SendAsset(destination string, sourceDex string, destinationDex string, token string, amount string, opts ...TransferOption) (map[string]interface{}, error) {
+	if err := e.validateTokenAmount(token, amount); err != nil {
+		return nil, err
+	}
+
+	nonce := e.timestampMS()
+
+	fromSubAccount := ""
+	if e.vaultAddress != nil {
+		fromSubAccount = *e.vaultAddress
+	}
+
+	action := map[string]interface{}{
+		"type":           "sendAsset",
+		"destination":    strings.ToLower(destination),
+		"sourceDex":      sourceDex,
+		"destinationDex": destinationDex,
+		"token":          token,
+		"amount":         amount,
+		"fromSubAccount": fromSubAccount,
+		"nonce":          nonce,
+	}
+
+	signature, err := utils.SignSendAssetAction(e.privateKey, action, e.IsMainnet())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign send asset action: %w", err)
+	}
+
+	return e.postTransfer(action, signature, nonce, opts)
 }
 
 // WithdrawFromBridge withdraws assets from the bridge
 func (e *Exchange) WithdrawFromBridge(destination string, amount string) (map[string]interface{}, error) {
-	timestamp := utils.GetTimestampMS()
+	if err := utils.ValidateDecimalAmount(amount, utils.USDDecimals); err != nil {
+		return nil, err
+	}
+
+	timestamp := e.timestampMS()
 
 	// Create action for signing (EIP712 expects time as string)
 	signAction := map[string]interface{}{
@@ -625,7 +1835,169 @@ func (e *Exchange) WithdrawFromBridge(destination string, amount string) (map[st
 		"signature":   signature,
 	}
 
-	return e.Post("/exchange", payload)
+	result, err := e.postExchange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult("withdraw", result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimReferralRewards claims the account's unclaimed referral rewards and returns the amount claimed
+// The claim response carries no amount, so claimed is the increase in the referral state's
+// claimedRewards across the claim, which stays correct if rewards accrue meanwhile.
+// claimRewards is not part of the documented API; an accepted claim that leaves claimedRewards
+// unchanged is reported as an error rather than as a zero claim.
+func (e *Exchange) ClaimReferralRewards() (result map[string]interface{}, claimed float64, err error) {
+	user := e.userAddress()
+
+	before, err := e.info.Referral(user)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get referral state: %w", err)
+	}
+	unclaimed, err := before.UnclaimedRewardsFloat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if unclaimed <= 0 {
+		return nil, 0, utils.NewValidationError("unclaimedRewards", "no referral rewards to claim")
+	}
+
+	timestamp := e.timestampMS()
+
+	action := map[string]interface{}{
+		"type": "claimRewards",
+	}
+
+	signature, err := utils.SignL1Action(
+		e.privateKey,
+		action,
+		e.vaultAddress,
+		timestamp,
+		e.expiresAfter,
+		e.IsMainnet(),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to sign claim rewards action: %w", err)
+	}
+
+	result, err = e.postAction(action, signature, timestamp)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := utils.CheckActionResult("claimRewards", result); err != nil {
+		return result, 0, err
+	}
+
+	after, err := e.info.Referral(user)
+	if err != nil {
+		return result, 0, fmt.Errorf("claim accepted but failed to get referral state: %w", err)
+	}
+	claimedBefore, err := before.ClaimedRewardsFloat()
+	if err != nil {
+		return result, 0, err
+	}
+	claimedAfter, err := after.ClaimedRewardsFloat()
+	if err != nil {
+		return result, 0, err
+	}
+
+	claimed = claimedAfter - claimedBefore
+	if claimed <= 0 {
+		return result, 0, fmt.Errorf("claim accepted but claimed referral rewards did not increase")
+	}
+
+	return result, claimed, nil
+}
+
+// ApproveBuilderFee approves builder to charge fees up to maxFeeRate, a percentage such as "0.001%"
+// Must be signed by the account's own key, not an agent.
+func (e *Exchange) ApproveBuilderFee(builder string, maxFeeRate string) (map[string]interface{}, error) {
+	if _, err := utils.ParseBuilderFeeRate(maxFeeRate); err != nil {
+		return nil, err
+	}
+
+	nonce := e.timestampMS()
+
+	// Create action for signing (without type field)
+	signAction := map[string]interface{}{
+		"maxFeeRate": maxFeeRate,
+		"builder":    strings.ToLower(builder),
+		"nonce":      fmt.Sprintf("%d", nonce), // String for EIP712
+	}
+
+	signature, err := utils.SignApproveBuilderFee(e.privateKey, signAction, e.IsMainnet())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign approve builder fee action: %w", err)
+	}
+
+	// Send direct payload without wrapper (user-signed actions don't use postAction wrapper)
+	payload := map[string]interface{}{
+		"type":       "approveBuilderFee",
+		"maxFeeRate": maxFeeRate,
+		"builder":    strings.ToLower(builder),
+		"nonce":      nonce, // int64 for API
+		"signature":  signature,
+	}
+
+	result, err := e.postExchange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult("approveBuilderFee", result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ApproveBuilderFeeAndOrder places an order with a builder fee of feeTenthsBps, first approving
+// builder up to maxFeeRate if the account's current approval doesn't cover the fee
+func (e *Exchange) ApproveBuilderFeeAndOrder(builder string, maxFeeRate string, req types.OrderRequest, feeTenthsBps int) (map[string]interface{}, error) {
+	maxFee, err := utils.ParseBuilderFeeRate(maxFeeRate)
+	if err != nil {
+		return nil, err
+	}
+	if feeTenthsBps > maxFee {
+		return nil, fmt.Errorf("builder fee %d exceeds max fee rate %s", feeTenthsBps, maxFeeRate)
+	}
+
+	approved, err := e.info.MaxBuilderFee(e.userAddress(), builder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approved builder fee: %w", err)
+	}
+
+	if approved < feeTenthsBps {
+		if _, err := e.ApproveBuilderFee(builder, maxFeeRate); err != nil {
+			return nil, fmt.Errorf("failed to approve builder fee: %w", err)
+		}
+	}
+
+	return e.BulkOrders([]types.OrderRequest{req}, &types.BuilderInfo{B: builder, F: feeTenthsBps})
+}
+
+// IsAgentValid reports whether the signing key is still authorized for the account
+// When the exchange signs with its own account key it is always valid; in agent mode the
+// agent must appear in the account's extraAgents with a validUntil in the future of the
+// exchange's clock.
+func (e *Exchange) IsAgentValid() (bool, error) {
+	signer := utils.GetAddressFromPrivateKey(e.privateKey)
+	if e.accountAddress == nil || strings.EqualFold(*e.accountAddress, signer) {
+		return true, nil
+	}
+
+	_, validUntil, err := e.info.IsAgentApproved(*e.accountAddress, signer)
+	if err != nil {
+		return false, err
+	}
+
+	return validUntil > e.timestampMS(), nil
 }
 
 // ApproveAgentResult represents the result of approving an agent
@@ -657,7 +2029,7 @@ func (e *Exchange) ApproveAgent(agentName ...string) (*ApproveAgentResult, error
 	}
 
 	// Get nonce
-	nonce := utils.GetTimestampMS()
+	nonce := e.timestampMS()
 
 	// Create action for signing (without type field)
 	signAction := map[string]interface{}{
@@ -685,7 +2057,7 @@ func (e *Exchange) ApproveAgent(agentName ...string) (*ApproveAgentResult, error
 		payload["agentName"] = name
 	}
 
-	result, err := e.Post("/exchange", payload)
+	result, err := e.postExchange(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to approve agent: %w", err)
 	}
@@ -693,6 +2065,48 @@ func (e *Exchange) ApproveAgent(agentName ...string) (*ApproveAgentResult, error
 	// Return both the result and the agent's private key
 	return &ApproveAgentResult{
 		Result:   result,
-		AgentKey: fmt.Sprintf("%#x", agentPrivateKey.D),
+		AgentKey: utils.FormatPrivateKey(agentPrivateKey),
+	}, nil
+}
+
+// NewAgentExchange approves a new agent and returns an Exchange that signs with the agent key
+// while acting on this exchange's account. The returned client shares this client's Info.
+func (e *Exchange) NewAgentExchange(agentName ...string) (*Exchange, error) {
+	approval, err := e.ApproveAgent(agentName...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckActionResult("approveAgent", approval.Result); err != nil {
+		return nil, err
+	}
+
+	agentKey, err := utils.ParsePrivateKey(approval.AgentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent key: %w", err)
+	}
+
+	accountAddress := utils.GetAddressFromPrivateKey(e.privateKey)
+	if e.accountAddress != nil {
+		accountAddress = *e.accountAddress
+	}
+
+	return &Exchange{
+		API:            e.API,
+		privateKey:     agentKey,
+		vaultAddress:   e.vaultAddress,
+		accountAddress: &accountAddress,
+		info:           e.info,
+		expiresAfter:   e.expiresAfter,
+		debugWriter:    e.debugWriter,
+		redactDebug:    e.redactDebug,
+		checkTrading:   e.checkTrading,
+		checkSigner:    e.checkSigner,
+		isAgent:        true,
+		clock:          e.clock,
+		isFrontend:     e.isFrontend,
+		checkBuilder:   e.checkBuilder,
+		normalizeNames: e.normalizeNames,
+		minNotional:    e.minNotional,
 	}, nil
 }
@@ -0,0 +1,1640 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"hyperliquid-go-sdk/pkg/types"
+	"hyperliquid-go-sdk/pkg/utils"
+)
+
+func TestBulkOrdersSignsAndPostsOrders(t *testing.T) {
+	server := newMockServer(t)
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(
+			map[string]interface{}{"resting": map[string]interface{}{"oid": 11}},
+			map[string]interface{}{"resting": map[string]interface{}{"oid": 12}},
+		)
+	})
+	exchange := newTestExchange(t, server)
+
+	cloid := types.NewCloidFromInt(7)
+	result, err := exchange.BulkOrders([]types.OrderRequest{
+		{
+			Coin:      "ETH",
+			IsBuy:     true,
+			Sz:        0.1,
+			LimitPx:   2000,
+			OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+			Cloid:     cloid,
+		},
+		{
+			Coin:       "BTC",
+			IsBuy:      false,
+			Sz:         0.01,
+			LimitPx:    60000,
+			OrderType:  types.OrderType{Trigger: &types.TriggerOrderType{IsMarket: true, TriggerPx: 61000, Tpsl: types.TpslSl}},
+			ReduceOnly: true,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkOrders: %v", err)
+	}
+	if result["status"] != "ok" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	payload := server.lastExchangePayload()
+	action := payload["action"]
+	if got := jsonField(t, action, "type"); got != "order" {
+		t.Fatalf("action type = %v, want order", got)
+	}
+	if got := jsonField(t, action, "grouping"); got != "na" {
+		t.Fatalf("grouping = %v, want na", got)
+	}
+
+	eth := jsonField(t, action, "orders", 0)
+	for key, want := range map[string]interface{}{"a": 0.0, "b": true, "p": "2000", "s": "0.1", "r": false, "c": cloid.ToRaw()} {
+		if got := jsonField(t, eth, key); got != want {
+			t.Errorf("ETH order %s = %v, want %v", key, got, want)
+		}
+	}
+	if got := jsonField(t, eth, "t", "limit", "tif"); got != "Gtc" {
+		t.Errorf("ETH order tif = %v, want Gtc", got)
+	}
+
+	btc := jsonField(t, action, "orders", 1)
+	for key, want := range map[string]interface{}{"a": 1.0, "b": false, "p": "60000", "s": "0.01", "r": true} {
+		if got := jsonField(t, btc, key); got != want {
+			t.Errorf("BTC order %s = %v, want %v", key, got, want)
+		}
+	}
+	trigger := jsonField(t, btc, "t", "trigger")
+	for key, want := range map[string]interface{}{"isMarket": true, "triggerPx": "61000", "tpsl": "sl"} {
+		if got := jsonField(t, trigger, key); got != want {
+			t.Errorf("BTC trigger %s = %v, want %v", key, got, want)
+		}
+	}
+
+	if _, ok := payload["nonce"].(float64); !ok {
+		t.Fatalf("payload nonce missing: %v", payload)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+func TestCancelSignsAndPostsCancel(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.Cancel("BTC", 42); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	action := payload["action"]
+	if got := jsonField(t, action, "type"); got != "cancel" {
+		t.Fatalf("action type = %v, want cancel", got)
+	}
+	if got := jsonField(t, action, "cancels", 0, "a"); got != 1.0 {
+		t.Errorf("cancel asset = %v, want 1", got)
+	}
+	if got := jsonField(t, action, "cancels", 0, "o"); got != 42.0 {
+		t.Errorf("cancel oid = %v, want 42", got)
+	}
+
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+func TestModifySignsAndPostsModify(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	_, err := exchange.Modify(42, types.OrderRequest{
+		Coin:      "ETH",
+		IsBuy:     false,
+		Sz:        0.25,
+		LimitPx:   2100.5,
+		OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifAlo}},
+	})
+	if err != nil {
+		t.Fatalf("Modify: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	action := payload["action"]
+	if got := jsonField(t, action, "type"); got != "modify" {
+		t.Fatalf("action type = %v, want modify", got)
+	}
+	if got := jsonField(t, action, "modifies", 0, "oid"); got != 42.0 {
+		t.Errorf("modify oid = %v, want 42", got)
+	}
+
+	order := jsonField(t, action, "modifies", 0, "order")
+	for key, want := range map[string]interface{}{"a": 0.0, "b": false, "p": "2100.5", "s": "0.25", "r": false} {
+		if got := jsonField(t, order, key); got != want {
+			t.Errorf("modified order %s = %v, want %v", key, got, want)
+		}
+	}
+	if got := jsonField(t, order, "t", "limit", "tif"); got != "Alo" {
+		t.Errorf("modified order tif = %v, want Alo", got)
+	}
+	if _, ok := order.(map[string]interface{})["c"]; ok {
+		t.Errorf("modified order has a cloid: %v", order)
+	}
+
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+// fixedClock is a utils.Clock that always returns the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestPackageClockDrivesNonceAndExpiry(t *testing.T) {
+	utils.SetClock(fixedClock(time.UnixMilli(1700000000000)))
+	t.Cleanup(func() { utils.SetClock(nil) })
+
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+	expiresAfter := utils.GetTimestampMS() + 30_000
+	exchange.SetExpiresAfter(&expiresAfter)
+
+	if _, err := exchange.Cancel("ETH", 1); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	if got := payload["nonce"]; got != 1700000000000.0 {
+		t.Fatalf("nonce = %v, want 1700000000000", got)
+	}
+	if got := payload["expiresAfter"]; got != 1700000030000.0 {
+		t.Fatalf("expiresAfter = %v, want 1700000030000", got)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+func TestOrderSlicedSubmitsChildOrders(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.OrderSliced("ETH", true, 0.35, 2000, types.TifGtc, 0.1); err != nil {
+		t.Fatalf("OrderSliced: %v", err)
+	}
+
+	requests := server.recorded("/exchange")
+	if len(requests) != 1 {
+		t.Fatalf("got %d /exchange requests, want a single batch", len(requests))
+	}
+	orders, _ := jsonField(t, requests[0].Payload, "action", "orders").([]interface{})
+	wantSizes := []string{"0.1", "0.1", "0.1", "0.05"}
+	if len(orders) != len(wantSizes) {
+		t.Fatalf("got %d child orders, want %d", len(orders), len(wantSizes))
+	}
+	for i, want := range wantSizes {
+		if got := jsonField(t, orders[i], "s"); got != want {
+			t.Errorf("child order %d size = %v, want %s", i, got, want)
+		}
+		if got := jsonField(t, orders[i], "p"); got != "2000" {
+			t.Errorf("child order %d price = %v, want 2000", i, got)
+		}
+	}
+
+	// Without size decimals the split cannot be rounded, so nothing is sent
+	asset, err := exchange.nameToAsset("ETH")
+	if err != nil {
+		t.Fatalf("nameToAsset: %v", err)
+	}
+	exchange.info.metaMutex.Lock()
+	delete(exchange.info.assetToSzDecimals, asset)
+	exchange.info.metaMutex.Unlock()
+	if _, err := exchange.OrderSliced("ETH", true, 0.35, 2000, types.TifGtc, 0.1); err == nil || !strings.Contains(err.Error(), "size decimals") {
+		t.Fatalf("expected a size decimals error, got %v", err)
+	}
+	if got := len(server.recorded("/exchange")); got != 1 {
+		t.Fatalf("got %d /exchange requests after the failed split, want 1", got)
+	}
+}
+
+func TestUsdTransferResults(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.UsdTransfer("0x0000000000000000000000000000000000000001", "10"); err != nil {
+		t.Fatalf("UsdTransfer: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	if payload["type"] != "usdSend" || payload["amount"] != "10" {
+		t.Fatalf("unexpected usdSend payload: %v", payload)
+	}
+
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"status": "err", "response": "Insufficient balance for transfer"}
+	})
+	_, err := exchange.UsdTransfer("0x0000000000000000000000000000000000000001", "1000000")
+	var exchangeErr *utils.ExchangeError
+	if !errors.As(err, &exchangeErr) {
+		t.Fatalf("expected an ExchangeError, got %v", err)
+	}
+	if exchangeErr.Action != "usdSend" || exchangeErr.Message != "Insufficient balance for transfer" {
+		t.Fatalf("unexpected error: %+v", exchangeErr)
+	}
+}
+
+func TestWithdrawFromBridgeRejected(t *testing.T) {
+	server := newMockServer(t)
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"status": "err", "response": "Insufficient balance for withdrawal"}
+	})
+	exchange := newTestExchange(t, server)
+
+	_, err := exchange.WithdrawFromBridge("0x0000000000000000000000000000000000000001", "5")
+	var exchangeErr *utils.ExchangeError
+	if !errors.As(err, &exchangeErr) || exchangeErr.Action != "withdraw" {
+		t.Fatalf("expected a withdraw ExchangeError, got %v", err)
+	}
+}
+
+func TestAddAndRemoveIsolatedMargin(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("clearinghouseState", clearinghouseStateFixture("1000", "150",
+		positionFixture("ETH", "1", map[string]interface{}{"type": "isolated", "value": 10, "rawUsd": "-1850"}, "150"),
+		positionFixture("BTC", "0.01", map[string]interface{}{"type": "cross", "value": 20}, "30"),
+	))
+	exchange := newTestExchange(t, server)
+	signer := utils.GetAddressFromPrivateKey(testKey(t))
+
+	if _, err := exchange.AddIsolatedMargin("ETH", 25.5); err != nil {
+		t.Fatalf("AddIsolatedMargin: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	for key, want := range map[string]interface{}{"type": "updateIsolatedMargin", "asset": 0.0, "isBuy": true, "ntli": 25500000.0} {
+		if got := jsonField(t, payload, "action", key); got != want {
+			t.Errorf("add action %s = %v, want %v", key, got, want)
+		}
+	}
+	assertSignedBy(t, payload, signer)
+
+	if _, err := exchange.RemoveIsolatedMargin("ETH", 100); err != nil {
+		t.Fatalf("RemoveIsolatedMargin: %v", err)
+	}
+	payload = server.lastExchangePayload()
+	for key, want := range map[string]interface{}{"asset": 0.0, "isBuy": true, "ntli": -100000000.0} {
+		if got := jsonField(t, payload, "action", key); got != want {
+			t.Errorf("remove action %s = %v, want %v", key, got, want)
+		}
+	}
+	assertSignedBy(t, payload, signer)
+	if got := server.infoRequests("clearinghouseState")[0]["user"]; got != signer {
+		t.Errorf("state fetched for %v, want %s", got, signer)
+	}
+
+	sent := len(server.recorded("/exchange"))
+	if _, err := exchange.RemoveIsolatedMargin("ETH", 150.01); err == nil {
+		t.Error("expected an error when removing more than the allocated margin")
+	}
+	if _, err := exchange.RemoveIsolatedMargin("BTC", 1); err == nil {
+		t.Error("expected an error for a cross position")
+	}
+	if _, err := exchange.AddIsolatedMargin("ETH", 0); err == nil {
+		t.Error("expected an error for a zero amount")
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatalf("rejected requests were sent to the exchange: %d, want %d", got, sent)
+	}
+}
+
+func TestWithDebugPayloadsWritesExactBody(t *testing.T) {
+	server := newMockServer(t)
+	var debug bytes.Buffer
+	exchange := newTestExchange(t, server).WithDebugPayloads(&debug)
+
+	limit := types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}}
+	if _, err := exchange.Order("ETH", true, 0.1, 2000, limit, false, nil, nil); err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+
+	if !strings.Contains(debug.String(), "\n  \"action\": {") {
+		t.Fatalf("debug output is not pretty-printed:\n%s", debug.String())
+	}
+	var written map[string]interface{}
+	if err := json.Unmarshal(debug.Bytes(), &written); err != nil {
+		t.Fatalf("debug output is not a single JSON object: %v\n%s", err, debug.String())
+	}
+	if !reflect.DeepEqual(written, server.lastExchangePayload()) {
+		t.Fatalf("debug output differs from the posted body:\n%s", debug.String())
+	}
+	for _, key := range []string{"action", "nonce", "signature", "vaultAddress", "expiresAfter"} {
+		if _, ok := written[key]; !ok {
+			t.Errorf("debug payload is missing %s", key)
+		}
+	}
+	if got := jsonField(t, written, "action", "orders", 0, "p"); got != "2000" {
+		t.Errorf("debug payload price = %v, want 2000", got)
+	}
+
+	// Without a writer nothing is written
+	debug.Reset()
+	exchange.WithDebugPayloads(nil)
+	if _, err := exchange.Cancel("ETH", 1); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if debug.Len() != 0 {
+		t.Fatalf("debug output written after disabling: %s", debug.String())
+	}
+}
+
+func TestVaultAddressDefaultsAndOverride(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestVaultExchange(t, server)
+	signer := utils.GetAddressFromPrivateKey(testKey(t))
+
+	// L1 actions carry the vault by default and are signed with it
+	if _, err := exchange.Cancel("ETH", 1); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	if payload["vaultAddress"] != testVault {
+		t.Fatalf("vaultAddress = %v, want %s", payload["vaultAddress"], testVault)
+	}
+	assertSignedBy(t, payload, signer)
+
+	// Vaultless transfers drop it by default and name the vault as the source account instead
+	if _, err := exchange.UsdClassTransfer("1.5", true); err != nil {
+		t.Fatalf("UsdClassTransfer: %v", err)
+	}
+	payload = server.lastExchangePayload()
+	if got, ok := payload["vaultAddress"]; !ok || got != nil {
+		t.Fatalf("usdClassTransfer vaultAddress = %v, want an explicit null", got)
+	}
+	if got := jsonField(t, payload, "action", "amount"); got != "1.5 subaccount:"+testVault {
+		t.Errorf("usdClassTransfer amount = %v, want the vault as subaccount", got)
+	}
+	if got := jsonField(t, payload, "action", "hyperliquidChain"); got != utils.TestnetChainName {
+		t.Errorf("usdClassTransfer hyperliquidChain = %v, want %s", got, utils.TestnetChainName)
+	}
+	if _, err := exchange.SendAsset("0x0000000000000000000000000000000000000001", "", "spot", "USDC", "2"); err != nil {
+		t.Fatalf("SendAsset: %v", err)
+	}
+	payload = server.lastExchangePayload()
+	if got, ok := payload["vaultAddress"]; !ok || got != nil {
+		t.Fatalf("sendAsset vaultAddress = %v, want an explicit null", got)
+	}
+	if got := jsonField(t, payload, "action", "fromSubAccount"); got != testVault {
+		t.Errorf("sendAsset fromSubAccount = %v, want %s", got, testVault)
+	}
+
+	// WithVault overrides the default per call
+	override := "0x0000000000000000000000000000000000000ABC"
+	if _, err := exchange.UsdClassTransfer("1.5", false, WithVault(override)); err != nil {
+		t.Fatalf("UsdClassTransfer: %v", err)
+	}
+	if got := server.lastExchangePayload()["vaultAddress"]; got != strings.ToLower(override) {
+		t.Fatalf("overridden vaultAddress = %v, want %s", got, strings.ToLower(override))
+	}
+	if _, err := exchange.SendAsset("0x0000000000000000000000000000000000000001", "", "spot", "USDC", "2", WithVault(override)); err != nil {
+		t.Fatalf("SendAsset: %v", err)
+	}
+	if got := server.lastExchangePayload()["vaultAddress"]; got != strings.ToLower(override) {
+		t.Fatalf("overridden vaultAddress = %v, want %s", got, strings.ToLower(override))
+	}
+}
+
+func TestUsdClassTransferWithoutVault(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.UsdClassTransfer("10", true); err != nil {
+		t.Fatalf("UsdClassTransfer: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	if got := jsonField(t, payload, "action", "amount"); got != "10" {
+		t.Errorf("amount = %v, want 10", got)
+	}
+	if got := jsonField(t, payload, "action", "toPerp"); got != true {
+		t.Errorf("toPerp = %v, want true", got)
+	}
+	if got := jsonField(t, payload, "action", "nonce"); got != payload["nonce"] {
+		t.Errorf("action nonce = %v, want the payload nonce %v", got, payload["nonce"])
+	}
+	if got, ok := payload["vaultAddress"]; !ok || got != nil {
+		t.Errorf("vaultAddress = %v, want an explicit null", got)
+	}
+
+	if _, err := exchange.UsdClassTransfer("1.1234567", true); err == nil {
+		t.Fatal("expected an error for an amount with more than 6 decimals")
+	}
+}
+
+func TestTransfersRejectInvalidAmountsBeforeSending(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+	destination := "0x0000000000000000000000000000000000000001"
+
+	if _, err := exchange.UsdTransfer(destination, "abc"); err == nil {
+		t.Error("UsdTransfer accepted a non-numeric amount")
+	}
+	if _, err := exchange.SpotTransfer(destination, "PURR:0xc1fb593aeffbeb02f85e0308e9956a90", "-1"); err == nil {
+		t.Error("SpotTransfer accepted a negative amount")
+	}
+	if _, err := exchange.WithdrawFromBridge(destination, "1.0000001"); err == nil {
+		t.Error("WithdrawFromBridge accepted an over-precise amount")
+	}
+	if requests := server.recorded("/exchange"); len(requests) != 0 {
+		t.Fatalf("invalid transfers reached the exchange: %d requests", len(requests))
+	}
+}
+
+func TestSetDisplayNameSignsAction(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.SetDisplayName("Market Maker 7"); err != nil {
+		t.Fatalf("SetDisplayName: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	want := map[string]interface{}{"type": "setDisplayName", "displayName": "Market Maker 7"}
+	if !reflect.DeepEqual(payload["action"], want) {
+		t.Fatalf("action = %v, want %v", payload["action"], want)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+
+	for _, name := range []string{strings.Repeat("x", 21), "caf\u00e9", "tab\tname"} {
+		if _, err := exchange.SetDisplayName(name); err == nil {
+			t.Errorf("SetDisplayName(%q) succeeded, want a validation error", name)
+		}
+	}
+	if requests := server.recorded("/exchange"); len(requests) != 1 {
+		t.Fatalf("invalid names reached the exchange: %d requests", len(requests))
+	}
+}
+
+func TestIsAgentValidDetectsStaleAgent(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestAgentExchange(t, server).WithClock(fixedClock(time.UnixMilli(1700000000000)))
+	agent := utils.GetAddressFromPrivateKey(exchange.privateKey)
+
+	respondAgent := func(validUntil int64) {
+		server.respondInfo("extraAgents", []interface{}{
+			map[string]interface{}{"name": "other", "address": "0x0000000000000000000000000000000000000001", "validUntil": 1800000000000},
+			map[string]interface{}{"name": "bot", "address": "0x" + strings.ToUpper(agent[2:]), "validUntil": validUntil},
+		})
+	}
+
+	respondAgent(1700000060000)
+	valid, err := exchange.IsAgentValid()
+	if err != nil || !valid {
+		t.Fatalf("IsAgentValid() = %v, %v for an unexpired agent; want true", valid, err)
+	}
+	if got := server.infoRequests("extraAgents")[0]["user"]; got != utils.GetAddressFromPrivateKey(testKey(t)) {
+		t.Fatalf("extraAgents requested for %v, want the master account", got)
+	}
+
+	respondAgent(1699999999999)
+	if valid, err := exchange.IsAgentValid(); err != nil || valid {
+		t.Fatalf("IsAgentValid() = %v, %v for an expired agent; want false", valid, err)
+	}
+
+	server.respondInfo("extraAgents", []interface{}{})
+	if valid, err := exchange.IsAgentValid(); err != nil || valid {
+		t.Fatalf("IsAgentValid() = %v, %v for a revoked agent; want false", valid, err)
+	}
+
+	server.respondInfo("extraAgents", rawResponse{status: 500, body: "unavailable"})
+	if _, err := exchange.IsAgentValid(); err == nil {
+		t.Fatal("expected an error when extraAgents fails")
+	}
+
+	// A client signing with the account key itself needs no lookup
+	requests := len(server.infoRequests("extraAgents"))
+	if valid, err := newTestExchange(t, server).IsAgentValid(); err != nil || !valid {
+		t.Fatalf("IsAgentValid() = %v, %v without an agent; want true", valid, err)
+	}
+	if got := len(server.infoRequests("extraAgents")); got != requests {
+		t.Fatal("extraAgents queried for a client without an agent")
+	}
+}
+
+func TestNewAgentExchangeSignsWithAgentForMaster(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+	master := utils.GetAddressFromPrivateKey(testKey(t))
+
+	agentExchange, err := exchange.NewAgentExchange("bot")
+	if err != nil {
+		t.Fatalf("NewAgentExchange: %v", err)
+	}
+
+	approval := server.lastExchangePayload()
+	if approval["type"] != "approveAgent" || approval["agentName"] != "bot" {
+		t.Fatalf("unexpected approval payload: %v", approval)
+	}
+	agent := utils.GetAddressFromPrivateKey(agentExchange.privateKey)
+	if !strings.EqualFold(approval["agentAddress"].(string), agent) {
+		t.Fatalf("approved agent %v, but the client signs with %s", approval["agentAddress"], agent)
+	}
+	if strings.EqualFold(agent, master) {
+		t.Fatal("agent client signs with the master key")
+	}
+	if got := agentExchange.UserAddress(); got != master {
+		t.Fatalf("agent client acts for %s, want %s", got, master)
+	}
+
+	if _, err := agentExchange.Cancel("ETH", 5); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	if payload["vaultAddress"] != nil {
+		t.Fatalf("agent order sent with vaultAddress %v", payload["vaultAddress"])
+	}
+	assertSignedBy(t, payload, agent)
+}
+
+func TestNewAgentExchangeRejectedApproval(t *testing.T) {
+	server := newMockServer(t)
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"status": "err", "response": "Extra agent already used."}
+	})
+
+	if agentExchange, err := newTestExchange(t, server).NewAgentExchange(); err == nil {
+		t.Fatalf("expected an error for a rejected approval, got %v", agentExchange)
+	}
+}
+
+func TestPostOnlyOrderReturnsWouldMatchSentinel(t *testing.T) {
+	server := newMockServer(t)
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(map[string]interface{}{
+			"error": "Post only order would have immediately matched, bbo was 1999.9@2000.1. asset=0",
+		})
+	})
+	exchange := newTestExchange(t, server)
+
+	_, err := exchange.PostOnlyOrder("ETH", true, 0.1, 2000.1, nil)
+	if !errors.Is(err, utils.ErrPostOnlyWouldMatch) {
+		t.Fatalf("expected ErrPostOnlyWouldMatch, got %v", err)
+	}
+	if got := jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "t", "limit", "tif"); got != "Alo" {
+		t.Fatalf("tif = %v, want Alo", got)
+	}
+
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(map[string]interface{}{"resting": map[string]interface{}{"oid": 21}})
+	})
+	if _, err := exchange.PostOnlyOrder("ETH", true, 0.1, 1999, nil); err != nil {
+		t.Fatalf("PostOnlyOrder resting: %v", err)
+	}
+}
+
+func TestSpotTransferFloatUsesTokenWeiDecimals(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.SpotTransferFloat(testUser, "PURR:0xc1fb593aeffbeb02f85e0308e9956a90", 1.25); err != nil {
+		t.Fatalf("SpotTransferFloat: %v", err)
+	}
+	if got := server.lastExchangePayload()["amount"]; got != "1.25" {
+		t.Fatalf("amount = %v, want 1.25", got)
+	}
+
+	// PURR has 5 wei decimals
+	requests := len(server.recorded("/exchange"))
+	if _, err := exchange.SpotTransferFloat(testUser, "PURR", 0.000001); err == nil {
+		t.Fatal("expected an error for an amount below the token precision")
+	}
+	if _, err := exchange.SpotTransfer(testUser, "PURR", "0.000001"); err == nil {
+		t.Fatal("SpotTransfer accepted an amount below the token precision")
+	}
+	if got := len(server.recorded("/exchange")); got != requests {
+		t.Fatal("an over-precise amount was sent")
+	}
+
+	// A token with 18 wei decimals takes amounts beyond 8 decimals
+	spotMeta := testSpotMeta()
+	spotMeta.Tokens = append(spotMeta.Tokens, types.SpotTokenInfo{Name: "WEI", SzDecimals: 2, WeiDecimals: 18, Index: 3, TokenId: "0x0000000000000000000000000000000a", IsCanonical: true})
+	exchange, err := NewExchange(testKey(t), server.URL, nil, testMeta(), nil, nil, spotMeta, nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	if _, err := exchange.SpotTransferFloat(testUser, "WEI", 0.000000000001); err != nil {
+		t.Fatalf("SpotTransferFloat with 18 wei decimals: %v", err)
+	}
+	if got := server.lastExchangePayload()["amount"]; got != "0.000000000001" {
+		t.Fatalf("amount = %v, want 0.000000000001", got)
+	}
+}
+
+func TestTradingCheckRejectsHaltedAsset(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("metaAndAssetCtxs", metaAndAssetCtxsResponse(
+		map[string]interface{}{"funding": "0.0000125", "markPx": "2000"},
+		map[string]interface{}{"funding": "0", "markPx": "0"},
+	))
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(map[string]interface{}{"resting": map[string]interface{}{"oid": 31}})
+	})
+	limit := types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}}
+
+	// Without the opt-in check no contexts are fetched and the order is sent
+	exchange := newTestExchange(t, server)
+	if _, err := exchange.Order("BTC", true, 0.01, 60000, limit, false, nil, nil); err != nil {
+		t.Fatalf("Order without check: %v", err)
+	}
+	if got := len(server.infoRequests("metaAndAssetCtxs")); got != 0 {
+		t.Fatalf("sent %d metaAndAssetCtxs requests without the check", got)
+	}
+
+	exchange.WithTradingCheck(true)
+	sent := len(server.recorded("/exchange"))
+	_, err := exchange.Order("BTC", true, 0.01, 60000, limit, false, nil, nil)
+	if !errors.Is(err, utils.ErrAssetNotTrading) {
+		t.Fatalf("expected ErrAssetNotTrading, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "BTC") {
+		t.Errorf("error %q does not name the asset", err)
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatal("an order on a halted asset was sent")
+	}
+
+	if _, err := exchange.Order("ETH", true, 0.1, 2000, limit, false, nil, nil); err != nil {
+		t.Fatalf("Order on a trading asset: %v", err)
+	}
+}
+
+func TestMarketOrderCloidRoundTrips(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("allMids", map[string]interface{}{"ETH": "2000"})
+	server.handleExchange(func(payload map[string]interface{}) interface{} {
+		order := payload["action"].(map[string]interface{})["orders"].([]interface{})[0].(map[string]interface{})
+		return orderResponse(map[string]interface{}{
+			"filled": map[string]interface{}{"totalSz": "0.1", "avgPx": "2000.5", "oid": 41, "cloid": order["c"]},
+		})
+	})
+	exchange := newTestExchange(t, server)
+
+	result, cloid, err := exchange.MarketOrder("ETH", true, 0.1, nil, nil)
+	if err != nil {
+		t.Fatalf("MarketOrder: %v", err)
+	}
+	if cloid == nil {
+		t.Fatal("MarketOrder did not return a generated cloid")
+	}
+	if _, err := types.NewCloid(cloid.ToRaw()); err != nil {
+		t.Fatalf("generated cloid %s is invalid: %v", cloid, err)
+	}
+	if got := jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "c"); got != cloid.ToRaw() {
+		t.Fatalf("action cloid = %v, want %s", got, cloid)
+	}
+
+	response, err := utils.ParseOrderResponse(result)
+	if err != nil {
+		t.Fatalf("ParseOrderResponse: %v", err)
+	}
+	echoed := response.Response.Data.Statuses[0].Cloid()
+	if echoed == nil || *echoed != cloid.ToRaw() {
+		t.Fatalf("response cloid = %v, want %s", echoed, cloid)
+	}
+
+	explicit := types.NewCloidFromInt(99)
+	if _, got, err := exchange.MarketOrder("ETH", false, 0.1, nil, explicit); err != nil || got != explicit {
+		t.Fatalf("MarketOrder with cloid returned %v, %v; want %s", got, err, explicit)
+	}
+}
+
+func TestSetDisplayNameCharsetBoundaries(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	for _, name := range []string{strings.Repeat("x", 20), "MM-7_v2.0 (~!)", ""} {
+		if _, err := exchange.SetDisplayName(name); err != nil {
+			t.Errorf("SetDisplayName(%q): %v", name, err)
+			continue
+		}
+		if got := jsonField(t, server.lastExchangePayload()["action"], "displayName"); got != name {
+			t.Errorf("displayName = %v, want %q", got, name)
+		}
+	}
+
+	_, err := exchange.SetDisplayName("name\u00a0here")
+	if err == nil || !strings.Contains(err.Error(), "printable ASCII") {
+		t.Fatalf("expected a charset error, got %v", err)
+	}
+}
+
+func TestSignerCheckCatchesMismatchedKey(t *testing.T) {
+	server := newMockServer(t)
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(map[string]interface{}{"success": "success"})
+	})
+
+	if _, err := newTestExchange(t, server).WithSignerCheck(true).Cancel("ETH", 5); err != nil {
+		t.Fatalf("Cancel with the account key: %v", err)
+	}
+
+	// Signing with another key for the account outside agent mode is caught before sending
+	sent := len(server.recorded("/exchange"))
+	mismatched := newTestAgentExchange(t, server).WithSignerCheck(true)
+	_, err := mismatched.Cancel("ETH", 5)
+	if err == nil || !strings.Contains(err.Error(), "signer check failed") || !strings.Contains(err.Error(), "does not match account") {
+		t.Fatalf("expected a signer mismatch error, got %v", err)
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatal("an action with a mismatched signer was sent")
+	}
+
+	// Without the check the same action reaches the exchange
+	if _, err := newTestAgentExchange(t, server).Cancel("ETH", 5); err != nil {
+		t.Fatalf("Cancel without the check: %v", err)
+	}
+}
+
+func TestSignerCheckAcceptsEverySignatureForm(t *testing.T) {
+	server := newMockServer(t)
+	account := utils.GetAddressFromPrivateKey(testKey(t))
+	exchange, err := NewExchange(testKey(t), server.URL, nil, testMeta(), nil, &account, testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+
+	action := map[string]interface{}{"type": "noop"}
+	signature, err := utils.SignL1Action(testKey(t), action, nil, 7, nil, exchange.IsMainnet())
+	if err != nil {
+		t.Fatalf("SignL1Action: %v", err)
+	}
+
+	// v as built in code, as decoded from JSON and as a 0/1 recovery id
+	for name, v := range map[string]interface{}{"int": signature.V, "json": float64(signature.V), "recovery id": int64(signature.V - 27)} {
+		sig := map[string]interface{}{"r": signature.R, "s": signature.S, "v": v}
+		if err := exchange.verifySigner(action, sig, 7, nil); err != nil {
+			t.Errorf("verifySigner(%s): %v", name, err)
+		}
+	}
+
+	// A signature over another nonce recovers to an unrelated address
+	sig := map[string]interface{}{"r": signature.R, "s": signature.S, "v": signature.V}
+	if err := exchange.verifySigner(action, sig, 8, nil); err == nil || !strings.Contains(err.Error(), "does not match account") {
+		t.Fatalf("expected a signer mismatch for the wrong nonce, got %v", err)
+	}
+}
+
+func TestDebugRedactionHidesSignature(t *testing.T) {
+	server := newMockServer(t)
+	var debug bytes.Buffer
+	exchange := newTestExchange(t, server).WithDebugPayloads(&debug).WithDebugRedaction(true)
+
+	limit := types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}}
+	if _, err := exchange.Order("ETH", true, 0.1, 2000, limit, false, nil, nil); err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if _, err := exchange.UsdTransfer("0x0000000000000000000000000000000000000001", "10"); err != nil {
+		t.Fatalf("UsdTransfer: %v", err)
+	}
+
+	requests := server.recorded("/exchange")
+	decoder := json.NewDecoder(&debug)
+	for i, request := range requests {
+		var written map[string]interface{}
+		if err := decoder.Decode(&written); err != nil {
+			t.Fatalf("debug payload %d is not JSON: %v", i, err)
+		}
+		for _, key := range []string{"r", "s"} {
+			if got := jsonField(t, written, "signature", key); got != "[REDACTED]" {
+				t.Errorf("debug payload %d signature %s = %v, want it redacted", i, key, got)
+			}
+		}
+		if got, want := jsonField(t, written, "signature", "v"), jsonField(t, request.Payload, "signature", "v"); got != want {
+			t.Errorf("debug payload %d signature v = %v, want %v", i, got, want)
+		}
+
+		// The body sent to the exchange keeps the real signature
+		if r, _ := jsonField(t, request.Payload, "signature", "r").(string); !strings.HasPrefix(r, "0x") {
+			t.Errorf("posted payload %d signature r = %q", i, r)
+		}
+	}
+
+	output := debug.String()
+	for _, request := range requests {
+		if r := jsonField(t, request.Payload, "signature", "r").(string); strings.Contains(output, r) {
+			t.Fatal("debug output contains a signature value")
+		}
+	}
+	if strings.Contains(output, strings.TrimPrefix(testKeyHex, "0x")) {
+		t.Fatal("debug output contains the private key")
+	}
+}
+
+func TestVaultExchangeQueriesVaultState(t *testing.T) {
+	server := newMockServer(t)
+	respondAccount(server)
+	exchange := newTestVaultExchange(t, server)
+
+	if got := exchange.UserAddress(); got != testVault {
+		t.Fatalf("UserAddress = %s, want the vault %s", got, testVault)
+	}
+
+	state, err := exchange.UserState("")
+	if err != nil {
+		t.Fatalf("UserState: %v", err)
+	}
+	if state.MarginSummary.AccountValue != "1000.5" {
+		t.Fatalf("unexpected state: %+v", state.MarginSummary)
+	}
+	if orders, err := exchange.OpenOrders(""); err != nil || len(orders) != 1 || orders[0].Oid != 101 {
+		t.Fatalf("OpenOrders = %+v, %v", orders, err)
+	}
+	if fills, err := exchange.UserFills(""); err != nil || len(fills) != 1 {
+		t.Fatalf("UserFills = %+v, %v", fills, err)
+	}
+
+	for _, infoType := range []string{"clearinghouseState", "openOrders", "userFills"} {
+		requests := server.infoRequests(infoType)
+		if len(requests) != 1 || requests[0]["user"] != testVault {
+			t.Errorf("%s requests = %v, want one for the vault", infoType, requests)
+		}
+	}
+}
+
+func TestPositionTpSlUsesPositionGrouping(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("clearinghouseState", clearinghouseStateFixture("1000", "100",
+		positionFixture("ETH", "0.5", map[string]interface{}{"type": "cross", "value": 10}, "100"),
+		positionFixture("BTC", "-0.01", map[string]interface{}{"type": "cross", "value": 10}, "60"),
+	))
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse("waitingForTrigger", "waitingForTrigger")
+	})
+	exchange := newTestExchange(t, server)
+
+	takeProfit, stopLoss := 2200.0, 1800.0
+	if _, err := exchange.PositionTpSl("ETH", &takeProfit, &stopLoss, 0.5); err != nil {
+		t.Fatalf("PositionTpSl: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	action := payload["action"]
+	if got := jsonField(t, action, "grouping"); got != "positionTpsl" {
+		t.Fatalf("grouping = %v, want positionTpsl", got)
+	}
+	for i, leg := range []struct{ tpsl, triggerPx string }{{"tp", "2200"}, {"sl", "1800"}} {
+		order := jsonField(t, action, "orders", i)
+		// A long position is closed by sells
+		for key, want := range map[string]interface{}{"a": 0.0, "b": false, "s": "0.5", "r": true} {
+			if got := jsonField(t, order, key); got != want {
+				t.Errorf("%s order %s = %v, want %v", leg.tpsl, key, got, want)
+			}
+		}
+		trigger := jsonField(t, order, "t", "trigger")
+		for key, want := range map[string]interface{}{"isMarket": true, "triggerPx": leg.triggerPx, "tpsl": leg.tpsl} {
+			if got := jsonField(t, trigger, key); got != want {
+				t.Errorf("%s trigger %s = %v, want %v", leg.tpsl, key, got, want)
+			}
+		}
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+
+	// A short position is closed by buys, and a single leg is allowed
+	if _, err := exchange.PositionTpSl("BTC", nil, &takeProfit, 0.01); err != nil {
+		t.Fatalf("PositionTpSl short: %v", err)
+	}
+	action = server.lastExchangePayload()["action"]
+	if orders := jsonField(t, action, "orders").([]interface{}); len(orders) != 1 {
+		t.Fatalf("sent %d orders, want 1", len(orders))
+	}
+	if got := jsonField(t, action, "orders", 0, "b"); got != true {
+		t.Errorf("short position close is buy = %v, want true", got)
+	}
+
+	if _, err := exchange.PositionTpSl("ETH", nil, nil, 0.5); err == nil {
+		t.Error("expected an error without prices")
+	}
+	if _, err := exchange.PositionTpSl("HFUN/USDC", &takeProfit, nil, 1); err == nil {
+		t.Error("expected an error without a position")
+	}
+}
+
+func TestTwapCancelActionAndResponse(t *testing.T) {
+	server := newMockServer(t)
+	twapCancelResponse := func(status interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"status":   "ok",
+			"response": map[string]interface{}{"type": "twapCancel", "data": map[string]interface{}{"status": status}},
+		}
+	}
+	server.handleExchange(func(map[string]interface{}) interface{} { return twapCancelResponse("success") })
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.TwapCancel("BTC", 7); err != nil {
+		t.Fatalf("TwapCancel: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	want := map[string]interface{}{"type": "twapCancel", "a": 1.0, "t": 7.0}
+	if !reflect.DeepEqual(payload["action"], want) {
+		t.Fatalf("action = %v, want %v", payload["action"], want)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return twapCancelResponse(map[string]interface{}{"error": "Twap was never placed, already canceled, or filled."})
+	})
+	_, err := exchange.TwapCancel("BTC", 7)
+	if !errors.Is(err, utils.ErrTwapNotRunning) {
+		t.Fatalf("expected ErrTwapNotRunning for a finished TWAP, got %v", err)
+	}
+
+	if _, err := exchange.TwapCancel("SOL", 7); err == nil {
+		t.Fatal("expected an error for an unknown coin")
+	}
+}
+
+func TestWithClockProducesDeterministicSignature(t *testing.T) {
+	server := newMockServer(t)
+	clock := fixedClock(time.UnixMilli(1700000000000))
+	limit := types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}}
+
+	var signatures []interface{}
+	for i := 0; i < 2; i++ {
+		exchange := newTestExchange(t, server).WithClock(clock)
+		if _, err := exchange.Order("ETH", true, 0.1, 2000, limit, false, types.NewCloidFromInt(1), nil); err != nil {
+			t.Fatalf("Order: %v", err)
+		}
+		payload := server.lastExchangePayload()
+		if got := payload["nonce"]; got != 1700000000000.0 {
+			t.Fatalf("nonce = %v, want 1700000000000", got)
+		}
+		assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+		signatures = append(signatures, payload["signature"])
+	}
+
+	// Signing is deterministic (RFC 6979), so a fixed clock pins the signature
+	want := map[string]interface{}{
+		"r": "0xedd7b82510d5b98f730964c32ed43a0788b9a97bd361d7e42d819e3bd811fe6e",
+		"s": "0x3480eca0ecb3ffc4280e81364de24cd90f084ab4a570b1d1ab1392ff76837960",
+		"v": 27.0,
+	}
+	for i, signature := range signatures {
+		if !reflect.DeepEqual(signature, want) {
+			t.Errorf("signature %d = %v, want %v", i, signature, want)
+		}
+	}
+}
+
+func TestLimitOrderRejectsUnknownTifBeforeSending(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.LimitOrder("ETH", true, 0.1, 2000, types.Tif("gtc"), false, nil); err == nil {
+		t.Fatal("expected an error for a lowercase tif")
+	}
+	if requests := server.recorded("/exchange"); len(requests) != 0 {
+		t.Fatalf("an order with an invalid tif was sent: %v", requests[0].Payload)
+	}
+}
+
+func TestWithFrontendFlagsOnlyOrderActions(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server).WithFrontend(true)
+	limit := types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}}
+
+	calls := []struct {
+		name       string
+		call       func() error
+		isFrontend bool
+	}{
+		{"order", func() error { _, err := exchange.Order("ETH", true, 0.1, 2000, limit, false, nil, nil); return err }, true},
+		{"cancel", func() error { _, err := exchange.Cancel("ETH", 1); return err }, true},
+		{"cancelByCloid", func() error { _, err := exchange.CancelByCloid("ETH", types.NewCloidFromInt(1)); return err }, true},
+		{"modify", func() error {
+			_, err := exchange.Modify(1, types.OrderRequest{Coin: "ETH", IsBuy: true, Sz: 0.1, LimitPx: 2001, OrderType: limit})
+			return err
+		}, true},
+		{"updateLeverage", func() error { _, err := exchange.UpdateLeverage("ETH", true, 5); return err }, false},
+	}
+	for _, c := range calls {
+		if err := c.call(); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		payload := server.lastExchangePayload()
+		if got := jsonField(t, payload["action"], "type"); got != c.name {
+			t.Fatalf("action type = %v, want %s", got, c.name)
+		}
+		if _, ok := payload["isFrontend"]; ok != c.isFrontend {
+			t.Errorf("%s: isFrontend present = %v, want %v", c.name, ok, c.isFrontend)
+		}
+		if c.isFrontend && payload["isFrontend"] != true {
+			t.Errorf("%s: isFrontend = %v, want true", c.name, payload["isFrontend"])
+		}
+		// The flag is outside the signed hash
+		assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+	}
+
+	exchange.WithFrontend(false)
+	if _, err := exchange.Cancel("ETH", 1); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if _, ok := server.lastExchangePayload()["isFrontend"]; ok {
+		t.Fatal("isFrontend sent after disabling")
+	}
+}
+
+func TestApproveBuilderFeeAndOrder(t *testing.T) {
+	const builder = "0x00000000000000000000000000000000000000b1"
+	order := types.OrderRequest{
+		Coin: "ETH", IsBuy: true, Sz: 0.1, LimitPx: 2000,
+		OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+	}
+
+	tests := []struct {
+		name         string
+		approved     int
+		wantApproval bool
+	}{
+		{name: "already approved", approved: 10, wantApproval: false},
+		{name: "needs approval", approved: 0, wantApproval: true},
+	}
+	for _, tt := range tests {
+		server := newMockServer(t)
+		server.respondInfo("maxBuilderFee", tt.approved)
+		exchange := newTestExchange(t, server)
+
+		if _, err := exchange.ApproveBuilderFeeAndOrder(builder, "0.01%", order, 5); err != nil {
+			t.Fatalf("%s: ApproveBuilderFeeAndOrder: %v", tt.name, err)
+		}
+
+		query := server.infoRequests("maxBuilderFee")
+		if len(query) != 1 || query[0]["user"] != exchange.UserAddress() || query[0]["builder"] != builder {
+			t.Fatalf("%s: maxBuilderFee requests = %v", tt.name, query)
+		}
+
+		requests := server.recorded("/exchange")
+		wantRequests := 1
+		if tt.wantApproval {
+			wantRequests = 2
+			approval := requests[0].Payload
+			if approval["type"] != "approveBuilderFee" || approval["maxFeeRate"] != "0.01%" || approval["builder"] != builder {
+				t.Fatalf("%s: unexpected approval %v", tt.name, approval)
+			}
+		}
+		if len(requests) != wantRequests {
+			t.Fatalf("%s: sent %d exchange requests, want %d", tt.name, len(requests), wantRequests)
+		}
+
+		action := server.lastExchangePayload()["action"]
+		if got := jsonField(t, action, "type"); got != "order" {
+			t.Fatalf("%s: last action = %v, want order", tt.name, got)
+		}
+		if got := jsonField(t, action, "builder"); !reflect.DeepEqual(got, map[string]interface{}{"b": builder, "f": 5.0}) {
+			t.Fatalf("%s: builder = %v", tt.name, got)
+		}
+	}
+
+	// A fee above the requested max rate is rejected before any request
+	server := newMockServer(t)
+	if _, err := newTestExchange(t, server).ApproveBuilderFeeAndOrder(builder, "0.001%", order, 5); err == nil {
+		t.Fatal("expected an error for a fee above the max fee rate")
+	}
+	if requests := server.recorded(""); len(requests) != 0 {
+		t.Fatalf("sent %d requests for an invalid fee", len(requests))
+	}
+}
+
+func TestPostOnlyOrderWithRepriceMovesInsideSpread(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("l2Book", l2BookFrame("ETH", "1999", "2000", 1)["data"])
+	wouldMatch := map[string]interface{}{"error": "Post only order would have immediately matched, bbo was 1999@2000. asset=0"}
+
+	// Orders at or through the touch cross; anything strictly inside rests
+	var prices []string
+	server.handleExchange(func(payload map[string]interface{}) interface{} {
+		order := payload["action"].(map[string]interface{})["orders"].([]interface{})[0].(map[string]interface{})
+		prices = append(prices, order["p"].(string))
+		isBuy := order["b"].(bool)
+		if (isBuy && order["p"] == "1999.9") || (!isBuy && order["p"] == "1999.1") {
+			return orderResponse(map[string]interface{}{"resting": map[string]interface{}{"oid": 51}})
+		}
+		return orderResponse(wouldMatch)
+	})
+	exchange := newTestExchange(t, server)
+
+	_, px, err := exchange.PostOnlyOrderWithReprice("ETH", true, 0.1, 2001, 3)
+	if err != nil {
+		t.Fatalf("PostOnlyOrderWithReprice buy: %v", err)
+	}
+	if px != 1999.9 || !reflect.DeepEqual(prices, []string{"2001", "1999.9"}) {
+		t.Fatalf("buy repriced to %v via %v, want 1999.9 after one retry", px, prices)
+	}
+
+	prices = nil
+	_, px, err = exchange.PostOnlyOrderWithReprice("ETH", false, 0.1, 1998, 3)
+	if err != nil {
+		t.Fatalf("PostOnlyOrderWithReprice sell: %v", err)
+	}
+	if px != 1999.1 || !reflect.DeepEqual(prices, []string{"1998", "1999.1"}) {
+		t.Fatalf("sell repriced to %v via %v, want 1999.1 after one retry", px, prices)
+	}
+
+	// A book that keeps crossing exhausts the retries
+	prices = nil
+	server.handleExchange(func(payload map[string]interface{}) interface{} {
+		prices = append(prices, jsonField(t, payload["action"], "orders", 0, "p").(string))
+		return orderResponse(wouldMatch)
+	})
+	if _, _, err := exchange.PostOnlyOrderWithReprice("ETH", true, 0.1, 2001, 2); !errors.Is(err, utils.ErrPostOnlyWouldMatch) {
+		t.Fatalf("expected ErrPostOnlyWouldMatch after the retries, got %v", err)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("made %d attempts, want 3", len(prices))
+	}
+}
+
+func TestClearScheduledCancelSendsNoTime(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	if _, err := exchange.ClearScheduledCancel(); err != nil {
+		t.Fatalf("ClearScheduledCancel: %v", err)
+	}
+
+	payload := server.lastExchangePayload()
+	if want := map[string]interface{}{"type": "scheduleCancel"}; !reflect.DeepEqual(payload["action"], want) {
+		t.Fatalf("action = %v, want %v", payload["action"], want)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+func TestBuilderFeeCheckRejectsOverMaxFee(t *testing.T) {
+	const builder = "0x00000000000000000000000000000000000000b1"
+	server := newMockServer(t)
+	server.respondInfo("maxBuilderFee", 10)
+	order := []types.OrderRequest{{
+		Coin: "ETH", IsBuy: true, Sz: 0.1, LimitPx: 2000,
+		OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+	}}
+	exchange := newTestExchange(t, server).WithBuilderFeeCheck(true)
+
+	_, err := exchange.BulkOrders(order, &types.BuilderInfo{B: strings.ToUpper(builder[:2]) + builder[2:], F: 11})
+	var validationErr *utils.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "builder" {
+		t.Fatalf("expected a builder ValidationError, got %v", err)
+	}
+	if requests := server.recorded("/exchange"); len(requests) != 0 {
+		t.Fatal("an order with an over-max builder fee was sent")
+	}
+
+	if _, err := exchange.BulkOrders(order, &types.BuilderInfo{B: builder, F: 10}); err != nil {
+		t.Fatalf("BulkOrders at the approved max: %v", err)
+	}
+	if got := jsonField(t, server.lastExchangePayload()["action"], "builder"); !reflect.DeepEqual(got, map[string]interface{}{"b": builder, "f": 10.0}) {
+		t.Fatalf("builder = %v", got)
+	}
+	for _, request := range server.infoRequests("maxBuilderFee") {
+		if request["user"] != exchange.UserAddress() || request["builder"] != builder {
+			t.Fatalf("unexpected maxBuilderFee request: %v", request)
+		}
+	}
+}
+
+func TestClaimReferralRewardsReportsClaimedIncrease(t *testing.T) {
+	server := newMockServer(t)
+	user := utils.GetAddressFromPrivateKey(testKey(t))
+	claimed := false
+	server.handleInfo("referral", func(payload map[string]interface{}) interface{} {
+		if payload["user"] != user {
+			t.Errorf("referral queried for %v, want %s", payload["user"], user)
+		}
+		state := map[string]interface{}{
+			"referredBy":       map[string]interface{}{"referrer": testVault, "code": "ABC"},
+			"cumVlm":           "12345.6",
+			"unclaimedRewards": "1.25",
+			"claimedRewards":   "3.5",
+			"builderRewards":   "0.0",
+		}
+		if claimed {
+			state["unclaimedRewards"] = "0.0"
+			state["claimedRewards"] = "4.75"
+		}
+		return state
+	})
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		claimed = true
+		return map[string]interface{}{"status": "ok", "response": map[string]interface{}{"type": "default"}}
+	})
+	exchange := newTestExchange(t, server)
+
+	claimable, err := exchange.info.ReferralRewards(user)
+	if err != nil || claimable != 1.25 {
+		t.Fatalf("ReferralRewards = %v, %v, want 1.25", claimable, err)
+	}
+
+	_, amount, err := exchange.ClaimReferralRewards()
+	if err != nil {
+		t.Fatalf("ClaimReferralRewards: %v", err)
+	}
+	if !approxEqual(amount, 1.25) {
+		t.Fatalf("claimed %v, want 1.25", amount)
+	}
+	payload := server.lastExchangePayload()
+	if action := payload["action"]; !reflect.DeepEqual(action, map[string]interface{}{"type": "claimRewards"}) {
+		t.Fatalf("action = %v", action)
+	}
+	assertSignedBy(t, payload, user)
+
+	// Nothing left to claim, so no action is sent
+	sent := len(server.recorded("/exchange"))
+	_, _, err = exchange.ClaimReferralRewards()
+	var validationErr *utils.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError with nothing to claim, got %v", err)
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatal("a claim was sent with no unclaimed rewards")
+	}
+}
+
+func TestCoinNameNormalizationResolvesFoldedNames(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+	order := func(coin string) types.OrderRequest {
+		return types.OrderRequest{
+			Coin: coin, IsBuy: true, Sz: 1, LimitPx: 100,
+			OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+		}
+	}
+
+	// Disabled by default: names must match exactly
+	if _, err := exchange.BulkOrders([]types.OrderRequest{order("eth")}, nil); err == nil {
+		t.Fatal("expected an error for eth without normalization")
+	}
+	if requests := server.recorded("/exchange"); len(requests) != 0 {
+		t.Fatal("an order with an unresolved coin was sent")
+	}
+
+	exchange.WithCoinNameNormalization(true)
+	for coin, want := range map[string]float64{"eth": 0, " ETH ": 0, "btc": 1, "purr/usdc": 10000} {
+		if _, err := exchange.BulkOrders([]types.OrderRequest{order(coin)}, nil); err != nil {
+			t.Fatalf("BulkOrders(%q): %v", coin, err)
+		}
+		if got := jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "a"); got != want {
+			t.Errorf("%q resolved to asset %v, want %v", coin, got, want)
+		}
+	}
+
+	// A name that folds to two coins stays ambiguous
+	meta := &types.Meta{Universe: []types.AssetInfo{
+		{Name: "kPEPE", SzDecimals: 0, MaxLeverage: 10},
+		{Name: "KPEPE", SzDecimals: 0, MaxLeverage: 10},
+	}}
+	ambiguous, err := NewExchange(testKey(t), server.URL, nil, meta, nil, nil, testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	ambiguous.WithCoinNameNormalization(true)
+	if _, err := ambiguous.BulkOrders([]types.OrderRequest{order("kpepe")}, nil); err == nil {
+		t.Fatal("expected an error for a name matching two coins")
+	}
+	if _, err := ambiguous.BulkOrders([]types.OrderRequest{order("KPEPE")}, nil); err != nil {
+		t.Fatalf("exact match with normalization: %v", err)
+	}
+	if got := jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "a"); got != 1.0 {
+		t.Errorf("KPEPE resolved to asset %v, want 1", got)
+	}
+}
+
+func TestMinNotionalPolicyRejectsOrBumpsSmallOrders(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+	order := func(sz float64, reduceOnly bool) []types.OrderRequest {
+		return []types.OrderRequest{{
+			Coin: "ETH", IsBuy: true, Sz: sz, LimitPx: 2000, ReduceOnly: reduceOnly,
+			OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+		}}
+	}
+	sentSz := func() interface{} {
+		return jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "s")
+	}
+
+	// By default the order is left for the exchange to judge
+	if _, err := exchange.BulkOrders(order(0.001, false), nil); err != nil {
+		t.Fatalf("BulkOrders: %v", err)
+	}
+	if got := sentSz(); got != "0.001" {
+		t.Fatalf("sent size %v, want 0.001", got)
+	}
+
+	exchange.WithMinNotionalPolicy(MinNotionalReject)
+	sent := len(server.recorded("/exchange"))
+	_, err := exchange.BulkOrders(order(0.0049, false), nil)
+	var validationErr *utils.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "sz" {
+		t.Fatalf("expected an sz ValidationError below the minimum, got %v", err)
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatal("a sub-minimum order was sent")
+	}
+	for _, sz := range []float64{0.005, 0.006} {
+		if _, err := exchange.BulkOrders(order(sz, false), nil); err != nil {
+			t.Fatalf("BulkOrders(%v) at or above the minimum: %v", sz, err)
+		}
+	}
+	if _, err := exchange.BulkOrders(order(0.001, true), nil); err != nil {
+		t.Fatalf("reduce-only order below the minimum: %v", err)
+	}
+
+	exchange.WithMinNotionalPolicy(MinNotionalBump)
+	requests := order(0.001, false)
+	if _, err := exchange.BulkOrders(requests, nil); err != nil {
+		t.Fatalf("BulkOrders with bump: %v", err)
+	}
+	if got := sentSz(); got != "0.005" {
+		t.Fatalf("bumped size %v, want 0.005", got)
+	}
+	if requests[0].Sz != 0.001 {
+		t.Fatalf("bumping changed the caller's order to %v", requests[0].Sz)
+	}
+	if _, err := exchange.BulkOrders(order(0.006, false), nil); err != nil {
+		t.Fatalf("BulkOrders above the minimum: %v", err)
+	}
+	if got := sentSz(); got != "0.006" {
+		t.Fatalf("size above the minimum sent as %v, want 0.006", got)
+	}
+}
+
+func TestEvmUserModifyActionShapeAndSigner(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	for _, usingBigBlocks := range []bool{true, false} {
+		if _, err := exchange.EvmUserModify(usingBigBlocks); err != nil {
+			t.Fatalf("EvmUserModify(%v): %v", usingBigBlocks, err)
+		}
+		payload := server.lastExchangePayload()
+		want := map[string]interface{}{"type": "evmUserModify", "usingBigBlocks": usingBigBlocks}
+		if !reflect.DeepEqual(payload["action"], want) {
+			t.Fatalf("action = %v, want %v", payload["action"], want)
+		}
+		assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+	}
+}
+
+func TestNoopActionAndSigning(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server).WithClock(fixedClock(time.UnixMilli(1700000000000)))
+
+	if _, err := exchange.Noop(); err != nil {
+		t.Fatalf("Noop: %v", err)
+	}
+	payload := server.lastExchangePayload()
+	if !reflect.DeepEqual(payload["action"], map[string]interface{}{"type": "noop"}) {
+		t.Fatalf("action = %v, want a noop", payload["action"])
+	}
+	if payload["nonce"] != 1700000000000.0 {
+		t.Fatalf("nonce = %v, want 1700000000000", payload["nonce"])
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+
+	// On behalf of a vault the noop carries the vault address but is still signed by the key
+	vault := newTestVaultExchange(t, server)
+	if _, err := vault.Noop(); err != nil {
+		t.Fatalf("vault Noop: %v", err)
+	}
+	payload = server.lastExchangePayload()
+	if payload["vaultAddress"] != testVault {
+		t.Fatalf("vaultAddress = %v, want %s", payload["vaultAddress"], testVault)
+	}
+	assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+}
+
+func TestMarketOrderVWAPLimitAgainstFlatSlippage(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("allMids", map[string]interface{}{"ETH": "2000"})
+	level := func(px, sz string) map[string]interface{} {
+		return map[string]interface{}{"px": px, "sz": sz, "n": 1}
+	}
+	server.respondInfo("l2Book", map[string]interface{}{"coin": "ETH", "time": 1, "levels": []interface{}{
+		[]interface{}{level("1999.5", "1"), level("1999", "1")},
+		[]interface{}{level("2000.5", "0.5"), level("2001", "0.5"), level("2010", "5")},
+	}})
+	server.handleExchange(func(map[string]interface{}) interface{} {
+		return orderResponse(map[string]interface{}{"filled": map[string]interface{}{"totalSz": "0.8", "avgPx": "2000.7", "oid": 1}})
+	})
+	exchange := newTestExchange(t, server)
+	limitPx := func() interface{} {
+		return jsonField(t, server.lastExchangePayload()["action"], "orders", 0, "p")
+	}
+
+	tests := []struct {
+		name  string
+		isBuy bool
+		sz    float64
+		opts  []MarketOrderOption
+		want  string
+	}{
+		// The flat default is 5% off the mid regardless of the book
+		{name: "flat buy", isBuy: true, sz: 0.8, want: "2100"},
+		{name: "flat sell", isBuy: false, sz: 1.5, want: "1900"},
+		// 0.5 @ 2000.5 + 0.3 @ 2001 averages 2000.6875; 0.1% above that is 2002.69
+		{name: "vwap buy", isBuy: true, sz: 0.8, opts: []MarketOrderOption{WithVWAPLimit(0.001)}, want: "2002.7"},
+		// Without a buffer the limit still reaches the last level needed
+		{name: "vwap buy no buffer", isBuy: true, sz: 0.8, opts: []MarketOrderOption{WithVWAPLimit(0)}, want: "2001"},
+		// 1 @ 1999.5 + 0.5 @ 1999 averages 1999.33; 0.1% below that is 1997.33
+		{name: "vwap sell", isBuy: false, sz: 1.5, opts: []MarketOrderOption{WithVWAPLimit(0.001)}, want: "1997.3"},
+		// The book cannot fill 10, so the flat slippage is used
+		{name: "vwap thin book", isBuy: true, sz: 10, opts: []MarketOrderOption{WithVWAPLimit(0.001)}, want: "2100"},
+	}
+	for _, tt := range tests {
+		if _, _, err := exchange.MarketOrder("ETH", tt.isBuy, tt.sz, nil, nil, tt.opts...); err != nil {
+			t.Fatalf("%s: MarketOrder: %v", tt.name, err)
+		}
+		if got := limitPx(); got != tt.want {
+			t.Errorf("%s: limit price = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateReferralCodeShapeAndValidation(t *testing.T) {
+	server := newMockServer(t)
+	exchange := newTestExchange(t, server)
+
+	for _, code := range []string{"A", "HYPERGO2024", strings.Repeat("z", 20)} {
+		if _, err := exchange.CreateReferralCode(code); err != nil {
+			t.Fatalf("CreateReferralCode(%q): %v", code, err)
+		}
+		payload := server.lastExchangePayload()
+		want := map[string]interface{}{"type": "registerReferrer", "code": code}
+		if !reflect.DeepEqual(payload["action"], want) {
+			t.Fatalf("action = %v, want %v", payload["action"], want)
+		}
+		assertSignedBy(t, payload, utils.GetAddressFromPrivateKey(testKey(t)))
+	}
+
+	sent := len(server.recorded("/exchange"))
+	for _, code := range []string{"", strings.Repeat("z", 21), "HYPER-GO", "hyper go", "caf\u00e9", "CODE_1"} {
+		_, err := exchange.CreateReferralCode(code)
+		var validationErr *utils.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "code" {
+			t.Errorf("CreateReferralCode(%q) = %v, want a code ValidationError", code, err)
+		}
+	}
+	if got := len(server.recorded("/exchange")); got != sent {
+		t.Fatalf("%d invalid referral codes were sent", got-sent)
+	}
+}
+
+func TestOpenIsolatedSetsLeverageFromMargin(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("meta", map[string]interface{}{
+		"universe": []interface{}{
+			map[string]interface{}{"name": "ETH", "szDecimals": 4, "maxLeverage": 25, "marginTableId": 51},
+			map[string]interface{}{"name": "BTC", "szDecimals": 5, "maxLeverage": 40},
+		},
+		"marginTables": []interface{}{
+			[]interface{}{51, map[string]interface{}{
+				"description": "tiered 25x",
+				"marginTiers": []interface{}{
+					map[string]interface{}{"lowerBound": "0.0", "maxLeverage": 25},
+					map[string]interface{}{"lowerBound": "5000.0", "maxLeverage": 10},
+				},
+			}},
+		},
+	})
+	server.handleExchange(func(payload map[string]interface{}) interface{} {
+		if action, _ := payload["action"].(map[string]interface{}); action["type"] == "order" {
+			return orderResponse(map[string]interface{}{"resting": map[string]interface{}{"oid": 1}})
+		}
+		return map[string]interface{}{"status": "ok", "response": map[string]interface{}{"type": "default"}}
+	})
+	exchange := newTestExchange(t, server)
+
+	// 1 ETH at 2000 with 250 of margin needs 8x
+	if _, err := exchange.OpenIsolated("ETH", true, 1, 2000, 250); err != nil {
+		t.Fatalf("OpenIsolated: %v", err)
+	}
+	requests := server.recorded("/exchange")
+	if len(requests) != 2 {
+		t.Fatalf("sent %d exchange requests, want leverage then order", len(requests))
+	}
+	leverage := requests[0].Payload["action"]
+	if want := map[string]interface{}{"type": "updateLeverage", "asset": 0.0, "isCross": false, "leverage": 8.0}; !reflect.DeepEqual(leverage, want) {
+		t.Fatalf("leverage action = %v, want %v", leverage, want)
+	}
+	order := requests[1].Payload["action"]
+	if jsonField(t, order, "orders", 0, "p") != "2000" || jsonField(t, order, "orders", 0, "s") != "1" || jsonField(t, order, "orders", 0, "b") != true {
+		t.Fatalf("unexpected order action %v", order)
+	}
+
+	tests := []struct {
+		name        string
+		sz, px      float64
+		margin      float64
+		wantMinimum string
+	}{
+		// 2000 notional at the 25x max needs at least 80
+		{name: "below max leverage", sz: 1, px: 2000, margin: 50, wantMinimum: "80.00"},
+		// 6000 notional falls in the 10x tier, so 25x is not available
+		{name: "above tier max", sz: 3, px: 2000, margin: 300, wantMinimum: "600.00"},
+	}
+	for _, tt := range tests {
+		_, err := exchange.OpenIsolated("ETH", true, tt.sz, tt.px, tt.margin)
+		var validationErr *utils.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "margin" {
+			t.Fatalf("%s: expected a margin ValidationError, got %v", tt.name, err)
+		}
+		if !strings.Contains(err.Error(), tt.wantMinimum) {
+			t.Errorf("%s: error %q does not give the minimum margin %s", tt.name, err, tt.wantMinimum)
+		}
+	}
+	if got := len(server.recorded("/exchange")); got != 2 {
+		t.Fatalf("rejected OpenIsolated calls sent %d requests", got-2)
+	}
+}
+
+func TestReplaceCancelsAndPlacesOrRollsBack(t *testing.T) {
+	const cloid = "0x00000000000000000000000000000009"
+	server := newMockServer(t)
+	server.handleInfo("orderStatus", func(map[string]interface{}) interface{} {
+		order := openOrderFixture("ETH", "B", "1900.0", "0.5", 77)
+		order["origSz"] = "1.0"
+		order["orderType"] = "Limit"
+		order["tif"] = "Alo"
+		order["cloid"] = cloid
+		order["reduceOnly"] = false
+		return map[string]interface{}{
+			"status": "order",
+			"order":  map[string]interface{}{"order": order, "status": "open", "statusTimestamp": 1700000000000},
+		}
+	})
+	rejectNewOrder := false
+	server.handleExchange(func(payload map[string]interface{}) interface{} {
+		action, _ := payload["action"].(map[string]interface{})
+		if action["type"] == "cancel" {
+			return map[string]interface{}{"status": "ok", "response": map[string]interface{}{
+				"type": "cancel", "data": map[string]interface{}{"statuses": []interface{}{"success"}},
+			}}
+		}
+		orders, _ := action["orders"].([]interface{})
+		if first, _ := orders[0].(map[string]interface{}); rejectNewOrder && first["p"] == "1950" {
+			return orderResponse(map[string]interface{}{"error": "Order could not immediately match against any resting orders. asset=0"})
+		}
+		return orderResponse(map[string]interface{}{"resting": map[string]interface{}{"oid": 78}})
+	})
+	exchange := newTestExchange(t, server)
+	newOrder := types.OrderRequest{
+		Coin: "ETH", IsBuy: true, Sz: 0.5, LimitPx: 1950,
+		OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifIoc}},
+	}
+	actionTypes := func(requests []recordedRequest) []interface{} {
+		var sent []interface{}
+		for _, request := range requests {
+			sent = append(sent, jsonField(t, request.Payload, "action", "type"))
+		}
+		return sent
+	}
+
+	result, err := exchange.Replace(77, newOrder)
+	if err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if result.Cancel == nil || result.Order == nil || result.Rollback != nil {
+		t.Fatalf("unexpected replace result: %+v", result)
+	}
+	requests := server.recorded("/exchange")
+	if got := actionTypes(requests); !reflect.DeepEqual(got, []interface{}{"cancel", "order"}) {
+		t.Fatalf("sent %v, want cancel then order", got)
+	}
+	if got := jsonField(t, requests[0].Payload, "action", "cancels", 0, "o"); got != 77.0 {
+		t.Fatalf("canceled oid %v, want 77", got)
+	}
+	if got := jsonField(t, requests[1].Payload, "action", "orders", 0, "t", "limit", "tif"); got != "Ioc" {
+		t.Fatalf("replacement tif = %v, want Ioc", got)
+	}
+
+	// When the replacement is rejected the original order is placed again
+	rejectNewOrder = true
+	result, err = exchange.Replace(77, newOrder)
+	if err == nil || !strings.Contains(err.Error(), "failed to place replacement for order 77") {
+		t.Fatalf("expected a replacement error, got %v", err)
+	}
+	if result == nil || result.Rollback == nil {
+		t.Fatalf("replace result has no rollback: %+v", result)
+	}
+	requests = server.recorded("/exchange")[2:]
+	if got := actionTypes(requests); !reflect.DeepEqual(got, []interface{}{"cancel", "order", "order"}) {
+		t.Fatalf("sent %v, want cancel, order and rollback order", got)
+	}
+	restored := jsonField(t, requests[2].Payload, "action", "orders", 0)
+	want := map[string]interface{}{
+		"a": 0.0, "b": true, "p": "1900", "s": "0.5", "r": false,
+		"t": map[string]interface{}{"limit": map[string]interface{}{"tif": "Alo"}},
+		"c": cloid,
+	}
+	if !reflect.DeepEqual(restored, want) {
+		t.Fatalf("restored order = %v, want %v", restored, want)
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+// GapEvent reports a message whose time suggests messages were dropped or reordered
+type GapEvent struct {
+	Subscription types.Subscription
+	PrevTime     int64 // latest time seen before this message, in milliseconds
+	Time         int64 // time of this message, in milliseconds
+	Regressed    bool  // true if Time is older than PrevTime, false for an implausible jump
+}
+
+// gapDetector tracks the latest message time of one subscription
+type gapDetector struct {
+	subscription types.Subscription
+	maxJump      time.Duration
+	onGap        func(GapEvent)
+	mutex        sync.Mutex
+	lastTime     int64
+}
+
+// observe checks the time carried by msgData against the latest time seen
+// It runs on the read pump so messages are seen in the order they arrived.
+func (d *gapDetector) observe(msgData map[string]interface{}) {
+	first, last, ok := messageTimes(msgData["data"])
+	if !ok {
+		return
+	}
+
+	d.mutex.Lock()
+	prev := d.lastTime
+	if last > d.lastTime {
+		d.lastTime = last
+	}
+	d.mutex.Unlock()
+
+	if prev == 0 {
+		return
+	}
+
+	switch {
+	case first < prev:
+		go d.onGap(GapEvent{Subscription: d.subscription, PrevTime: prev, Time: first, Regressed: true})
+	case d.maxJump > 0 && first-prev > d.maxJump.Milliseconds():
+		go d.onGap(GapEvent{Subscription: d.subscription, PrevTime: prev, Time: first})
+	}
+}
+
+// messageTimes returns the earliest and latest time in a message's data: the time field of
+// an object such as an l2Book, or the time fields of an array such as trades
+func messageTimes(data interface{}) (first int64, last int64, ok bool) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		t, ok := value["time"].(float64)
+		if !ok {
+			return 0, 0, false
+		}
+		return int64(t), int64(t), true
+	case []interface{}:
+		for _, item := range value {
+			entry, isMap := item.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+			t, isNumber := entry["time"].(float64)
+			if !isNumber {
+				continue
+			}
+			if !ok || int64(t) < first {
+				first = int64(t)
+			}
+			if !ok || int64(t) > last {
+				last = int64(t)
+			}
+			ok = true
+		}
+		return first, last, ok
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+func TestGapDetectorReportsOutOfOrderFrames(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	book := types.Subscription{Type: "l2Book", Coin: "ETH"}
+	if err := info.WatchGaps(book, time.Second, func(GapEvent) {}); err == nil {
+		t.Fatal("expected an error watching a subscription that is not registered")
+	}
+
+	books := make(chan types.L2BookData, 8)
+	if err := info.SubscribeL2Book("ETH", func(data types.L2BookData) { books <- data }); err != nil {
+		t.Fatalf("SubscribeL2Book: %v", err)
+	}
+	server.nextWebsocketMessage()
+	gaps := make(chan GapEvent, 8)
+	if err := info.WatchGaps(book, 5*time.Second, func(event GapEvent) { gaps <- event }); err != nil {
+		t.Fatalf("WatchGaps: %v", err)
+	}
+
+	nextGap := func() GapEvent {
+		t.Helper()
+		select {
+		case event := <-gaps:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a gap")
+			return GapEvent{}
+		}
+	}
+	// Frames are sent one at a time and each is awaited, so they arrive in the order sent
+	send := func(frameTime int64) {
+		t.Helper()
+		server.sendWebsocket(l2BookFrame("ETH", "1999", "2001", frameTime))
+		select {
+		case <-books:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for the book at %d", frameTime)
+		}
+	}
+
+	send(1000)
+	send(2000)
+	send(1500)
+	if event := nextGap(); !event.Regressed || event.PrevTime != 2000 || event.Time != 1500 || event.Subscription != book {
+		t.Fatalf("unexpected regression event: %+v", event)
+	}
+
+	// A regression does not move the latest time back, and a jump past maxJump is reported
+	send(2500)
+	send(9000)
+	if event := nextGap(); event.Regressed || event.PrevTime != 2500 || event.Time != 9000 {
+		t.Fatalf("unexpected jump event: %+v", event)
+	}
+
+	send(9000)
+	send(10000)
+	select {
+	case event := <-gaps:
+		t.Fatalf("unexpected gap for in-order frames: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGapDetectorReadsTradeTimes(t *testing.T) {
+	gaps := make(chan GapEvent, 4)
+	detector := &gapDetector{
+		subscription: types.Subscription{Type: "trades", Coin: "ETH"},
+		onGap:        func(event GapEvent) { gaps <- event },
+	}
+	trades := func(times ...float64) map[string]interface{} {
+		var data []interface{}
+		for _, tradeTime := range times {
+			data = append(data, map[string]interface{}{"coin": "ETH", "time": tradeTime})
+		}
+		return map[string]interface{}{"channel": "trades", "data": data}
+	}
+
+	detector.observe(trades(100, 300, 200))
+	detector.observe(trades(300, 400))
+	detector.observe(map[string]interface{}{"channel": "trades", "data": "no times"})
+	select {
+	case event := <-gaps:
+		t.Fatalf("unexpected gap: %+v", event)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Without maxJump only regressions are reported, measured from the earliest trade
+	detector.observe(trades(5000, 350))
+	select {
+	case event := <-gaps:
+		if !event.Regressed || event.PrevTime != 400 || event.Time != 350 {
+			t.Fatalf("unexpected gap: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a gap")
+	}
+}
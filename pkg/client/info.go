@@ -1,21 +1,44 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/sync/errgroup"
 	"hyperliquid-go-sdk/pkg/types"
+	"hyperliquid-go-sdk/pkg/utils"
 )
 
 // Info provides methods to query market data and information
+// The name/asset lookup tables are guarded by metaMutex so RefreshMeta can replace
+// them while other goroutines resolve names.
 type Info struct {
 	*API
+	metaMutex         sync.RWMutex
 	coinToAsset       map[string]int
 	nameToCoin        map[string]string
 	assetToSzDecimals map[int]int
+	spotMeta          *types.SpotMeta
+	perpDexs          []string
+	minNotional       map[string]float64
 	wsManager         *WebsocketManager
 }
 
+// metaMaps holds the lookup tables built from exchange metadata
+type metaMaps struct {
+	coinToAsset       map[string]int
+	nameToCoin        map[string]string
+	assetToSzDecimals map[int]int
+}
+
 // NewInfo creates a new Info client
 func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Meta, spotMeta *types.SpotMeta, perpDexs []string) (*Info, error) {
 	api := NewAPI(baseURL, timeout)
@@ -25,6 +48,7 @@ func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Me
 		coinToAsset:       make(map[string]int),
 		nameToCoin:        make(map[string]string),
 		assetToSzDecimals: make(map[int]int),
+		perpDexs:          perpDexs,
 	}
 
 	// Initialize WebSocket manager if not skipped
@@ -39,20 +63,41 @@ func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Me
 		}
 	}
 
+	if err := info.loadMeta(meta, spotMeta, perpDexs); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// RefreshMeta re-fetches perp and spot metadata and atomically replaces the lookup tables
+// Call it to pick up newly listed assets without recreating the client
+func (i *Info) RefreshMeta() error {
+	return i.loadMeta(nil, nil, i.perpDexs)
+}
+
+// loadMeta builds the lookup tables from metadata, fetching any that is not provided
+func (i *Info) loadMeta(meta *types.Meta, spotMeta *types.SpotMeta, perpDexs []string) error {
+	maps := &metaMaps{
+		coinToAsset:       make(map[string]int),
+		nameToCoin:        make(map[string]string),
+		assetToSzDecimals: make(map[int]int),
+	}
+
 	// Initialize spot meta
 	if spotMeta == nil {
 		var err error
-		spotMeta, err = info.SpotMeta()
+		spotMeta, err = i.SpotMeta()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get spot meta: %w", err)
+			return fmt.Errorf("failed to get spot meta: %w", err)
 		}
 	}
 
 	// Initialize spot assets (start at 10000)
 	for _, spotInfo := range spotMeta.Universe {
 		asset := spotInfo.Index + 10000
-		info.coinToAsset[spotInfo.Name] = asset
-		info.nameToCoin[spotInfo.Name] = spotInfo.Name
+		maps.coinToAsset[spotInfo.Name] = asset
+		maps.nameToCoin[spotInfo.Name] = spotInfo.Name
 
 		if len(spotInfo.Tokens) >= 2 {
 			base := spotInfo.Tokens[0]
@@ -61,11 +106,11 @@ func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Me
 			if base < len(spotMeta.Tokens) && quote < len(spotMeta.Tokens) {
 				baseInfo := spotMeta.Tokens[base]
 				quoteInfo := spotMeta.Tokens[quote]
-				info.assetToSzDecimals[asset] = baseInfo.SzDecimals
+				maps.assetToSzDecimals[asset] = baseInfo.SzDecimals
 
 				name := fmt.Sprintf("%s/%s", baseInfo.Name, quoteInfo.Name)
-				if _, exists := info.nameToCoin[name]; !exists {
-					info.nameToCoin[name] = spotInfo.Name
+				if _, exists := maps.nameToCoin[name]; !exists {
+					maps.nameToCoin[name] = spotInfo.Name
 				}
 			}
 		}
@@ -77,16 +122,19 @@ func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Me
 	if perpDexs == nil {
 		perpDexs = []string{""}
 	} else {
-		perpDexsList, err := info.PerpDexs()
+		perpDexsList, err := i.PerpDexs()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get perp dexs: %w", err)
+			return fmt.Errorf("failed to get perp dexs: %w", err)
 		}
 
-		for i, perpDex := range perpDexsList[1:] {
+		if len(perpDexsList) > 0 {
+			perpDexsList = perpDexsList[1:]
+		}
+		for idx, perpDex := range perpDexsList {
 			// builder-deployed perp dexs start at 110000
 			if perpDexMap, ok := perpDex.(map[string]interface{}); ok {
 				if name, ok := perpDexMap["name"].(string); ok {
-					perpDexToOffset[name] = 110000 + i*10000
+					perpDexToOffset[name] = 110000 + idx*10000
 				}
 			}
 		}
@@ -102,26 +150,134 @@ func NewInfo(baseURL string, timeout *time.Duration, skipWS bool, meta *types.Me
 		if perpDex == "" && meta != nil {
 			perpMeta = meta
 		} else {
-			perpMeta, err = info.Meta(perpDex)
+			perpMeta, err = i.Meta(perpDex)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get meta for dex %s: %w", perpDex, err)
+				return fmt.Errorf("failed to get meta for dex %s: %w", perpDex, err)
 			}
 		}
 
-		info.setPerpMeta(perpMeta, offset)
+		maps.setPerpMeta(perpMeta, offset)
 	}
 
-	return info, nil
+	i.metaMutex.Lock()
+	i.coinToAsset = maps.coinToAsset
+	i.nameToCoin = maps.nameToCoin
+	i.assetToSzDecimals = maps.assetToSzDecimals
+	i.spotMeta = spotMeta
+	i.metaMutex.Unlock()
+
+	return nil
 }
 
 // setPerpMeta sets the perpetual asset metadata
-func (i *Info) setPerpMeta(meta *types.Meta, offset int) {
+func (m *metaMaps) setPerpMeta(meta *types.Meta, offset int) {
 	for asset, assetInfo := range meta.Universe {
 		actualAsset := asset + offset
-		i.coinToAsset[assetInfo.Name] = actualAsset
-		i.nameToCoin[assetInfo.Name] = assetInfo.Name
-		i.assetToSzDecimals[actualAsset] = assetInfo.SzDecimals
+		m.coinToAsset[assetInfo.Name] = actualAsset
+		m.nameToCoin[assetInfo.Name] = assetInfo.Name
+		m.assetToSzDecimals[actualAsset] = assetInfo.SzDecimals
+	}
+}
+
+// coinForName resolves a name (coin or spot pair alias) to its coin
+func (i *Info) coinForName(name string) (string, bool) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	coin, exists := i.nameToCoin[name]
+	return coin, exists
+}
+
+// normalizeName finds the name matching name after trimming whitespace and ignoring case
+// An exact match wins; a name that folds to several distinct names is treated as not found.
+func (i *Info) normalizeName(name string) (string, bool) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	trimmed := strings.TrimSpace(name)
+	if _, exists := i.nameToCoin[trimmed]; exists {
+		return trimmed, true
+	}
+
+	var match string
+	for candidate := range i.nameToCoin {
+		if !strings.EqualFold(candidate, trimmed) {
+			continue
+		}
+		if match != "" {
+			return "", false
+		}
+		match = candidate
 	}
+
+	return match, match != ""
+}
+
+// Universe returns every perp, spot and builder-deployed perp asset known to the client,
+// sorted by asset ID. Spot entries are named by their BASE/QUOTE pair where one exists.
+// It reads the cached metadata; call RefreshMeta first to pick up new listings.
+func (i *Info) Universe() ([]types.UniverseEntry, error) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	if len(i.coinToAsset) == 0 {
+		return nil, fmt.Errorf("no asset metadata loaded")
+	}
+
+	pairNames := make(map[string]string)
+	for name, coin := range i.nameToCoin {
+		if name != coin && strings.Contains(name, "/") {
+			pairNames[coin] = name
+		}
+	}
+
+	entries := make([]types.UniverseEntry, 0, len(i.coinToAsset))
+	for coin, asset := range i.coinToAsset {
+		entry := types.UniverseEntry{
+			Name:       coin,
+			Coin:       coin,
+			AssetID:    asset,
+			SzDecimals: i.assetToSzDecimals[asset],
+		}
+
+		switch {
+		case asset >= 110000:
+			entry.Kind = types.AssetKindBuilder
+		case asset >= 10000:
+			entry.Kind = types.AssetKindSpot
+			if name, exists := pairNames[coin]; exists {
+				entry.Name = name
+			}
+		default:
+			entry.Kind = types.AssetKindPerp
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].AssetID < entries[b].AssetID
+	})
+
+	return entries, nil
+}
+
+// assetForCoin resolves a coin to its asset ID
+func (i *Info) assetForCoin(coin string) (int, bool) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	asset, exists := i.coinToAsset[coin]
+	return asset, exists
+}
+
+// szDecimalsForAsset returns the size decimals of an asset ID
+func (i *Info) szDecimalsForAsset(asset int) (int, bool) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	szDecimals, exists := i.assetToSzDecimals[asset]
+	return szDecimals, exists
 }
 
 // DisconnectWebsocket disconnects the WebSocket connection
@@ -132,8 +288,82 @@ func (i *Info) DisconnectWebsocket() error {
 	return i.wsManager.Stop()
 }
 
+// cachedSpotMeta returns the spot metadata loaded by NewInfo or RefreshMeta
+func (i *Info) cachedSpotMeta() *types.SpotMeta {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	return i.spotMeta
+}
+
+// SpotTransferToken resolves a token name to the token string expected by SpotTransfer
+// Canonical tokens are referenced by name; other tokens need "NAME:tokenId" to be unambiguous.
+// Values already in "NAME:tokenId" form are returned unchanged.
+func (i *Info) SpotTransferToken(coin string) (string, error) {
+	if strings.Contains(coin, ":") {
+		return coin, nil
+	}
+
+	spotMeta := i.cachedSpotMeta()
+	if spotMeta == nil {
+		return "", fmt.Errorf("spot meta not loaded")
+	}
+
+	for _, token := range spotMeta.Tokens {
+		if token.Name != coin {
+			continue
+		}
+		if token.IsCanonical {
+			return token.Name, nil
+		}
+		return fmt.Sprintf("%s:%s", token.Name, token.TokenId), nil
+	}
+
+	return "", fmt.Errorf("spot token not found: %s", coin)
+}
+
+// TokenInfo returns the cached spot metadata of a token by name, or by "NAME:tokenId"
+// When several tokens share a name the canonical one is returned; use "NAME:tokenId" to pick another.
+func (i *Info) TokenInfo(name string) (*types.SpotTokenInfo, error) {
+	tokenName, tokenID, hasID := strings.Cut(name, ":")
+
+	spotMeta := i.cachedSpotMeta()
+	if spotMeta == nil {
+		return nil, fmt.Errorf("spot meta not loaded")
+	}
+
+	var match *types.SpotTokenInfo
+	for idx := range spotMeta.Tokens {
+		token := spotMeta.Tokens[idx]
+		if token.Name != tokenName || (hasID && !strings.EqualFold(token.TokenId, tokenID)) {
+			continue
+		}
+		if match == nil || (token.IsCanonical && !match.IsCanonical) {
+			match = &token
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("spot token not found: %s", name)
+	}
+
+	return match, nil
+}
+
+// tokenWeiDecimals returns the wei decimals of a spot token by name (or "NAME:tokenId")
+func (i *Info) tokenWeiDecimals(token string) (int, error) {
+	tokenInfo, err := i.TokenInfo(token)
+	if err != nil {
+		return 0, err
+	}
+
+	return tokenInfo.WeiDecimals, nil
+}
+
 // NameToAsset converts asset name to asset ID
 func (i *Info) NameToAsset(name string) (int, error) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
 	if coin, exists := i.nameToCoin[name]; exists {
 		if asset, exists := i.coinToAsset[coin]; exists {
 			return asset, nil
@@ -142,91 +372,158 @@ func (i *Info) NameToAsset(name string) (int, error) {
 	return 0, fmt.Errorf("asset not found: %s", name)
 }
 
-// UserState retrieves trading details about a user
-func (i *Info) UserState(address string, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type": "clearinghouseState",
-		"user": address,
-	}
+// SpotAssetId returns the asset ID used to order a spot pair, 10000 plus the pair's index
+// pairName may be the pair's "BASE/QUOTE" name or its spot coin such as "@107".
+func (i *Info) SpotAssetId(pairName string) (int, error) {
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
 
-	if dex != "" {
-		payload["dex"] = dex
+	coin, exists := i.nameToCoin[pairName]
+	if !exists {
+		return 0, fmt.Errorf("spot pair not found: %s", pairName)
+	}
+	asset, exists := i.coinToAsset[coin]
+	if !exists {
+		return 0, fmt.Errorf("asset not found for spot pair: %s", pairName)
+	}
+	if asset < 10000 || asset >= 110000 {
+		return 0, fmt.Errorf("%s is not a spot pair (asset %d)", pairName, asset)
 	}
 
-	return i.Post("/info", payload)
+	return asset, nil
 }
 
-// OpenOrders retrieves a user's open orders
-func (i *Info) OpenOrders(address string, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type": "openOrders",
-		"user": address,
+// SetMinNotional overrides the minimum order notional for coin
+// The exchange does not publish per-asset minimums, so utils.DefaultMinNotional applies otherwise.
+func (i *Info) SetMinNotional(coin string, minNotional float64) {
+	i.metaMutex.Lock()
+	defer i.metaMutex.Unlock()
+
+	if i.minNotional == nil {
+		i.minNotional = make(map[string]float64)
 	}
+	i.minNotional[coin] = minNotional
+}
 
-	if dex != "" {
-		payload["dex"] = dex
+// MinNotional returns the minimum order notional in USD for coin
+func (i *Info) MinNotional(coin string) (float64, error) {
+	resolved, exists := i.coinForName(coin)
+	if !exists {
+		return 0, fmt.Errorf("coin not found: %s", coin)
 	}
 
-	return i.Post("/info", payload)
+	i.metaMutex.RLock()
+	defer i.metaMutex.RUnlock()
+
+	if minNotional, ok := i.minNotional[coin]; ok {
+		return minNotional, nil
+	}
+	if minNotional, ok := i.minNotional[resolved]; ok {
+		return minNotional, nil
+	}
+	return utils.DefaultMinNotional, nil
 }
 
-// FrontendOpenOrders retrieves a user's open orders with additional frontend data
-func (i *Info) FrontendOpenOrders(address string, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type": "frontendOpenOrders",
-		"user": address,
+// ValidateOrder checks an order against the exchange's basic requirements before it is sent
+// Reduce-only orders are exempt from the minimum notional so small positions can be closed.
+func (i *Info) ValidateOrder(order types.OrderRequest) error {
+	if order.Sz <= 0 {
+		return utils.NewValidationError("sz", fmt.Sprintf("must be positive, got %f", order.Sz))
+	}
+	if order.LimitPx <= 0 {
+		return utils.NewValidationError("limitPx", fmt.Sprintf("must be positive, got %f", order.LimitPx))
 	}
 
-	if dex != "" {
-		payload["dex"] = dex
+	minNotional, err := i.MinNotional(order.Coin)
+	if err != nil {
+		return err
+	}
+	if !order.ReduceOnly && !utils.MeetsMinNotional(order.Sz, order.LimitPx, minNotional) {
+		return utils.NewValidationError("sz", fmt.Sprintf("order notional %.2f is below the minimum of %.2f", order.Notional(), minNotional))
 	}
 
-	return i.Post("/info", payload)
+	return nil
 }
 
-// UserFills retrieves a user's fills
-func (i *Info) UserFills(address string, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type": "userFills",
-		"user": address,
+// SzDecimals returns the size decimals for a coin from the cached metadata
+func (i *Info) SzDecimals(coin string) (int, error) {
+	asset, err := i.NameToAsset(coin)
+	if err != nil {
+		return 0, err
 	}
 
-	if dex != "" {
-		payload["dex"] = dex
+	szDecimals, exists := i.szDecimalsForAsset(asset)
+	if !exists {
+		return 0, fmt.Errorf("size decimals not found for asset: %s", coin)
 	}
 
-	return i.Post("/info", payload)
+	return szDecimals, nil
 }
 
-// UserFillsByTime retrieves a user's fills within a time range
-func (i *Info) UserFillsByTime(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type":      "userFillsByTime",
-		"user":      address,
-		"startTime": startTime,
+// maxPriceSigFigs is the number of significant figures allowed in non-integer prices
+const maxPriceSigFigs = 5
+
+// roundPrice rounds px to the exchange's price rules for asset: at most 5 significant figures
+// (integer prices are always allowed) and at most 6 - szDecimals decimals for perps or
+// 8 - szDecimals for spot
+func (i *Info) roundPrice(asset int, px float64) float64 {
+	if px != math.Trunc(px) {
+		px = utils.RoundToSignificantFigures(px, maxPriceSigFigs)
 	}
 
-	if endTime != nil {
-		payload["endTime"] = *endTime
+	multiplier := math.Pow(10, float64(i.maxPriceDecimals(asset)))
+	return math.Round(px*multiplier) / multiplier
+}
+
+// maxPriceDecimals returns the maximum number of price decimals for asset
+func (i *Info) maxPriceDecimals(asset int) int {
+	maxDecimals := 6
+	if utils.IsSpotAsset(asset) && !utils.IsPerpAsset(asset) {
+		maxDecimals = 8
+	}
+	if szDecimals, exists := i.szDecimalsForAsset(asset); exists {
+		maxDecimals -= szDecimals
 	}
+	return maxDecimals
+}
 
-	if dex != "" {
-		payload["dex"] = dex
+// priceTick returns the smallest valid price increment for asset near px
+func (i *Info) priceTick(asset int, px float64) float64 {
+	decimalTick := math.Pow(10, -float64(i.maxPriceDecimals(asset)))
+	sigFigTick := math.Pow(10, math.Floor(math.Log10(math.Abs(px)))-(maxPriceSigFigs-1))
+	// Integer prices are always valid
+	sigFigTick = math.Min(sigFigTick, 1)
+	return math.Max(decimalTick, sigFigTick)
+}
+
+// WirePrice returns the exact price string that will be sent for an order on coin at px
+// The price is rounded to the exchange's significant figure and decimal limits first.
+func (i *Info) WirePrice(coin string, px float64) (string, error) {
+	asset, err := i.NameToAsset(coin)
+	if err != nil {
+		return "", err
 	}
 
-	return i.Post("/info", payload)
+	return utils.FloatToWire(i.roundPrice(asset, px))
 }
 
-// UserNonFundingLedgerUpdates retrieves a user's non-funding ledger updates
-func (i *Info) UserNonFundingLedgerUpdates(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
-	payload := map[string]interface{}{
-		"type":      "userNonFundingLedgerUpdates",
-		"user":      address,
-		"startTime": startTime,
+// WireSize returns the exact size string that will be sent for an order on coin of sz
+// The size is rounded to the asset's size decimals first.
+func (i *Info) WireSize(coin string, sz float64) (string, error) {
+	szDecimals, err := i.SzDecimals(coin)
+	if err != nil {
+		return "", err
 	}
 
-	if endTime != nil {
-		payload["endTime"] = *endTime
+	multiplier := math.Pow(10, float64(szDecimals))
+	return utils.FloatToWire(math.Round(sz*multiplier) / multiplier)
+}
+
+// UserState retrieves trading details about a user
+func (i *Info) UserState(address string, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "clearinghouseState",
+		"user": address,
 	}
 
 	if dex != "" {
@@ -236,45 +533,45 @@ func (i *Info) UserNonFundingLedgerUpdates(address string, startTime int64, endT
 	return i.Post("/info", payload)
 }
 
-// UserFunding retrieves a user's funding history
-func (i *Info) UserFunding(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
+// UserStateTyped retrieves trading details about a user decoded into a ClearinghouseState
+func (i *Info) UserStateTyped(address string, dex string) (*types.ClearinghouseState, error) {
 	payload := map[string]interface{}{
-		"type":      "userFunding",
-		"user":      address,
-		"startTime": startTime,
-	}
-
-	if endTime != nil {
-		payload["endTime"] = *endTime
+		"type": "clearinghouseState",
+		"user": address,
 	}
 
 	if dex != "" {
 		payload["dex"] = dex
 	}
 
-	return i.Post("/info", payload)
+	var state types.ClearinghouseState
+	if err := i.postInto("/info", payload, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
 }
 
-// UserRateLimit retrieves a user's rate limit information
-func (i *Info) UserRateLimit(address string, dex string) (map[string]interface{}, error) {
+// SpotUserState retrieves a user's spot token balances
+func (i *Info) SpotUserState(address string) (*types.SpotClearinghouseState, error) {
 	payload := map[string]interface{}{
-		"type": "userRateLimit",
+		"type": "spotClearinghouseState",
 		"user": address,
 	}
 
-	if dex != "" {
-		payload["dex"] = dex
+	var state types.SpotClearinghouseState
+	if err := i.postInto("/info", payload, &state); err != nil {
+		return nil, err
 	}
 
-	return i.Post("/info", payload)
+	return &state, nil
 }
 
-// OrderStatus retrieves the status of an order
-func (i *Info) OrderStatus(address string, oid int, dex string) (map[string]interface{}, error) {
+// OpenOrders retrieves a user's open orders
+func (i *Info) OpenOrders(address string, dex string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
-		"type": "orderStatus",
+		"type": "openOrders",
 		"user": address,
-		"oid":  oid,
 	}
 
 	if dex != "" {
@@ -284,33 +581,30 @@ func (i *Info) OrderStatus(address string, oid int, dex string) (map[string]inte
 	return i.Post("/info", payload)
 }
 
-// L2Book retrieves the L2 order book for an asset
-func (i *Info) L2Book(coin string, dex string, nSigFigs *int, mantissa *int) (map[string]interface{}, error) {
+// OpenOrdersTyped retrieves a user's open orders decoded into OpenOrder values
+func (i *Info) OpenOrdersTyped(address string, dex string) ([]types.OpenOrder, error) {
 	payload := map[string]interface{}{
-		"type": "l2Book",
-		"coin": coin,
+		"type": "openOrders",
+		"user": address,
 	}
 
 	if dex != "" {
 		payload["dex"] = dex
 	}
 
-	if nSigFigs != nil {
-		payload["nSigFigs"] = *nSigFigs
-	}
-
-	if mantissa != nil {
-		payload["mantissa"] = *mantissa
+	var orders []types.OpenOrder
+	if err := i.postInto("/info", payload, &orders); err != nil {
+		return nil, err
 	}
 
-	return i.Post("/info", payload)
+	return orders, nil
 }
 
-// RecentTrades retrieves recent trades for an asset
-func (i *Info) RecentTrades(coin string, dex string) (map[string]interface{}, error) {
+// FrontendOpenOrders retrieves a user's open orders with additional frontend data
+func (i *Info) FrontendOpenOrders(address string, dex string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
-		"type": "recentTrades",
-		"coin": coin,
+		"type": "frontendOpenOrders",
+		"user": address,
 	}
 
 	if dex != "" {
@@ -320,36 +614,29 @@ func (i *Info) RecentTrades(coin string, dex string) (map[string]interface{}, er
 	return i.Post("/info", payload)
 }
 
-// AllMids retrieves mid prices for all assets
-func (i *Info) AllMids(dex string) (map[string]string, error) {
+// FrontendOpenOrdersTyped retrieves a user's open orders with tif and trigger details decoded
+func (i *Info) FrontendOpenOrdersTyped(address string, dex string) ([]types.FrontendOpenOrder, error) {
 	payload := map[string]interface{}{
-		"type": "allMids",
+		"type": "frontendOpenOrders",
+		"user": address,
 	}
 
 	if dex != "" {
 		payload["dex"] = dex
 	}
 
-	result, err := i.Post("/info", payload)
-	if err != nil {
+	var orders []types.FrontendOpenOrder
+	if err := i.postInto("/info", payload, &orders); err != nil {
 		return nil, err
 	}
 
-	mids := make(map[string]string)
-	// The API response directly contains the price data, not wrapped in a 'mids' key
-	for k, v := range result {
-		if str, ok := v.(string); ok {
-			mids[k] = str
-		}
-	}
-
-	return mids, nil
+	return orders, nil
 }
 
-// UserTradesHistory retrieves a user's trade history
-func (i *Info) UserTradesHistory(address string, dex string) (map[string]interface{}, error) {
+// UserFills retrieves a user's fills
+func (i *Info) UserFills(address string, dex string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
-		"type": "userTradesHistory",
+		"type": "userFills",
 		"user": address,
 	}
 
@@ -360,38 +647,835 @@ func (i *Info) UserTradesHistory(address string, dex string) (map[string]interfa
 	return i.Post("/info", payload)
 }
 
-// Meta retrieves the universe of perpetual assets
-func (i *Info) Meta(dex string) (*types.Meta, error) {
+// UserFillsTyped retrieves a user's most recent fills decoded into Fill values
+func (i *Info) UserFillsTyped(address string, dex string) ([]types.Fill, error) {
 	payload := map[string]interface{}{
-		"type": "meta",
+		"type": "userFills",
+		"user": address,
 	}
 
 	if dex != "" {
 		payload["dex"] = dex
 	}
 
-	result, err := i.Post("/info", payload)
-	if err != nil {
+	var fills []types.Fill
+	if err := i.postInto("/info", payload, &fills); err != nil {
 		return nil, err
 	}
 
-	var meta types.Meta
-	if universe, ok := result["universe"].([]interface{}); ok {
-		for _, item := range universe {
-			if assetMap, ok := item.(map[string]interface{}); ok {
-				var asset types.AssetInfo
-				if name, ok := assetMap["name"].(string); ok {
-					asset.Name = name
-				}
-				if szDecimals, ok := assetMap["szDecimals"].(float64); ok {
-					asset.SzDecimals = int(szDecimals)
-				}
+	return fills, nil
+}
+
+// UserFillsByTimePageSize is the maximum number of fills returned by a single userFillsByTime request
+const UserFillsByTimePageSize = 2000
+
+// UserFillsByTime retrieves a user's fills within a time range
+// At most UserFillsByTimePageSize fills are returned; use UserFillsByTimePage to detect truncation.
+func (i *Info) UserFillsByTime(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type":      "userFillsByTime",
+		"user":      address,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// UserFillsByTimePage retrieves one page of a user's fills within a time range
+// hasMore is true when the page is full, meaning fills beyond the last returned one may exist;
+// request the next page starting after the last fill's time. pageSize is the cap the server applies;
+// it is not sent with the request, so values <= 0 or above UserFillsByTimePageSize use that cap.
+func (i *Info) UserFillsByTimePage(address string, startTime int64, endTime *int64, dex string, pageSize int) ([]types.Fill, bool, error) {
+	if pageSize <= 0 {
+		pageSize = UserFillsByTimePageSize
+	}
+	pageSize = min(pageSize, UserFillsByTimePageSize)
+
+	payload := map[string]interface{}{
+		"type":      "userFillsByTime",
+		"user":      address,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	var fills []types.Fill
+	if err := i.postInto("/info", payload, &fills); err != nil {
+		return nil, false, err
+	}
+
+	return fills, len(fills) >= pageSize, nil
+}
+
+// AllUserFillsByTime retrieves all of a user's fills between startTime and endTime
+// Pages are fetched by moving startTime up to the last returned fill's time until a page comes
+// back short, and fills repeated across page boundaries are dropped by tid.
+func (i *Info) AllUserFillsByTime(ctx context.Context, user string, startTime, endTime int64) ([]types.Fill, error) {
+	var all []types.Fill
+	seen := make(map[int]bool)
+
+	for startTime <= endTime {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		payload := map[string]interface{}{
+			"type":      "userFillsByTime",
+			"user":      user,
+			"startTime": startTime,
+			"endTime":   endTime,
+		}
+
+		var page []types.Fill
+		if err := i.postIntoContext(ctx, "/info", payload, &page); err != nil {
+			return nil, fmt.Errorf("failed to get fills from %d: %w", startTime, err)
+		}
+
+		added := 0
+		lastTime := startTime
+		for _, fill := range page {
+			if fill.Time > lastTime {
+				lastTime = fill.Time
+			}
+			if seen[fill.Tid] {
+				continue
+			}
+			seen[fill.Tid] = true
+			all = append(all, fill)
+			added++
+		}
+
+		if len(page) < UserFillsByTimePageSize {
+			break
+		}
+
+		// Fills sharing the last millisecond are requested again and deduped; if a whole page
+		// was repeats, step past that millisecond so paging always makes progress
+		if added == 0 || lastTime == startTime {
+			lastTime++
+		}
+		startTime = lastTime
+	}
+
+	return all, nil
+}
+
+// UserSnapshot fetches a user's perp state, open orders and fills concurrently
+// It is AccountSnapshot without the spot state.
+func (i *Info) UserSnapshot(address string) (*types.UserSnapshot, error) {
+	snapshot, err := i.accountSnapshot(context.Background(), address, false)
+	if err != nil {
+		return nil, fmt.Errorf("user snapshot for %s: %w", address, err)
+	}
+
+	return &types.UserSnapshot{
+		User:       snapshot.User,
+		State:      snapshot.State,
+		OpenOrders: snapshot.OpenOrders,
+		Fills:      snapshot.Fills,
+	}, nil
+}
+
+// AccountSnapshot fetches a user's perp state, spot balances, open orders and recent fills concurrently
+func (i *Info) AccountSnapshot(user string) (*types.AccountSnapshot, error) {
+	return i.AccountSnapshotContext(context.Background(), user)
+}
+
+// AccountSnapshotContext is AccountSnapshot with a context; the first failure cancels the remaining requests
+func (i *Info) AccountSnapshotContext(ctx context.Context, user string) (*types.AccountSnapshot, error) {
+	snapshot, err := i.accountSnapshot(ctx, user, true)
+	if err != nil {
+		return nil, fmt.Errorf("account snapshot for %s: %w", user, err)
+	}
+
+	return snapshot, nil
+}
+
+// accountSnapshot runs the snapshot requests concurrently, skipping the spot state unless includeSpot is set
+func (i *Info) accountSnapshot(ctx context.Context, user string, includeSpot bool) (*types.AccountSnapshot, error) {
+	snapshot := &types.AccountSnapshot{
+		User:  user,
+		State: &types.ClearinghouseState{},
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(4)
+
+	g.Go(func() error {
+		payload := map[string]interface{}{"type": "clearinghouseState", "user": user}
+		if err := i.postIntoContext(gctx, "/info", payload, snapshot.State); err != nil {
+			return fmt.Errorf("failed to get clearinghouse state: %w", err)
+		}
+		return nil
+	})
+	if includeSpot {
+		snapshot.SpotState = &types.SpotClearinghouseState{}
+		g.Go(func() error {
+			payload := map[string]interface{}{"type": "spotClearinghouseState", "user": user}
+			if err := i.postIntoContext(gctx, "/info", payload, snapshot.SpotState); err != nil {
+				return fmt.Errorf("failed to get spot state: %w", err)
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		payload := map[string]interface{}{"type": "openOrders", "user": user}
+		if err := i.postIntoContext(gctx, "/info", payload, &snapshot.OpenOrders); err != nil {
+			return fmt.Errorf("failed to get open orders: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		payload := map[string]interface{}{"type": "userFills", "user": user}
+		if err := i.postIntoContext(gctx, "/info", payload, &snapshot.Fills); err != nil {
+			return fmt.Errorf("failed to get user fills: %w", err)
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// UserNonFundingLedgerUpdates retrieves a user's non-funding ledger updates
+func (i *Info) UserNonFundingLedgerUpdates(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type":      "userNonFundingLedgerUpdates",
+		"user":      address,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// UserFunding retrieves a user's funding history
+func (i *Info) UserFunding(address string, startTime int64, endTime *int64, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type":      "userFunding",
+		"user":      address,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// FundingSummary returns the net funding per coin over the window, summed from the usdc deltas
+// of the user's funding history. Positive values were received, negative values were paid.
+func (i *Info) FundingSummary(user string, startTime int64, endTime *int64) (map[string]float64, error) {
+	payload := map[string]interface{}{
+		"type":      "userFunding",
+		"user":      user,
+		"startTime": startTime,
+	}
+
+	if endTime != nil {
+		payload["endTime"] = *endTime
+	}
+
+	var history []types.UserFundingEntry
+	if err := i.postInto("/info", payload, &history); err != nil {
+		return nil, err
+	}
+
+	summary := make(map[string]float64)
+	for _, entry := range history {
+		usdc, err := strconv.ParseFloat(entry.Delta.Usdc, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid funding usdc %q for %s: %w", entry.Delta.Usdc, entry.Delta.Coin, err)
+		}
+		summary[entry.Delta.Coin] += usdc
+	}
+
+	return summary, nil
+}
+
+// UserRateLimit retrieves a user's rate limit information
+func (i *Info) UserRateLimit(address string, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "userRateLimit",
+		"user": address,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// UserFees retrieves a user's current maker/taker fee rates and referral discount
+func (i *Info) UserFees(address string) (*types.UserFees, error) {
+	payload := map[string]interface{}{
+		"type": "userFees",
+		"user": address,
+	}
+
+	var fees types.UserFees
+	if err := i.postInto("/info", payload, &fees); err != nil {
+		return nil, err
+	}
+
+	return &fees, nil
+}
+
+// UserTwapOrders retrieves a user's currently running TWAP orders
+// Finished, terminated and errored TWAPs from the history are omitted.
+func (i *Info) UserTwapOrders(user string) ([]types.TwapState, error) {
+	payload := map[string]interface{}{
+		"type": "twapHistory",
+		"user": user,
+	}
+
+	var history []struct {
+		TwapID *int64          `json:"twapId"`
+		State  types.TwapState `json:"state"`
+		Status struct {
+			Status string `json:"status"`
+		} `json:"status"`
+	}
+	if err := i.postInto("/info", payload, &history); err != nil {
+		return nil, err
+	}
+
+	twaps := make([]types.TwapState, 0, len(history))
+	for _, entry := range history {
+		if entry.Status.Status != "activated" {
+			continue
+		}
+		state := entry.State
+		if entry.TwapID != nil {
+			state.TwapID = *entry.TwapID
+		}
+		state.Status = entry.Status.Status
+		twaps = append(twaps, state)
+	}
+
+	return twaps, nil
+}
+
+// Referral retrieves a user's referral status and reward totals
+func (i *Info) Referral(address string) (*types.ReferralState, error) {
+	payload := map[string]interface{}{
+		"type": "referral",
+		"user": address,
+	}
+
+	var state types.ReferralState
+	if err := i.postInto("/info", payload, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// ReferralRewards returns the referral rewards in USDC that address can currently claim
+func (i *Info) ReferralRewards(address string) (float64, error) {
+	state, err := i.Referral(address)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.UnclaimedRewardsFloat()
+}
+
+// EstimateFee estimates the fee for trading notional of coin at the user's current rate
+// Maker (add liquidity) or taker (cross) rates are selected by isMaker, and spot rates are used
+// for spot assets. The active referral discount is applied.
+func (i *Info) EstimateFee(user string, coin string, notional float64, isMaker bool) (float64, error) {
+	asset, err := i.NameToAsset(coin)
+	if err != nil {
+		return 0, err
+	}
+
+	fees, err := i.UserFees(user)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user fees: %w", err)
+	}
+
+	// spot assets start at 10000, builder-deployed perps at 110000
+	isSpot := utils.IsSpotAsset(asset) && !utils.IsPerpAsset(asset)
+
+	var rateStr string
+	switch {
+	case isSpot && isMaker:
+		rateStr = fees.UserSpotAddRate
+	case isSpot:
+		rateStr = fees.UserSpotCrossRate
+	case isMaker:
+		rateStr = fees.UserAddRate
+	default:
+		rateStr = fees.UserCrossRate
+	}
+
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse fee rate %q: %w", rateStr, err)
+	}
+
+	discount := 0.0
+	if fees.ActiveReferralDiscount != "" {
+		discount, err = strconv.ParseFloat(fees.ActiveReferralDiscount, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse referral discount: %w", err)
+		}
+	}
+
+	return notional * rate * (1 - discount), nil
+}
+
+// OrderStatus retrieves the status of an order
+func (i *Info) OrderStatus(address string, oid int, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "orderStatus",
+		"user": address,
+		"oid":  oid,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// OrderStatusTyped retrieves the status of an order by oid decoded into an OrderStatusResult
+// An unknown oid is not an error; check Found on the result.
+func (i *Info) OrderStatusTyped(address string, oid int, dex string) (*types.OrderStatusResult, error) {
+	payload := map[string]interface{}{
+		"type": "orderStatus",
+		"user": address,
+		"oid":  oid,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	var result types.OrderStatusResult
+	if err := i.postInto("/info", payload, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// L2Book retrieves the L2 order book for an asset
+func (i *Info) L2Book(coin string, dex string, nSigFigs *int, mantissa *int) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "l2Book",
+		"coin": coin,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	if nSigFigs != nil {
+		payload["nSigFigs"] = *nSigFigs
+	}
+
+	if mantissa != nil {
+		payload["mantissa"] = *mantissa
+	}
+
+	return i.Post("/info", payload)
+}
+
+// L2BookTyped retrieves an L2 book snapshot for coin decoded into L2BookData
+// Levels[0] holds bids and Levels[1] asks, best first.
+func (i *Info) L2BookTyped(coin string, dex string) (*types.L2BookData, error) {
+	if mapped, exists := i.coinForName(coin); exists {
+		coin = mapped
+	}
+
+	payload := map[string]interface{}{
+		"type": "l2Book",
+		"coin": coin,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	var book types.L2BookData
+	if err := i.postInto("/info", payload, &book); err != nil {
+		return nil, err
+	}
+
+	return &book, nil
+}
+
+// ExpectedFillPrice fetches the current book for coin and returns the average price a market order
+// of sz would fill at, and how much of sz the book can absorb
+func (i *Info) ExpectedFillPrice(coin string, isBuy bool, sz float64) (avgPx float64, filledSz float64, err error) {
+	if sz <= 0 {
+		return 0, 0, utils.NewValidationError("sz", fmt.Sprintf("must be positive, got %f", sz))
+	}
+
+	book, err := i.L2BookTyped(coin, "")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get book: %w", err)
+	}
+
+	avgPx, filledSz = utils.BookFillPrice(*book, isBuy, sz)
+	if filledSz == 0 {
+		side := "bid"
+		if isBuy {
+			side = "ask"
+		}
+		return 0, 0, fmt.Errorf("no liquidity on the %s side of the %s book", side, coin)
+	}
+
+	return avgPx, filledSz, nil
+}
+
+// RecentTrades retrieves recent trades for an asset
+func (i *Info) RecentTrades(coin string, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "recentTrades",
+		"coin": coin,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// AllMids retrieves mid prices for all assets
+// Mids are scoped to dex: "" returns the main dex and a builder dex name returns only that
+// dex's mids. Results are not cached, so callers caching mids must key them by dex.
+func (i *Info) AllMids(dex string) (map[string]string, error) {
+	payload := map[string]interface{}{
+		"type": "allMids",
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	result, err := i.Post("/info", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	mids := make(map[string]string)
+	// The API response directly contains the price data, not wrapped in a 'mids' key
+	for k, v := range result {
+		if str, ok := v.(string); ok {
+			mids[k] = str
+		}
+	}
+
+	return mids, nil
+}
+
+// UserTradesHistory retrieves a user's trade history
+// Note: userTradesHistory is not part of the documented info API. Entries share the
+// userFills schema; prefer UserFills or UserFillsByTime, which are the supported endpoints.
+func (i *Info) UserTradesHistory(address string, dex string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type": "userTradesHistory",
+		"user": address,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	return i.Post("/info", payload)
+}
+
+// CandleSnapshot retrieves candles for an asset within a time range
+func (i *Info) CandleSnapshot(name string, interval string, startTime int64, endTime int64) ([]types.Candle, error) {
+	if err := utils.ValidateCandleInterval(interval); err != nil {
+		return nil, err
+	}
+
+	coin := name
+	if mapped, exists := i.coinForName(name); exists {
+		coin = mapped
+	}
+
+	payload := map[string]interface{}{
+		"type": "candleSnapshot",
+		"req": map[string]interface{}{
+			"coin":      coin,
+			"interval":  interval,
+			"startTime": startTime,
+			"endTime":   endTime,
+		},
+	}
+
+	var candles []types.Candle
+	if err := i.postInto("/info", payload, &candles); err != nil {
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+// UserTradesHistoryTyped retrieves a user's trade history decoded into fills
+// Unlike public Trades, each entry is one of the user's own executions and carries
+// the order id, direction, closed PnL and fee.
+func (i *Info) UserTradesHistoryTyped(address string, dex string) ([]types.Fill, error) {
+	payload := map[string]interface{}{
+		"type": "userTradesHistory",
+		"user": address,
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	var fills []types.Fill
+	if err := i.postInto("/info", payload, &fills); err != nil {
+		return nil, err
+	}
+
+	return fills, nil
+}
+
+// Meta retrieves the universe of perpetual assets
+func (i *Info) Meta(dex string) (*types.Meta, error) {
+	payload := map[string]interface{}{
+		"type": "meta",
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	result, err := i.Post("/info", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta types.Meta
+	if universe, ok := result["universe"].([]interface{}); ok {
+		for _, item := range universe {
+			if assetMap, ok := item.(map[string]interface{}); ok {
+				var asset types.AssetInfo
+				if name, ok := assetMap["name"].(string); ok {
+					asset.Name = name
+				}
+				if szDecimals, ok := assetMap["szDecimals"].(float64); ok {
+					asset.SzDecimals = int(szDecimals)
+				}
+				if maxLeverage, ok := assetMap["maxLeverage"].(float64); ok {
+					asset.MaxLeverage = int(maxLeverage)
+				}
+				if marginTableID, ok := assetMap["marginTableId"].(float64); ok {
+					asset.MarginTableID = int(marginTableID)
+				}
+				if isDelisted, ok := assetMap["isDelisted"].(bool); ok {
+					asset.IsDelisted = isDelisted
+				}
 				meta.Universe = append(meta.Universe, asset)
 			}
 		}
 	}
 
-	return &meta, nil
+	// Margin tables are [id, table] pairs
+	if marginTables, ok := result["marginTables"].([]interface{}); ok {
+		for _, item := range marginTables {
+			pair, ok := item.([]interface{})
+			if !ok || len(pair) != 2 {
+				continue
+			}
+			id, ok := pair[0].(float64)
+			if !ok {
+				continue
+			}
+			tableData, err := json.Marshal(pair[1])
+			if err != nil {
+				continue
+			}
+			var table types.MarginTable
+			if err := json.Unmarshal(tableData, &table); err != nil {
+				continue
+			}
+			table.ID = int(id)
+			meta.MarginTables = append(meta.MarginTables, table)
+		}
+	}
+
+	return &meta, nil
+}
+
+// MaxLeverage returns the max leverage for a position of notional on a perp coin of the default dex,
+// taking the asset's margin table tiers into account
+func (i *Info) MaxLeverage(coin string, notional float64) (int, error) {
+	meta, err := i.Meta("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get meta: %w", err)
+	}
+
+	for _, assetInfo := range meta.Universe {
+		if assetInfo.Name != coin {
+			continue
+		}
+
+		maxLeverage := assetInfo.MaxLeverage
+		for _, table := range meta.MarginTables {
+			if table.ID == assetInfo.MarginTableID {
+				if tierMax := table.MaxLeverageFor(notional); tierMax > 0 {
+					maxLeverage = tierMax
+				}
+				break
+			}
+		}
+		return maxLeverage, nil
+	}
+
+	return 0, fmt.Errorf("coin not found: %s", coin)
+}
+
+// CanSetLeverage checks whether changing the leverage of address's position in coin is safe
+// The new leverage must be within the asset's margin tier for the position notional, and the
+// initial margin at the new leverage must be covered: by the cross account value for cross,
+// or by the isolated margin plus withdrawable balance for isolated. Staying within the tier's
+// max leverage keeps the position above its maintenance margin (half the initial margin at max leverage).
+// If not safe, reason explains why.
+func (i *Info) CanSetLeverage(address string, coin string, leverage int, isCross bool) (bool, string, error) {
+	if leverage < 1 {
+		return false, "leverage must be at least 1", nil
+	}
+
+	meta, err := i.Meta("")
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get meta: %w", err)
+	}
+
+	var assetInfo *types.AssetInfo
+	for idx := range meta.Universe {
+		if meta.Universe[idx].Name == coin {
+			assetInfo = &meta.Universe[idx]
+			break
+		}
+	}
+	if assetInfo == nil {
+		return false, "", fmt.Errorf("coin not found: %s", coin)
+	}
+	if assetInfo.MaxLeverage > 0 && leverage > assetInfo.MaxLeverage {
+		return false, fmt.Sprintf("leverage %dx exceeds max leverage %dx for %s", leverage, assetInfo.MaxLeverage, coin), nil
+	}
+
+	state, err := i.UserStateTyped(address, "")
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get user state: %w", err)
+	}
+
+	var position *types.Position
+	for idx := range state.AssetPositions {
+		if state.AssetPositions[idx].Position.Coin == coin {
+			position = &state.AssetPositions[idx].Position
+			break
+		}
+	}
+	if position == nil {
+		return true, "", nil
+	}
+
+	positionValue, err := strconv.ParseFloat(position.PositionValue, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to parse position value: %w", err)
+	}
+	marginUsed, err := strconv.ParseFloat(position.MarginUsed, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to parse margin used: %w", err)
+	}
+
+	tierMaxLeverage := assetInfo.MaxLeverage
+	for _, table := range meta.MarginTables {
+		if table.ID == assetInfo.MarginTableID {
+			if maxLeverage := table.MaxLeverageFor(positionValue); maxLeverage > 0 {
+				tierMaxLeverage = maxLeverage
+			}
+			break
+		}
+	}
+	if tierMaxLeverage > 0 && leverage > tierMaxLeverage {
+		return false, fmt.Sprintf("leverage %dx exceeds max leverage %dx for a %.2f notional position", leverage, tierMaxLeverage, positionValue), nil
+	}
+
+	requiredMargin := positionValue / float64(leverage)
+	currentlyCross := position.Leverage.IsCross()
+
+	if isCross {
+		// A missing cross margin summary means nothing is held in the cross account
+		var accountValue, totalMarginUsed float64
+		if state.CrossMarginSummary.AccountValue != "" {
+			accountValue, err = strconv.ParseFloat(state.CrossMarginSummary.AccountValue, 64)
+			if err != nil {
+				return false, "", fmt.Errorf("failed to parse cross account value: %w", err)
+			}
+		}
+		if state.CrossMarginSummary.TotalMarginUsed != "" {
+			totalMarginUsed, err = strconv.ParseFloat(state.CrossMarginSummary.TotalMarginUsed, 64)
+			if err != nil {
+				return false, "", fmt.Errorf("failed to parse cross margin used: %w", err)
+			}
+		}
+		if currentlyCross {
+			totalMarginUsed -= marginUsed
+		} else {
+			// Isolated margin returns to the cross account when switching
+			accountValue += marginUsed
+		}
+		if totalMarginUsed+requiredMargin > accountValue {
+			return false, fmt.Sprintf("cross margin required %.2f exceeds account value %.2f", totalMarginUsed+requiredMargin, accountValue), nil
+		}
+		return true, "", nil
+	}
+
+	withdrawable, err := strconv.ParseFloat(state.Withdrawable, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to parse withdrawable: %w", err)
+	}
+	available := withdrawable
+	if !currentlyCross {
+		available += marginUsed
+	}
+	if requiredMargin > available {
+		return false, fmt.Sprintf("isolated margin required %.2f exceeds available %.2f", requiredMargin, available), nil
+	}
+
+	return true, "", nil
 }
 
 // SpotMeta retrieves the universe of spot assets
@@ -474,22 +1558,78 @@ func (i *Info) SpotMeta() (*types.SpotMeta, error) {
 	return &spotMeta, nil
 }
 
+// MetaAndAssetCtxs retrieves perpetual metadata together with the current asset contexts
+// The returned contexts are indexed the same as meta.Universe
+func (i *Info) MetaAndAssetCtxs(dex string) (*types.Meta, []types.PerpAssetCtx, error) {
+	payload := map[string]interface{}{
+		"type": "metaAndAssetCtxs",
+	}
+
+	if dex != "" {
+		payload["dex"] = dex
+	}
+
+	var result []json.RawMessage
+	if err := i.postInto("/info", payload, &result); err != nil {
+		return nil, nil, err
+	}
+
+	if len(result) != 2 {
+		return nil, nil, fmt.Errorf("unexpected metaAndAssetCtxs response length: %d", len(result))
+	}
+
+	var meta struct {
+		Universe []types.AssetInfo `json:"universe"`
+	}
+	if err := json.Unmarshal(result[0], &meta); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode meta: %w", err)
+	}
+
+	var ctxs []types.PerpAssetCtx
+	if err := json.Unmarshal(result[1], &ctxs); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode asset contexts: %w", err)
+	}
+
+	return &types.Meta{Universe: meta.Universe}, ctxs, nil
+}
+
+// EstimateFunding estimates the funding payment for holding a position in coin for the given hours
+// using the current funding rate. A positive result is paid, a negative result is received.
+func (i *Info) EstimateFunding(coin string, notional float64, hours float64, isLong bool) (float64, error) {
+	meta, ctxs, err := i.MetaAndAssetCtxs("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get asset contexts: %w", err)
+	}
+
+	for idx, asset := range meta.Universe {
+		if asset.Name != coin || idx >= len(ctxs) {
+			continue
+		}
+
+		fundingRate, err := strconv.ParseFloat(ctxs[idx].Funding, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse funding rate for %s: %w", coin, err)
+		}
+
+		return utils.EstimateFunding(notional, fundingRate, hours, isLong), nil
+	}
+
+	return 0, fmt.Errorf("asset context not found: %s", coin)
+}
+
 // PerpDexs retrieves the list of perpetual dexes
 func (i *Info) PerpDexs() ([]interface{}, error) {
 	payload := map[string]interface{}{
 		"type": "perpDexs",
 	}
 
-	result, err := i.Post("/info", payload)
-	if err != nil {
+	// The response is a list whose first entry is null, standing for the main dex
+	var dexs []interface{}
+	if err := i.postInto("/info", payload, &dexs); err != nil {
 		return nil, err
 	}
 
-	if dexs, ok := result["dexs"].([]interface{}); ok {
-		return dexs, nil
-	}
-
-	return []interface{}{}, nil
+	return dexs, nil
 }
 
 // ClearinghouseState retrieves clearinghouse state
@@ -511,6 +1651,232 @@ func (i *Info) BatchUserStates(addresses []string, dex string) (map[string]inter
 	return i.Post("/info", payload)
 }
 
+// MaxBuilderFee retrieves the max fee, in tenths of a basis point, user has approved for builder
+func (i *Info) MaxBuilderFee(user string, builder string) (int, error) {
+	payload := map[string]interface{}{
+		"type":    "maxBuilderFee",
+		"user":    user,
+		"builder": strings.ToLower(builder),
+	}
+
+	var maxFee int
+	if err := i.postInto("/info", payload, &maxFee); err != nil {
+		return 0, err
+	}
+
+	return maxFee, nil
+}
+
+// ExtraAgents retrieves the agents approved by an account
+func (i *Info) ExtraAgents(address string) ([]types.ExtraAgent, error) {
+	payload := map[string]interface{}{
+		"type": "extraAgents",
+		"user": address,
+	}
+
+	var agents []types.ExtraAgent
+	if err := i.postInto("/info", payload, &agents); err != nil {
+		return nil, err
+	}
+
+	return agents, nil
+}
+
+// IsAgentApproved returns whether agentAddress is an unexpired agent of user, and its validUntil
+// Addresses are matched case-insensitively. validUntil is 0 if the agent is not found.
+func (i *Info) IsAgentApproved(user string, agentAddress string) (bool, int64, error) {
+	agents, err := i.ExtraAgents(user)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get extra agents: %w", err)
+	}
+
+	for _, agent := range agents {
+		if strings.EqualFold(agent.Address, agentAddress) {
+			return agent.ValidUntil > utils.GetTimestampMS(), agent.ValidUntil, nil
+		}
+	}
+
+	return false, 0, nil
+}
+
+// DelegatorHistory retrieves a user's staking ledger of delegations, undelegations and transfers
+func (i *Info) DelegatorHistory(address string) ([]types.DelegatorLedgerEntry, error) {
+	payload := map[string]interface{}{
+		"type": "delegatorHistory",
+		"user": address,
+	}
+
+	var history []types.DelegatorLedgerEntry
+	if err := i.postInto("/info", payload, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// VaultDetails retrieves details about a vault
+func (i *Info) VaultDetails(vaultAddress string, user string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type":         "vaultDetails",
+		"vaultAddress": vaultAddress,
+	}
+
+	if user != "" {
+		payload["user"] = user
+	}
+
+	return i.Post("/info", payload)
+}
+
+// VaultFollowers retrieves the depositors of a vault with their equity and lockups
+func (i *Info) VaultFollowers(vaultAddress string) ([]types.VaultFollower, error) {
+	payload := map[string]interface{}{
+		"type":         "vaultDetails",
+		"vaultAddress": vaultAddress,
+	}
+
+	var details struct {
+		Followers []types.VaultFollower `json:"followers"`
+	}
+	if err := i.postInto("/info", payload, &details); err != nil {
+		return nil, err
+	}
+
+	return details.Followers, nil
+}
+
+// TxDetails retrieves explorer details for an L1 transaction by hash
+func (i *Info) TxDetails(hash string) (map[string]interface{}, error) {
+	if !utils.ValidateTxHash(hash) {
+		return nil, utils.NewValidationError("hash", "must be 0x followed by 64 hex characters")
+	}
+
+	payload := map[string]interface{}{
+		"type": "txDetails",
+		"hash": hash,
+	}
+
+	var result map[string]interface{}
+	if err := i.postExplorer(payload, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TxDetailsTyped retrieves explorer details for an L1 transaction by hash as a typed struct
+func (i *Info) TxDetailsTyped(hash string) (*types.ExplorerTx, error) {
+	if !utils.ValidateTxHash(hash) {
+		return nil, utils.NewValidationError("hash", "must be 0x followed by 64 hex characters")
+	}
+
+	payload := map[string]interface{}{
+		"type": "txDetails",
+		"hash": hash,
+	}
+
+	var result struct {
+		Tx *types.ExplorerTx `json:"tx"`
+	}
+	if err := i.postExplorer(payload, &result); err != nil {
+		return nil, err
+	}
+	if result.Tx == nil {
+		return nil, fmt.Errorf("transaction not found: %s", hash)
+	}
+
+	return result.Tx, nil
+}
+
+// BlockDetails retrieves explorer details for an L1 block by height
+func (i *Info) BlockDetails(height int64) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"type":   "blockDetails",
+		"height": height,
+	}
+
+	var result map[string]interface{}
+	if err := i.postExplorer(payload, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BlockDetailsTyped retrieves explorer details for an L1 block by height as a typed struct
+func (i *Info) BlockDetailsTyped(height int64) (*types.ExplorerBlock, error) {
+	payload := map[string]interface{}{
+		"type":   "blockDetails",
+		"height": height,
+	}
+
+	var result struct {
+		BlockDetails *types.ExplorerBlock `json:"blockDetails"`
+	}
+	if err := i.postExplorer(payload, &result); err != nil {
+		return nil, err
+	}
+	if result.BlockDetails == nil {
+		return nil, fmt.Errorf("block not found: %d", height)
+	}
+
+	return result.BlockDetails, nil
+}
+
+// UsingBigBlocks returns true if the user's HyperEVM transactions go into big blocks
+// The flag is set with Exchange.EvmUserModify and read from the HyperEVM RPC's eth_usingBigBlocks.
+func (i *Info) UsingBigBlocks(user string) (bool, error) {
+	payload := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_usingBigBlocks",
+		"params":  []string{user},
+	}
+
+	body, statusCode, err := i.doPost(context.Background(), i.EVMRPCURL(), payload)
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Result *bool `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return false, decodeError(statusCode, body, err)
+	}
+	if response.Error != nil {
+		return false, fmt.Errorf("eth_usingBigBlocks failed (%d): %s", response.Error.Code, response.Error.Message)
+	}
+	if response.Result == nil {
+		return false, fmt.Errorf("eth_usingBigBlocks returned no result")
+	}
+
+	return *response.Result, nil
+}
+
+// postExplorer posts a query to the explorer endpoint and decodes the response into v
+func (i *Info) postExplorer(payload map[string]interface{}, v interface{}) error {
+	body, statusCode, err := i.doPost(context.Background(), i.ExplorerURL(), payload)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return decodeError(statusCode, body, err)
+	}
+
+	return nil
+}
+
+// IsConnected returns true if the info client's websocket is connected
+func (i *Info) IsConnected() bool {
+	return i.wsManager != nil && i.wsManager.IsConnected()
+}
+
 // Subscribe subscribes to WebSocket channels (if WebSocket is enabled)
 func (i *Info) Subscribe(subscriptions []types.Subscription, callback func(interface{})) error {
 	if i.wsManager == nil {
@@ -520,6 +1886,26 @@ func (i *Info) Subscribe(subscriptions []types.Subscription, callback func(inter
 	return i.wsManager.Subscribe(subscriptions, callback)
 }
 
+// OnWebsocketFatal sets a callback invoked once if the websocket permanently loses its connection
+// after exhausting its reconnection attempts; subscriptions are dead from then on
+func (i *Info) OnWebsocketFatal(callback func(error)) error {
+	if i.wsManager == nil {
+		return fmt.Errorf("WebSocket manager not available (skip_ws was used)")
+	}
+
+	i.wsManager.SetOnFatal(callback)
+	return nil
+}
+
+// WatchGaps reports suspected dropped messages on an existing subscription; see WebsocketManager.WatchGaps
+func (i *Info) WatchGaps(subscription types.Subscription, maxJump time.Duration, onGap func(GapEvent)) error {
+	if i.wsManager == nil {
+		return fmt.Errorf("WebSocket manager not available (skip_ws was used)")
+	}
+
+	return i.wsManager.WatchGaps(subscription, maxJump, onGap)
+}
+
 // Unsubscribe unsubscribes from WebSocket channels (if WebSocket is enabled)
 func (i *Info) Unsubscribe(subscriptions []types.Subscription) error {
 	if i.wsManager == nil {
@@ -528,3 +1914,176 @@ func (i *Info) Unsubscribe(subscriptions []types.Subscription) error {
 
 	return i.wsManager.Unsubscribe(subscriptions)
 }
+
+// SubscribeCandle subscribes to candle updates for a coin and interval with a typed callback
+// The name is resolved to its coin first, so spot pairs such as "PURR/USDC" work.
+func (i *Info) SubscribeCandle(name string, interval string, callback func(types.Candle)) error {
+	if err := utils.ValidateCandleInterval(interval); err != nil {
+		return err
+	}
+
+	coin, exists := i.coinForName(name)
+	if !exists {
+		return fmt.Errorf("coin not found: %s", name)
+	}
+
+	subscription := types.Subscription{Type: "candle", Coin: coin, Interval: interval}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var candle types.Candle
+		if err := decodeWsData(msg, &candle); err != nil {
+			log.Printf("Failed to decode candle message: %v", err)
+			return
+		}
+		callback(candle)
+	})
+}
+
+// SubscribeL2Book subscribes to L2 book updates for a coin with a typed callback
+func (i *Info) SubscribeL2Book(coin string, callback func(types.L2BookData)) error {
+	return i.SubscribeL2Books([]string{coin}, callback)
+}
+
+// SubscribeL2Books subscribes to L2 book updates for several coins routed to a single callback
+// Names are resolved to coins (e.g. "PURR/USDC" to its spot coin) before anything is sent, and
+// the subscribe messages are paced by the websocket manager. The Coin field of the delivered
+// data identifies which book was updated.
+func (i *Info) SubscribeL2Books(coins []string, callback func(types.L2BookData)) error {
+	subscriptions := make([]types.Subscription, 0, len(coins))
+	for _, name := range coins {
+		coin, exists := i.coinForName(name)
+		if !exists {
+			return fmt.Errorf("coin not found: %s", name)
+		}
+		subscriptions = append(subscriptions, types.Subscription{Type: "l2Book", Coin: coin})
+	}
+
+	return i.Subscribe(subscriptions, func(msg interface{}) {
+		var book types.L2BookData
+		if err := decodeWsData(msg, &book); err != nil {
+			log.Printf("Failed to decode l2Book message: %v", err)
+			return
+		}
+		callback(book)
+	})
+}
+
+// SubscribeUserFills subscribes to a user's fills with a typed callback
+// The first message after subscribing (and after every reconnect) has IsSnapshot set,
+// so consumers that dedupe fills should reset their state when they see it
+func (i *Info) SubscribeUserFills(user string, callback func(types.UserFillsData)) error {
+	subscription := types.Subscription{Type: "userFills", User: user}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var fills types.UserFillsData
+		if err := decodeWsData(msg, &fills); err != nil {
+			log.Printf("Failed to decode userFills message: %v", err)
+			return
+		}
+		callback(fills)
+	})
+}
+
+// SubscribeOrderUpdates subscribes to a user's order status changes with a typed callback
+func (i *Info) SubscribeOrderUpdates(user string, callback func([]types.OrderUpdate)) error {
+	subscription := types.Subscription{Type: "orderUpdates", User: user}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var updates []types.OrderUpdate
+		if err := decodeWsData(msg, &updates); err != nil {
+			log.Printf("Failed to decode orderUpdates message: %v", err)
+			return
+		}
+		callback(updates)
+	})
+}
+
+// SubscribeUserFundings subscribes to a user's funding payments with a typed callback
+func (i *Info) SubscribeUserFundings(user string, callback func(types.UserFundingsData)) error {
+	subscription := types.Subscription{Type: "userFundings", User: user}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var fundings types.UserFundingsData
+		if err := decodeWsData(msg, &fundings); err != nil {
+			log.Printf("Failed to decode userFundings message: %v", err)
+			return
+		}
+		callback(fundings)
+	})
+}
+
+// SubscribeUserLedgerUpdates subscribes to a user's non-funding ledger updates with a typed callback
+func (i *Info) SubscribeUserLedgerUpdates(user string, callback func(types.UserLedgerUpdatesData)) error {
+	subscription := types.Subscription{Type: "userNonFundingLedgerUpdates", User: user}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var updates types.UserLedgerUpdatesData
+		if err := decodeWsData(msg, &updates); err != nil {
+			log.Printf("Failed to decode userNonFundingLedgerUpdates message: %v", err)
+			return
+		}
+		callback(updates)
+	})
+}
+
+// UserCallbacks bundles optional typed handlers for a user's streams; nil handlers are not subscribed
+type UserCallbacks struct {
+	Fills         func(types.UserFillsData)
+	Fundings      func(types.UserFundingsData)
+	LedgerUpdates func(types.UserLedgerUpdatesData)
+	OrderUpdates  func([]types.OrderUpdate)
+}
+
+// SubscribeUserAll subscribes to each of a user's streams that has a handler in callbacks
+// If any subscription fails, the ones already made are unsubscribed.
+func (i *Info) SubscribeUserAll(user string, callbacks UserCallbacks) error {
+	var subscribed []types.Subscription
+
+	subscribe := func(subType string, subscribeFn func() error) error {
+		if err := subscribeFn(); err != nil {
+			if len(subscribed) > 0 {
+				i.Unsubscribe(subscribed)
+			}
+			return fmt.Errorf("failed to subscribe to %s: %w", subType, err)
+		}
+		subscribed = append(subscribed, types.Subscription{Type: subType, User: user})
+		return nil
+	}
+
+	if callbacks.Fills != nil {
+		if err := subscribe("userFills", func() error { return i.SubscribeUserFills(user, callbacks.Fills) }); err != nil {
+			return err
+		}
+	}
+	if callbacks.Fundings != nil {
+		if err := subscribe("userFundings", func() error { return i.SubscribeUserFundings(user, callbacks.Fundings) }); err != nil {
+			return err
+		}
+	}
+	if callbacks.LedgerUpdates != nil {
+		if err := subscribe("userNonFundingLedgerUpdates", func() error { return i.SubscribeUserLedgerUpdates(user, callbacks.LedgerUpdates) }); err != nil {
+			return err
+		}
+	}
+	if callbacks.OrderUpdates != nil {
+		if err := subscribe("orderUpdates", func() error { return i.SubscribeOrderUpdates(user, callbacks.OrderUpdates) }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubscribeUserEvents subscribes to a user's events with a typed callback
+func (i *Info) SubscribeUserEvents(user string, callback func(types.UserEventsData)) error {
+	subscription := types.Subscription{Type: "userEvents", User: user}
+
+	return i.Subscribe([]types.Subscription{subscription}, func(msg interface{}) {
+		var events types.UserEventsData
+		if err := decodeWsData(msg, &events); err != nil {
+			log.Printf("Failed to decode userEvents message: %v", err)
+			return
+		}
+		callback(events)
+	})
+}
@@ -0,0 +1,1586 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyperliquid-go-sdk/pkg/types"
+	"hyperliquid-go-sdk/pkg/utils"
+)
+
+func TestInfoEstimateFundingUsesAssetContext(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("metaAndAssetCtxs", metaAndAssetCtxsResponse(
+		map[string]interface{}{"funding": "0.0000125", "markPx": "2000"},
+		map[string]interface{}{"funding": "-0.00002", "markPx": "60000"},
+	))
+	info := newTestInfo(t, server)
+
+	got, err := info.EstimateFunding("BTC", 50000, 10, false)
+	if err != nil {
+		t.Fatalf("EstimateFunding: %v", err)
+	}
+	// Shorts pay when funding is negative
+	if !approxEqual(got, 10) {
+		t.Fatalf("EstimateFunding = %v, want 10", got)
+	}
+
+	got, err = info.EstimateFunding("ETH", 20000, 4, true)
+	if err != nil {
+		t.Fatalf("EstimateFunding: %v", err)
+	}
+	if !approxEqual(got, 1) {
+		t.Fatalf("EstimateFunding = %v, want 1", got)
+	}
+
+	if _, err := info.EstimateFunding("SOL", 1000, 1, true); err == nil {
+		t.Fatal("expected an error for an unknown coin")
+	}
+}
+
+func TestSubscribeCandleDecodesFrames(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	candles := make(chan types.Candle, 4)
+	if err := info.SubscribeCandle("HFUN/USDC", "1m", func(candle types.Candle) { candles <- candle }); err != nil {
+		t.Fatalf("SubscribeCandle: %v", err)
+	}
+
+	message := server.nextWebsocketMessage()
+	if got := jsonField(t, message, "subscription", "coin"); got != "@1" {
+		t.Fatalf("subscribed to coin %v, want @1", got)
+	}
+	if got := jsonField(t, message, "subscription", "interval"); got != "1m" {
+		t.Fatalf("subscribed to interval %v, want 1m", got)
+	}
+
+	// A candle for another interval of the same coin is not delivered
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "candle",
+		"data":    map[string]interface{}{"t": 1, "T": 2, "s": "@1", "i": "5m", "o": "1", "c": "1", "h": "1", "l": "1", "v": "0", "n": 0},
+	})
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "candle",
+		"data": map[string]interface{}{
+			"t": 1700000040000, "T": 1700000099999, "s": "@1", "i": "1m",
+			"o": "0.251", "c": "0.255", "h": "0.26", "l": "0.25", "v": "1234.5", "n": 17,
+		},
+	})
+
+	want := types.Candle{
+		OpenTime: 1700000040000, CloseTime: 1700000099999, Coin: "@1", Interval: "1m",
+		Open: "0.251", Close: "0.255", High: "0.26", Low: "0.25", Volume: "1234.5", NumTrades: 17,
+	}
+	select {
+	case candle := <-candles:
+		if candle != want {
+			t.Fatalf("candle = %+v, want %+v", candle, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the candle")
+	}
+	select {
+	case candle := <-candles:
+		t.Fatalf("unexpected extra candle: %+v", candle)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeCandleRejectsInvalidInput(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	if err := info.SubscribeCandle("ETH", "7m", func(types.Candle) {}); err == nil {
+		t.Error("expected an error for an invalid interval")
+	}
+	if err := info.SubscribeCandle("DOGE", "1m", func(types.Candle) {}); err == nil {
+		t.Error("expected an error for an unknown coin")
+	}
+}
+
+func TestSubscribeL2BooksRoutesCoinsToOneCallback(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	books := make(chan types.L2BookData, 4)
+	if err := info.SubscribeL2Books([]string{"ETH", "HFUN/USDC"}, func(book types.L2BookData) { books <- book }); err != nil {
+		t.Fatalf("SubscribeL2Books: %v", err)
+	}
+	for _, want := range []string{"ETH", "@1"} {
+		if got := jsonField(t, server.nextWebsocketMessage(), "subscription", "coin"); got != want {
+			t.Fatalf("subscribed to %v, want %s", got, want)
+		}
+	}
+
+	server.sendWebsocket(l2BookFrame("BTC", "59999", "60001", 1))
+	server.sendWebsocket(l2BookFrame("ETH", "1999", "2001", 2))
+	server.sendWebsocket(l2BookFrame("@1", "0.25", "0.26", 3))
+
+	received := make(map[string]types.L2BookData)
+	for len(received) < 2 {
+		select {
+		case book := <-books:
+			received[book.Coin] = book
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for books, got %v", received)
+		}
+	}
+	if _, ok := received["BTC"]; ok {
+		t.Fatal("received a book for a coin that was not subscribed")
+	}
+	if got := received["ETH"].Levels[0][0].Px; got != "1999" {
+		t.Errorf("ETH best bid = %s, want 1999", got)
+	}
+	if got := received["@1"].Levels[1][0].Px; got != "0.26" {
+		t.Errorf("@1 best ask = %s, want 0.26", got)
+	}
+	select {
+	case book := <-books:
+		t.Fatalf("unexpected extra book for %s", book.Coin)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeL2BooksSendsPacedFramePerCoin(t *testing.T) {
+	server := newMockServer(t)
+	const delay = 10 * time.Millisecond
+	info := newTestInfoWithWebsocket(t, server, func(w *WebsocketManager) { w.subscribeDelay = delay })
+
+	// An unknown name fails before anything is subscribed
+	if err := info.SubscribeL2Books([]string{"ETH", "NOPE"}, func(types.L2BookData) {}); err == nil {
+		t.Fatal("expected an error for an unknown coin")
+	}
+
+	coins := []string{"ETH", "BTC", "PURR/USDC", "HFUN/USDC"}
+	start := time.Now()
+	if err := info.SubscribeL2Books(coins, func(types.L2BookData) {}); err != nil {
+		t.Fatalf("SubscribeL2Books: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Duration(len(coins)-1)*delay {
+		t.Errorf("subscribed to %d coins in %v, want at least %v between frames", len(coins), elapsed, delay)
+	}
+
+	for _, want := range []string{"ETH", "BTC", "PURR/USDC", "@1"} {
+		message := server.nextWebsocketMessage()
+		if got := jsonField(t, message, "subscription", "type"); got != "l2Book" {
+			t.Fatalf("subscription type = %v, want l2Book", got)
+		}
+		if got := jsonField(t, message, "subscription", "coin"); got != want {
+			t.Fatalf("subscribed to %v, want %s", got, want)
+		}
+	}
+	select {
+	case message := <-server.wsReceived:
+		t.Fatalf("unexpected extra frame %v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeUserFillsPreservesSnapshotFlagAcrossReconnect(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, func(w *WebsocketManager) {
+		w.reconnectDelay = time.Millisecond
+	})
+
+	updates := make(chan types.UserFillsData, 4)
+	if err := info.SubscribeUserFills(testUser, func(data types.UserFillsData) { updates <- data }); err != nil {
+		t.Fatalf("SubscribeUserFills: %v", err)
+	}
+	server.nextWebsocketMessage()
+
+	next := func() types.UserFillsData {
+		t.Helper()
+		select {
+		case data := <-updates:
+			return data
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for fills")
+			return types.UserFillsData{}
+		}
+	}
+	fillsFrame := func(isSnapshot bool, tid int) map[string]interface{} {
+		return map[string]interface{}{
+			"channel": "userFills",
+			"data": map[string]interface{}{
+				"user":       testUser,
+				"isSnapshot": isSnapshot,
+				"fills":      []interface{}{fillFixture("ETH", "2000", "0.1", "B", tid, int64(tid))},
+			},
+		}
+	}
+
+	server.sendWebsocket(fillsFrame(true, 1))
+	if data := next(); !data.IsSnapshot || len(data.Fills) != 1 || data.Fills[0].Tid != 1 {
+		t.Fatalf("unexpected initial snapshot: %+v", data)
+	}
+	server.sendWebsocket(fillsFrame(false, 2))
+	if data := next(); data.IsSnapshot || data.Fills[0].Tid != 2 {
+		t.Fatalf("unexpected incremental update: %+v", data)
+	}
+
+	// After a reconnect the subscription is replayed and the server sends a fresh snapshot
+	server.dropWebsockets()
+	server.waitWebsocketConnection()
+	if got := jsonField(t, server.nextWebsocketMessage(), "subscription", "type"); got != "userFills" {
+		t.Fatalf("resubscribed to %v, want userFills", got)
+	}
+	server.sendWebsocket(fillsFrame(true, 3))
+	if data := next(); !data.IsSnapshot || data.Fills[0].Tid != 3 {
+		t.Fatalf("unexpected snapshot after reconnect: %+v", data)
+	}
+}
+
+func TestUserTradesHistoryTypedDecodesFills(t *testing.T) {
+	server := newMockServer(t)
+	liquidated := fillFixture("BTC", "60000", "0.01", "A", 8, 1700000000500)
+	liquidated["dir"] = "Close Long"
+	liquidated["closedPnl"] = "-12.5"
+	server.respondInfo("userTradesHistory", []interface{}{
+		fillFixture("ETH", "2000.5", "0.1", "B", 7, 1700000000000),
+		liquidated,
+	})
+	info := newTestInfo(t, server)
+
+	fills, err := info.UserTradesHistoryTyped(testUser, "")
+	if err != nil {
+		t.Fatalf("UserTradesHistoryTyped: %v", err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills, want 2", len(fills))
+	}
+	if fills[0].Coin != "ETH" || fills[0].Px != "2000.5" || fills[0].Side != "B" || fills[0].Tid != 7 || fills[0].Oid != 70 {
+		t.Errorf("unexpected first fill: %+v", fills[0])
+	}
+	if fills[1].Dir != "Close Long" || fills[1].ClosedPnl != "-12.5" || fills[1].Time != 1700000000500 {
+		t.Errorf("unexpected second fill: %+v", fills[1])
+	}
+
+	requests := server.infoRequests("userTradesHistory")
+	if len(requests) != 1 || requests[0]["user"] != testUser {
+		t.Fatalf("unexpected requests: %v", requests)
+	}
+	if _, ok := requests[0]["dex"]; ok {
+		t.Fatal("dex sent for the default dex")
+	}
+}
+
+func TestSzDecimalsPerpAndSpot(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfo(t, server)
+
+	for name, want := range map[string]int{"ETH": 4, "BTC": 5, "PURR/USDC": 0, "HFUN/USDC": 2, "@1": 2} {
+		got, err := info.SzDecimals(name)
+		if err != nil {
+			t.Errorf("SzDecimals(%q): %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("SzDecimals(%q) = %d, want %d", name, got, want)
+		}
+	}
+
+	if _, err := info.SzDecimals("DOGE"); err == nil {
+		t.Error("expected an error for an unknown coin")
+	}
+}
+
+func TestVaultFollowersDecode(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("vaultDetails", map[string]interface{}{
+		"name":         "Test Vault",
+		"vaultAddress": testVault,
+		"leader":       testUser,
+		"followers": []interface{}{
+			map[string]interface{}{
+				"user": "0x0000000000000000000000000000000000000001", "vaultEquity": "1520.25",
+				"pnl": "20.25", "allTimePnl": "45.5", "daysFollowing": 12, "lockupUntil": 1700086400000,
+			},
+			map[string]interface{}{
+				"user": "Leader", "vaultEquity": "50000.0", "pnl": "-10.0",
+				"allTimePnl": "1000.0", "daysFollowing": 300, "lockupUntil": 0,
+			},
+		},
+	})
+	info := newTestInfo(t, server)
+
+	followers, err := info.VaultFollowers(testVault)
+	if err != nil {
+		t.Fatalf("VaultFollowers: %v", err)
+	}
+
+	want := []types.VaultFollower{
+		{User: "0x0000000000000000000000000000000000000001", VaultEquity: "1520.25", Pnl: "20.25", AllTimePnl: "45.5", DaysFollowing: 12, LockupUntil: 1700086400000},
+		{User: "Leader", VaultEquity: "50000.0", Pnl: "-10.0", AllTimePnl: "1000.0", DaysFollowing: 300},
+	}
+	if !reflect.DeepEqual(followers, want) {
+		t.Fatalf("VaultFollowers = %+v, want %+v", followers, want)
+	}
+	if got := server.infoRequests("vaultDetails")[0]["vaultAddress"]; got != testVault {
+		t.Fatalf("requested vault %v, want %s", got, testVault)
+	}
+}
+
+func TestDelegatorHistoryDecode(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("delegatorHistory", []interface{}{
+		map[string]interface{}{
+			"time": 1700000300000, "hash": "0xabc1",
+			"delta": map[string]interface{}{"delegate": map[string]interface{}{
+				"validator": "0x5ac99df645f3414876c816caa18b2d234024b487", "amount": "100.0", "isUndelegate": true,
+			}},
+		},
+		map[string]interface{}{
+			"time": 1700000200000, "hash": "0xabc2",
+			"delta": map[string]interface{}{"cDeposit": map[string]interface{}{"amount": "250.5"}},
+		},
+		map[string]interface{}{
+			"time": 1700000100000, "hash": "0xabc3",
+			"delta": map[string]interface{}{"withdrawal": map[string]interface{}{"amount": "10.0", "phase": "initiated"}},
+		},
+	})
+	info := newTestInfo(t, server)
+
+	history, err := info.DelegatorHistory(testUser)
+	if err != nil {
+		t.Fatalf("DelegatorHistory: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("got %d entries, want 3", len(history))
+	}
+
+	delegate := history[0].Delta.Delegate
+	if history[0].Time != 1700000300000 || history[0].Hash != "0xabc1" || delegate == nil ||
+		delegate.Amount != "100.0" || !delegate.IsUndelegate || history[0].Delta.CDeposit != nil {
+		t.Errorf("unexpected undelegate entry: %+v", history[0])
+	}
+	if deposit := history[1].Delta.CDeposit; deposit == nil || deposit.Amount != "250.5" || history[1].Delta.Delegate != nil {
+		t.Errorf("unexpected deposit entry: %+v", history[1])
+	}
+	if withdrawal := history[2].Delta.Withdrawal; withdrawal == nil || withdrawal.Amount != "10.0" || withdrawal.Phase != "initiated" {
+		t.Errorf("unexpected withdrawal entry: %+v", history[2])
+	}
+	if got := server.infoRequests("delegatorHistory")[0]["user"]; got != testUser {
+		t.Fatalf("requested history for %v, want %s", got, testUser)
+	}
+}
+
+func TestExplorerDetailsUseExplorerEndpoint(t *testing.T) {
+	server := newMockServer(t)
+	server.handlePath("/explorer", func(payload map[string]interface{}) interface{} {
+		switch payload["type"] {
+		case "txDetails":
+			return map[string]interface{}{"type": "txDetails", "tx": map[string]interface{}{"hash": payload["hash"], "block": 1234}}
+		case "blockDetails":
+			return map[string]interface{}{"type": "blockDetails", "blockDetails": map[string]interface{}{"height": payload["height"], "numTxs": 2}}
+		}
+		return rawResponse{status: 400, body: "unknown type"}
+	})
+	info := newTestInfo(t, server)
+
+	if got := info.ExplorerURL(); got != server.URL+"/explorer" {
+		t.Fatalf("ExplorerURL() = %s, want %s/explorer", got, server.URL)
+	}
+
+	tx, err := info.TxDetails("0x1f0e5d3c9b7a2f4e6d8c0b1a3e5f7d9c2b4a6e8f0d1c3b5a7e9f2d4c6b8a0e1f")
+	if err != nil {
+		t.Fatalf("TxDetails: %v", err)
+	}
+	if got := jsonField(t, tx, "tx", "hash"); got != "0x1f0e5d3c9b7a2f4e6d8c0b1a3e5f7d9c2b4a6e8f0d1c3b5a7e9f2d4c6b8a0e1f" {
+		t.Errorf("tx hash = %v, want 0x1f0e5d3c9b7a2f4e6d8c0b1a3e5f7d9c2b4a6e8f0d1c3b5a7e9f2d4c6b8a0e1f", got)
+	}
+
+	block, err := info.BlockDetails(1234)
+	if err != nil {
+		t.Fatalf("BlockDetails: %v", err)
+	}
+	if got := jsonField(t, block, "blockDetails", "height"); got != 1234.0 {
+		t.Errorf("block height = %v, want 1234", got)
+	}
+
+	if requests := server.recorded("/explorer"); len(requests) != 2 {
+		t.Fatalf("got %d explorer requests, want 2", len(requests))
+	}
+	if requests := server.recorded("/info"); len(requests) != 0 {
+		t.Fatalf("explorer queries were sent to /info: %d requests", len(requests))
+	}
+}
+
+func TestExplorerDetailsTypedAndHashValidation(t *testing.T) {
+	const hash = "0x1f0e5d3c9b7a2f4e6d8c0b1a3e5f7d9c2b4a6e8f0d1c3b5a7e9f2d4c6b8a0e1f"
+	server := newMockServer(t)
+	server.handlePath("/explorer", func(payload map[string]interface{}) interface{} {
+		switch payload["type"] {
+		case "txDetails":
+			if payload["hash"] != hash {
+				return map[string]interface{}{"type": "txDetails", "tx": nil}
+			}
+			return map[string]interface{}{"type": "txDetails", "tx": map[string]interface{}{
+				"action": map[string]interface{}{"type": "usdSend", "amount": "1"},
+				"block":  1234, "error": nil, "hash": hash, "time": 1700000000000, "user": testUser,
+			}}
+		case "blockDetails":
+			return map[string]interface{}{"type": "blockDetails", "blockDetails": map[string]interface{}{
+				"height": payload["height"], "blockTime": 1700000000000, "numTxs": 1,
+				"txs": []interface{}{map[string]interface{}{"hash": hash, "error": "Insufficient balance"}},
+			}}
+		}
+		return rawResponse{status: 400, body: "unknown type"}
+	})
+	info := newTestInfo(t, server)
+
+	tx, err := info.TxDetailsTyped(hash)
+	if err != nil {
+		t.Fatalf("TxDetailsTyped: %v", err)
+	}
+	if tx.Hash != hash || tx.Block != 1234 || tx.User != testUser || tx.Action["type"] != "usdSend" || !tx.Succeeded() {
+		t.Fatalf("unexpected tx: %+v", tx)
+	}
+
+	block, err := info.BlockDetailsTyped(1234)
+	if err != nil {
+		t.Fatalf("BlockDetailsTyped: %v", err)
+	}
+	if block.Height != 1234 || block.NumTxs != 1 || len(block.Txs) != 1 || block.Txs[0].Succeeded() {
+		t.Fatalf("unexpected block: %+v", block)
+	}
+
+	missing := "0x" + strings.Repeat("0", 64)
+	if _, err := info.TxDetailsTyped(missing); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	// Malformed hashes are rejected before anything is sent
+	sent := len(server.recorded("/explorer"))
+	for _, bad := range []string{"", hash[2:], hash[:65], hash + "0", "0x" + strings.Repeat("g", 64)} {
+		var validationErr *utils.ValidationError
+		if _, err := info.TxDetails(bad); !errors.As(err, &validationErr) {
+			t.Errorf("TxDetails(%q) = %v, want a ValidationError", bad, err)
+		}
+		if _, err := info.TxDetailsTyped(bad); !errors.As(err, &validationErr) {
+			t.Errorf("TxDetailsTyped(%q) = %v, want a ValidationError", bad, err)
+		}
+	}
+	if got := len(server.recorded("/explorer")); got != sent {
+		t.Fatalf("%d requests were sent for malformed hashes", got-sent)
+	}
+}
+
+func TestUsingBigBlocksDecodesRPCResult(t *testing.T) {
+	server := newMockServer(t)
+	flags := map[string]interface{}{testUser: true, testVault: false}
+	server.handlePath("/evm", func(payload map[string]interface{}) interface{} {
+		if payload["method"] != "eth_usingBigBlocks" || payload["jsonrpc"] != "2.0" {
+			t.Errorf("unexpected RPC request %v", payload)
+		}
+		params, _ := payload["params"].([]interface{})
+		if len(params) != 1 {
+			t.Errorf("params = %v, want the user", payload["params"])
+		} else if flag, ok := flags[params[0].(string)]; ok {
+			return map[string]interface{}{"jsonrpc": "2.0", "id": payload["id"], "result": flag}
+		}
+		return map[string]interface{}{"jsonrpc": "2.0", "id": payload["id"], "error": map[string]interface{}{"code": -32602, "message": "invalid address"}}
+	})
+	info := newTestInfo(t, server)
+
+	for user, want := range flags {
+		got, err := info.UsingBigBlocks(user)
+		if err != nil {
+			t.Fatalf("UsingBigBlocks(%s): %v", user, err)
+		}
+		if got != want {
+			t.Errorf("UsingBigBlocks(%s) = %v, want %v", user, got, want)
+		}
+	}
+
+	_, err := info.UsingBigBlocks("0x0")
+	if err == nil || !strings.Contains(err.Error(), "invalid address") {
+		t.Fatalf("expected the RPC error, got %v", err)
+	}
+
+	if got := info.EVMRPCURL(); got != server.URL+"/evm" {
+		t.Errorf("EVMRPCURL() = %s, want %s/evm", got, server.URL)
+	}
+	if requests := server.recorded("/info"); len(requests) != 0 {
+		t.Fatalf("big block queries were sent to /info: %d requests", len(requests))
+	}
+}
+
+func TestExplorerURLForKnownNetworks(t *testing.T) {
+	for baseURL, want := range map[string]string{
+		utils.MainnetAPIURL: utils.MainnetExplorerURL,
+		utils.TestnetAPIURL: utils.TestnetExplorerURL,
+	} {
+		if got := NewAPI(baseURL, nil).ExplorerURL(); got != want {
+			t.Errorf("ExplorerURL() for %s = %s, want %s", baseURL, got, want)
+		}
+	}
+}
+
+func TestNameToAssetDuringRefreshMeta(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("meta", testMeta())
+	server.respondInfo("spotMeta", testSpotMeta())
+	info := newTestInfo(t, server)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if asset, err := info.NameToAsset("BTC"); err != nil || asset != 1 {
+					t.Errorf("NameToAsset(BTC) = %d, %v during refresh", asset, err)
+					return
+				}
+				if asset, err := info.NameToAsset("HFUN/USDC"); err != nil || asset != 10001 {
+					t.Errorf("NameToAsset(HFUN/USDC) = %d, %v during refresh", asset, err)
+					return
+				}
+				if szDecimals, err := info.SzDecimals("ETH"); err != nil || szDecimals != 4 {
+					t.Errorf("SzDecimals(ETH) = %d, %v during refresh", szDecimals, err)
+					return
+				}
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	for refresh := 0; refresh < 10; refresh++ {
+		if err := info.RefreshMeta(); err != nil {
+			t.Errorf("RefreshMeta: %v", err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if got := len(server.infoRequests("meta")); got != 10 {
+		t.Fatalf("got %d meta requests, want 10", got)
+	}
+}
+
+func TestAccountSnapshotPopulatesAllParts(t *testing.T) {
+	server := newMockServer(t)
+	respondAccount(server)
+	info := newTestInfo(t, server)
+
+	snapshot, err := info.AccountSnapshot(testUser)
+	if err != nil {
+		t.Fatalf("AccountSnapshot: %v", err)
+	}
+
+	if snapshot.User != testUser {
+		t.Errorf("User = %s, want %s", snapshot.User, testUser)
+	}
+	if snapshot.State == nil || snapshot.State.MarginSummary.AccountValue != "1000.5" || len(snapshot.State.AssetPositions) != 1 {
+		t.Errorf("unexpected clearinghouse state: %+v", snapshot.State)
+	}
+	if snapshot.SpotState == nil || len(snapshot.SpotState.Balances) != 2 || snapshot.SpotState.Balances[1].Total != "1200.0" {
+		t.Errorf("unexpected spot state: %+v", snapshot.SpotState)
+	}
+	if len(snapshot.OpenOrders) != 1 || snapshot.OpenOrders[0].Oid != 101 {
+		t.Errorf("unexpected open orders: %+v", snapshot.OpenOrders)
+	}
+	if len(snapshot.Fills) != 1 || snapshot.Fills[0].Px != "2000.0" {
+		t.Errorf("unexpected fills: %+v", snapshot.Fills)
+	}
+
+	for _, infoType := range []string{"clearinghouseState", "spotClearinghouseState", "openOrders", "userFills"} {
+		requests := server.infoRequests(infoType)
+		if len(requests) != 1 || requests[0]["user"] != testUser {
+			t.Errorf("%s requests = %v, want one for %s", infoType, requests, testUser)
+		}
+	}
+}
+
+func TestAccountSnapshotPartialFailure(t *testing.T) {
+	server := newMockServer(t)
+	respondAccount(server)
+	server.respondInfo("openOrders", rawResponse{status: 500, body: "internal error"})
+	info := newTestInfo(t, server)
+
+	snapshot, err := info.AccountSnapshot(testUser)
+	if err == nil {
+		t.Fatalf("expected an error, got snapshot %+v", snapshot)
+	}
+	if snapshot != nil {
+		t.Errorf("partial snapshot returned alongside the error: %+v", snapshot)
+	}
+	for _, part := range []string{"account snapshot for " + testUser, "failed to get open orders"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not contain %q", err, part)
+		}
+	}
+	var serverErr *utils.ServerError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != 500 {
+		t.Errorf("error does not wrap the 500 response: %v", err)
+	}
+}
+
+func TestAccountSnapshotContextCanceled(t *testing.T) {
+	server := newMockServer(t)
+	respondAccount(server)
+	info := newTestInfo(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := info.AccountSnapshotContext(ctx, testUser); !errors.Is(err, context.Canceled) {
+		t.Fatalf("AccountSnapshotContext with a canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestUserSnapshotFetchesThreePartsConcurrently(t *testing.T) {
+	server := newMockServer(t)
+
+	// Each handler waits until all three requests have arrived, which only happens if they are in flight together
+	var arrivals sync.WaitGroup
+	arrivals.Add(3)
+	allArrived := make(chan struct{})
+	go func() {
+		arrivals.Wait()
+		close(allArrived)
+	}()
+	for _, infoType := range []string{"clearinghouseState", "openOrders", "userFills"} {
+		response := map[string]interface{}{
+			"clearinghouseState": clearinghouseStateFixture("1000.5", "100"),
+			"openOrders":         []interface{}{openOrderFixture("ETH", "B", "1900.0", "0.1", 101)},
+			"userFills":          []interface{}{fillFixture("ETH", "2000.0", "0.5", "B", 1, 1700000000000)},
+		}[infoType]
+		infoType := infoType
+		server.handleInfo(infoType, func(map[string]interface{}) interface{} {
+			arrivals.Done()
+			select {
+			case <-allArrived:
+			case <-time.After(2 * time.Second):
+				t.Errorf("%s was not requested concurrently with the other parts", infoType)
+			}
+			return response
+		})
+	}
+	info := newTestInfo(t, server)
+
+	snapshot, err := info.UserSnapshot(testUser)
+	if err != nil {
+		t.Fatalf("UserSnapshot: %v", err)
+	}
+	if snapshot.User != testUser || snapshot.State == nil || snapshot.State.MarginSummary.AccountValue != "1000.5" {
+		t.Errorf("unexpected snapshot state: %+v", snapshot)
+	}
+	if len(snapshot.OpenOrders) != 1 || snapshot.OpenOrders[0].Oid != 101 {
+		t.Errorf("unexpected open orders: %+v", snapshot.OpenOrders)
+	}
+	if len(snapshot.Fills) != 1 || snapshot.Fills[0].Tid != 1 {
+		t.Errorf("unexpected fills: %+v", snapshot.Fills)
+	}
+
+	for _, infoType := range []string{"clearinghouseState", "openOrders", "userFills"} {
+		if requests := server.infoRequests(infoType); len(requests) != 1 || requests[0]["user"] != testUser {
+			t.Errorf("%s requests = %v, want one for %s", infoType, requests, testUser)
+		}
+	}
+	if requests := server.infoRequests("spotClearinghouseState"); len(requests) != 0 {
+		t.Errorf("UserSnapshot fetched the spot state: %v", requests)
+	}
+
+	respondAccount(server)
+	server.respondInfo("userFills", rawResponse{status: 500, body: "internal error"})
+	if _, err := info.UserSnapshot(testUser); err == nil || !strings.Contains(err.Error(), "user snapshot for "+testUser) {
+		t.Fatalf("expected a user snapshot error, got %v", err)
+	}
+}
+
+func TestUserStateTypedPopulatesBothMarginSummaries(t *testing.T) {
+	server := newMockServer(t)
+	state := clearinghouseStateFixture("1000.5", "100")
+	state["crossMarginSummary"] = map[string]interface{}{
+		"accountValue": "900.25", "totalNtlPos": "500", "totalRawUsd": "400.25", "totalMarginUsed": "50",
+	}
+	server.respondInfo("clearinghouseState", state)
+	info := newTestInfo(t, server)
+
+	typed, err := info.UserStateTyped(testUser, "")
+	if err != nil {
+		t.Fatalf("UserStateTyped: %v", err)
+	}
+	if accountValue, err := typed.MarginSummary.AccountValueFloat(); err != nil || accountValue != 1000.5 {
+		t.Errorf("marginSummary account value = %v, %v; want 1000.5", accountValue, err)
+	}
+	if accountValue, err := typed.CrossMarginSummary.AccountValueFloat(); err != nil || accountValue != 900.25 {
+		t.Errorf("crossMarginSummary account value = %v, %v; want 900.25", accountValue, err)
+	}
+	if marginUsed, err := typed.CrossMarginSummary.TotalMarginUsedFloat(); err != nil || marginUsed != 50 {
+		t.Errorf("crossMarginSummary margin used = %v, %v; want 50", marginUsed, err)
+	}
+}
+
+func TestExtraAgentsDecode(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("extraAgents", []interface{}{
+		map[string]interface{}{"name": "market maker", "address": "0x9f2a0b1c2d3e4f5061728394a5b6c7d8e9f0a1b2", "validUntil": 1767225600000},
+		map[string]interface{}{"name": "", "address": "0x0123456789abcdef0123456789abcdef01234567", "validUntil": 1735689600000},
+	})
+	info := newTestInfo(t, server)
+
+	agents, err := info.ExtraAgents(testUser)
+	if err != nil {
+		t.Fatalf("ExtraAgents: %v", err)
+	}
+
+	want := []types.ExtraAgent{
+		{Name: "market maker", Address: "0x9f2a0b1c2d3e4f5061728394a5b6c7d8e9f0a1b2", ValidUntil: 1767225600000},
+		{Name: "", Address: "0x0123456789abcdef0123456789abcdef01234567", ValidUntil: 1735689600000},
+	}
+	if !reflect.DeepEqual(agents, want) {
+		t.Fatalf("ExtraAgents = %+v, want %+v", agents, want)
+	}
+	if got := server.infoRequests("extraAgents")[0]["user"]; got != testUser {
+		t.Fatalf("requested agents for %v, want %s", got, testUser)
+	}
+}
+
+func TestEstimateFeeMakerAndTaker(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("userFees", map[string]interface{}{
+		"userCrossRate":          "0.00045",
+		"userAddRate":            "0.00015",
+		"userSpotCrossRate":      "0.0007",
+		"userSpotAddRate":        "0.0004",
+		"activeReferralDiscount": "0.04",
+		"feeSchedule":            map[string]interface{}{"cross": "0.00045", "add": "0.00015"},
+	})
+	info := newTestInfo(t, server)
+
+	tests := []struct {
+		coin    string
+		isMaker bool
+		want    float64
+	}{
+		{coin: "ETH", isMaker: false, want: 10000 * 0.00045 * 0.96},
+		{coin: "ETH", isMaker: true, want: 10000 * 0.00015 * 0.96},
+		{coin: "PURR/USDC", isMaker: false, want: 10000 * 0.0007 * 0.96},
+		{coin: "PURR/USDC", isMaker: true, want: 10000 * 0.0004 * 0.96},
+	}
+	for _, tt := range tests {
+		got, err := info.EstimateFee(testUser, tt.coin, 10000, tt.isMaker)
+		if err != nil {
+			t.Fatalf("EstimateFee(%s, maker=%v): %v", tt.coin, tt.isMaker, err)
+		}
+		if !approxEqual(got, tt.want) {
+			t.Errorf("EstimateFee(%s, maker=%v) = %v, want %v", tt.coin, tt.isMaker, got, tt.want)
+		}
+	}
+
+	if _, err := info.EstimateFee(testUser, "DOGE", 10000, true); err == nil {
+		t.Error("expected an error for an unknown coin")
+	}
+}
+
+func TestAllMidsIsScopedByDex(t *testing.T) {
+	server := newMockServer(t)
+	server.handleInfo("allMids", func(payload map[string]interface{}) interface{} {
+		if payload["dex"] == "testdex" {
+			return map[string]interface{}{"testdex:FOO": "12.5"}
+		}
+		return map[string]interface{}{"ETH": "2000.5", "BTC": "60000"}
+	})
+	info := newTestInfo(t, server)
+
+	main, err := info.AllMids("")
+	if err != nil {
+		t.Fatalf("AllMids main: %v", err)
+	}
+	builder, err := info.AllMids("testdex")
+	if err != nil {
+		t.Fatalf("AllMids testdex: %v", err)
+	}
+
+	if want := map[string]string{"ETH": "2000.5", "BTC": "60000"}; !reflect.DeepEqual(main, want) {
+		t.Fatalf("main dex mids = %v, want %v", main, want)
+	}
+	if want := map[string]string{"testdex:FOO": "12.5"}; !reflect.DeepEqual(builder, want) {
+		t.Fatalf("testdex mids = %v, want %v", builder, want)
+	}
+
+	requests := server.infoRequests("allMids")
+	if len(requests) != 2 {
+		t.Fatalf("got %d allMids requests, want 2", len(requests))
+	}
+	if _, ok := requests[0]["dex"]; ok {
+		t.Fatalf("main dex request sent dex %v", requests[0]["dex"])
+	}
+}
+
+func TestSpotTransferTokenCanonicalAndNonCanonical(t *testing.T) {
+	info := newTestInfo(t, newMockServer(t))
+
+	tests := map[string]string{
+		"PURR": "PURR",
+		"HFUN": "HFUN:0xbaf265ef389da684513d98d68edf4eae",
+		"HFUN:0xbaf265ef389da684513d98d68edf4eae": "HFUN:0xbaf265ef389da684513d98d68edf4eae",
+	}
+	for coin, want := range tests {
+		got, err := info.SpotTransferToken(coin)
+		if err != nil {
+			t.Errorf("SpotTransferToken(%q): %v", coin, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("SpotTransferToken(%q) = %q, want %q", coin, got, want)
+		}
+	}
+
+	if _, err := info.SpotTransferToken("NOPE"); err == nil {
+		t.Fatal("expected an error for an unknown token")
+	}
+}
+
+func TestSubscribeL2BookSurvivesReconnect(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, func(w *WebsocketManager) {
+		w.reconnectDelay = time.Millisecond
+	})
+
+	books := make(chan types.L2BookData, 4)
+	if err := info.SubscribeL2Book("ETH", func(book types.L2BookData) { books <- book }); err != nil {
+		t.Fatalf("SubscribeL2Book: %v", err)
+	}
+	server.nextWebsocketMessage()
+
+	next := func() types.L2BookData {
+		t.Helper()
+		select {
+		case book := <-books:
+			return book
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a book")
+			return types.L2BookData{}
+		}
+	}
+
+	server.sendWebsocket(l2BookFrame("ETH", "1999", "2001", 1))
+	if book := next(); book.Time != 1 {
+		t.Fatalf("unexpected book before reconnect: %+v", book)
+	}
+
+	server.dropWebsockets()
+	server.waitWebsocketConnection()
+	message := server.nextWebsocketMessage()
+	if got := jsonField(t, message, "subscription", "type"); got != "l2Book" {
+		t.Fatalf("resubscribed to %v, want l2Book", got)
+	}
+	if got := jsonField(t, message, "subscription", "coin"); got != "ETH" {
+		t.Fatalf("resubscribed to coin %v, want ETH", got)
+	}
+
+	server.sendWebsocket(l2BookFrame("ETH", "2049", "2051", 2))
+	book := next()
+	if book.Coin != "ETH" || book.Time != 2 {
+		t.Fatalf("unexpected book after reconnect: %+v", book)
+	}
+	if got := book.Levels[0][0].Px; got != "2049" {
+		t.Fatalf("best bid after reconnect = %s, want 2049", got)
+	}
+}
+
+func TestUserTwapOrdersDecodesRunningTwaps(t *testing.T) {
+	server := newMockServer(t)
+	twap := func(twapID int, status string) map[string]interface{} {
+		return map[string]interface{}{
+			"time":   1700000000,
+			"twapId": twapID,
+			"state": map[string]interface{}{
+				"coin": "ETH", "user": testUser, "side": "B", "sz": "10.0", "executedSz": "2.5",
+				"executedNtl": "5000.0", "minutes": 30, "reduceOnly": false, "randomize": true,
+				"timestamp": 1700000000000,
+			},
+			"status": map[string]interface{}{"status": status},
+		}
+	}
+	server.respondInfo("twapHistory", []interface{}{twap(7, "activated"), twap(6, "finished"), twap(5, "terminated")})
+	info := newTestInfo(t, server)
+
+	twaps, err := info.UserTwapOrders(testUser)
+	if err != nil {
+		t.Fatalf("UserTwapOrders: %v", err)
+	}
+	want := []types.TwapState{{
+		TwapID: 7, Coin: "ETH", Side: "B", TotalSz: "10.0", ExecutedSz: "2.5", ExecutedNtl: "5000.0",
+		Minutes: 30, Randomize: true, Timestamp: 1700000000000, Status: "activated",
+	}}
+	if !reflect.DeepEqual(twaps, want) {
+		t.Fatalf("UserTwapOrders = %+v, want %+v", twaps, want)
+	}
+	if got := server.infoRequests("twapHistory")[0]["user"]; got != testUser {
+		t.Fatalf("requested user %v, want %s", got, testUser)
+	}
+}
+
+func TestFundingSummarySumsPerCoin(t *testing.T) {
+	server := newMockServer(t)
+	funding := func(time int64, coin, usdc string) map[string]interface{} {
+		return map[string]interface{}{
+			"time": time,
+			"hash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"delta": map[string]interface{}{
+				"type": "funding", "coin": coin, "usdc": usdc, "szi": "1.0", "fundingRate": "0.0000125", "nSamples": nil,
+			},
+		}
+	}
+	server.respondInfo("userFunding", []interface{}{
+		funding(1, "ETH", "-0.25"),
+		funding(2, "BTC", "1.5"),
+		funding(3, "ETH", "-0.75"),
+		funding(4, "BTC", "0.5"),
+		funding(5, "ETH", "0.1"),
+	})
+	info := newTestInfo(t, server)
+
+	endTime := int64(5000)
+	summary, err := info.FundingSummary(testUser, 1000, &endTime)
+	if err != nil {
+		t.Fatalf("FundingSummary: %v", err)
+	}
+	if len(summary) != 2 || !approxEqual(summary["ETH"], -0.9) || !approxEqual(summary["BTC"], 2) {
+		t.Fatalf("FundingSummary = %v, want ETH -0.9 and BTC 2", summary)
+	}
+
+	request := server.infoRequests("userFunding")[0]
+	if request["user"] != testUser || request["startTime"] != 1000.0 || request["endTime"] != 5000.0 {
+		t.Fatalf("unexpected userFunding request: %v", request)
+	}
+
+	server.respondInfo("userFunding", []interface{}{funding(6, "ETH", "n/a")})
+	if _, err := info.FundingSummary(testUser, 1000, nil); err == nil {
+		t.Fatal("expected an error for an invalid usdc delta")
+	}
+}
+
+func TestUserFillsByTimePageReportsHasMore(t *testing.T) {
+	server := newMockServer(t)
+	page := func(n int) []interface{} {
+		fills := make([]interface{}, n)
+		for i := range fills {
+			fills[i] = fillFixture("ETH", "2000", "0.1", "B", i+1, int64(1000+i))
+		}
+		return fills
+	}
+	info := newTestInfo(t, server)
+
+	server.respondInfo("userFillsByTime", page(UserFillsByTimePageSize))
+	fills, hasMore, err := info.UserFillsByTimePage(testUser, 1000, nil, "", 0)
+	if err != nil {
+		t.Fatalf("UserFillsByTimePage: %v", err)
+	}
+	if len(fills) != UserFillsByTimePageSize || !hasMore {
+		t.Fatalf("full page: got %d fills, hasMore %v; want %d, true", len(fills), hasMore, UserFillsByTimePageSize)
+	}
+
+	server.respondInfo("userFillsByTime", page(3))
+	fills, hasMore, err = info.UserFillsByTimePage(testUser, 1000, nil, "", 0)
+	if err != nil {
+		t.Fatalf("UserFillsByTimePage: %v", err)
+	}
+	if len(fills) != 3 || hasMore {
+		t.Fatalf("partial page: got %d fills, hasMore %v; want 3, false", len(fills), hasMore)
+	}
+
+	// A custom page size is honored
+	if _, hasMore, err := info.UserFillsByTimePage(testUser, 1000, nil, "", 3); err != nil || !hasMore {
+		t.Fatalf("page size 3: hasMore %v, err %v; want true", hasMore, err)
+	}
+
+	// A page size above the server cap is clamped to it, so a full page still reports more
+	server.respondInfo("userFillsByTime", page(UserFillsByTimePageSize))
+	if _, hasMore, err := info.UserFillsByTimePage(testUser, 1000, nil, "", 5000); err != nil || !hasMore {
+		t.Fatalf("page size 5000: hasMore %v, err %v; want true", hasMore, err)
+	}
+	for _, request := range server.infoRequests("userFillsByTime") {
+		if _, ok := request["pageSize"]; ok {
+			t.Fatalf("request carries a pageSize: %v", request)
+		}
+	}
+}
+
+func TestCanSetLeverageSafeAndUnsafe(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("meta", map[string]interface{}{
+		"universe": []interface{}{
+			map[string]interface{}{"name": "ETH", "szDecimals": 4, "maxLeverage": 25, "marginTableId": 51},
+			map[string]interface{}{"name": "BTC", "szDecimals": 5, "maxLeverage": 40, "marginTableId": 40},
+		},
+		"marginTables": []interface{}{
+			[]interface{}{51, map[string]interface{}{
+				"description": "tiered 25x",
+				"marginTiers": []interface{}{
+					map[string]interface{}{"lowerBound": "0.0", "maxLeverage": 25},
+					map[string]interface{}{"lowerBound": "1000.0", "maxLeverage": 10},
+				},
+			}},
+		},
+	})
+	// A 2000 notional ETH position at 10x cross uses 200 of the 1000 account value
+	server.respondInfo("clearinghouseState", clearinghouseStateFixture("1000", "200",
+		positionFixture("ETH", "1.0", map[string]interface{}{"type": "cross", "value": 10}, "200"),
+	))
+	info := newTestInfo(t, server)
+
+	if ok, reason, err := info.CanSetLeverage(testUser, "ETH", 5, true); err != nil || !ok {
+		t.Fatalf("CanSetLeverage(5x) = %v, %q, %v; want safe", ok, reason, err)
+	}
+
+	tests := []struct {
+		leverage int
+		reason   string
+	}{
+		// 2000 of margin at 1x exceeds the account value
+		{leverage: 1, reason: "cross margin required 2000.00 exceeds account value 1000.00"},
+		// The 1000+ notional tier caps leverage at 10x, below the asset's 25x
+		{leverage: 20, reason: "exceeds max leverage 10x"},
+		{leverage: 30, reason: "exceeds max leverage 25x for ETH"},
+	}
+	for _, tt := range tests {
+		ok, reason, err := info.CanSetLeverage(testUser, "ETH", tt.leverage, true)
+		if err != nil {
+			t.Errorf("CanSetLeverage(%dx): %v", tt.leverage, err)
+			continue
+		}
+		if ok || !strings.Contains(reason, tt.reason) {
+			t.Errorf("CanSetLeverage(%dx) = %v, %q; want unsafe with %q", tt.leverage, ok, reason, tt.reason)
+		}
+	}
+
+	// Without a position any leverage within the asset max is safe
+	if ok, reason, err := info.CanSetLeverage(testUser, "BTC", 20, false); err != nil || !ok {
+		t.Fatalf("CanSetLeverage(BTC) = %v, %q, %v; want safe", ok, reason, err)
+	}
+}
+
+func TestValidateOrderFlagsSubMinimumNotional(t *testing.T) {
+	info := newTestInfo(t, newMockServer(t))
+	order := func(coin string, sz, px float64, reduceOnly bool) types.OrderRequest {
+		return types.OrderRequest{
+			Coin: coin, IsBuy: true, Sz: sz, LimitPx: px, ReduceOnly: reduceOnly,
+			OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: types.TifGtc}},
+		}
+	}
+
+	// 0.004 ETH at 2000 is 8 USD, below the 10 USD default
+	err := info.ValidateOrder(order("ETH", 0.004, 2000, false))
+	var validationErr *utils.ValidationError
+	if !errors.As(err, &validationErr) || !strings.Contains(validationErr.Message, "below the minimum of 10.00") {
+		t.Fatalf("expected a minimum notional ValidationError, got %v", err)
+	}
+
+	if err := info.ValidateOrder(order("ETH", 0.005, 2000, false)); err != nil {
+		t.Fatalf("ValidateOrder at the minimum: %v", err)
+	}
+	if err := info.ValidateOrder(order("ETH", 0.004, 2000, true)); err != nil {
+		t.Fatalf("reduce-only orders are exempt, got %v", err)
+	}
+
+	// Overrides apply by name or resolved coin
+	info.SetMinNotional("@1", 1)
+	if minNotional, err := info.MinNotional("HFUN/USDC"); err != nil || minNotional != 1 {
+		t.Fatalf("MinNotional(HFUN/USDC) = %v, %v; want 1", minNotional, err)
+	}
+	if err := info.ValidateOrder(order("HFUN/USDC", 10, 0.25, false)); err != nil {
+		t.Fatalf("ValidateOrder with an override: %v", err)
+	}
+	if _, err := info.MinNotional("SOL"); err == nil {
+		t.Fatal("expected an error for an unknown coin")
+	}
+}
+
+func TestWirePriceAndSizePerpAndSpot(t *testing.T) {
+	info := newTestInfo(t, newMockServer(t))
+
+	prices := []struct {
+		coin string
+		px   float64
+		want string
+	}{
+		// ETH has 4 size decimals, so perp prices keep at most 2 decimals and 5 significant figures
+		{coin: "ETH", px: 2000.123456, want: "2000.1"},
+		{coin: "ETH", px: 12.34567, want: "12.35"},
+		{coin: "ETH", px: 123456, want: "123456"},
+		{coin: "BTC", px: 60000.55, want: "60001"},
+		// Spot prices keep at most 8 - szDecimals decimals
+		{coin: "HFUN/USDC", px: 0.123456789, want: "0.12346"},
+		{coin: "PURR/USDC", px: 0.0000123456, want: "0.00001235"},
+	}
+	for _, tt := range prices {
+		got, err := info.WirePrice(tt.coin, tt.px)
+		if err != nil {
+			t.Errorf("WirePrice(%s, %v): %v", tt.coin, tt.px, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("WirePrice(%s, %v) = %q, want %q", tt.coin, tt.px, got, tt.want)
+		}
+	}
+
+	sizes := []struct {
+		coin string
+		sz   float64
+		want string
+	}{
+		{coin: "ETH", sz: 0.123456, want: "0.1235"},
+		{coin: "BTC", sz: 0.000011, want: "0.00001"},
+		{coin: "PURR/USDC", sz: 12.7, want: "13"},
+		{coin: "HFUN/USDC", sz: 1.006, want: "1.01"},
+	}
+	for _, tt := range sizes {
+		got, err := info.WireSize(tt.coin, tt.sz)
+		if err != nil {
+			t.Errorf("WireSize(%s, %v): %v", tt.coin, tt.sz, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("WireSize(%s, %v) = %q, want %q", tt.coin, tt.sz, got, tt.want)
+		}
+	}
+
+	if _, err := info.WirePrice("SOL", 100); err == nil {
+		t.Error("expected a WirePrice error for an unknown coin")
+	}
+	if _, err := info.WireSize("SOL", 1); err == nil {
+		t.Error("expected a WireSize error for an unknown coin")
+	}
+}
+
+func TestIsAgentApprovedMatchesCaseInsensitively(t *testing.T) {
+	utils.SetClock(fixedClock(time.UnixMilli(1750000000000)))
+	t.Cleanup(func() { utils.SetClock(nil) })
+
+	server := newMockServer(t)
+	server.respondInfo("extraAgents", []interface{}{
+		map[string]interface{}{"name": "market maker", "address": "0x9f2a0b1c2d3e4f5061728394a5b6c7d8e9f0a1b2", "validUntil": 1767225600000},
+		map[string]interface{}{"name": "old", "address": "0x0123456789abcdef0123456789abcdef01234567", "validUntil": 1735689600000},
+	})
+	info := newTestInfo(t, server)
+
+	tests := []struct {
+		agent      string
+		approved   bool
+		validUntil int64
+	}{
+		{agent: "0x9F2A0B1C2D3E4F5061728394A5B6C7D8E9F0A1B2", approved: true, validUntil: 1767225600000},
+		{agent: "0x9f2a0b1c2d3e4f5061728394a5b6c7d8e9f0a1b2", approved: true, validUntil: 1767225600000},
+		// Expired agents are found but not approved
+		{agent: "0x0123456789ABCDEF0123456789ABCDEF01234567", approved: false, validUntil: 1735689600000},
+		{agent: "0x0000000000000000000000000000000000000001", approved: false, validUntil: 0},
+	}
+	for _, tt := range tests {
+		approved, validUntil, err := info.IsAgentApproved(testUser, tt.agent)
+		if err != nil {
+			t.Fatalf("IsAgentApproved(%s): %v", tt.agent, err)
+		}
+		if approved != tt.approved || validUntil != tt.validUntil {
+			t.Errorf("IsAgentApproved(%s) = %v, %d; want %v, %d", tt.agent, approved, validUntil, tt.approved, tt.validUntil)
+		}
+	}
+}
+
+func TestOrderStatusTypedDecodesOrderAndUnknownOid(t *testing.T) {
+	server := newMockServer(t)
+	server.handleInfo("orderStatus", func(payload map[string]interface{}) interface{} {
+		if payload["oid"] != 77.0 {
+			return map[string]interface{}{"status": "unknownOid"}
+		}
+		order := openOrderFixture("ETH", "B", "2000.0", "0.0", 77)
+		order["origSz"] = "0.1"
+		order["orderType"] = "Limit"
+		order["tif"] = "Gtc"
+		order["cloid"] = "0x00000000000000000000000000000007"
+		return map[string]interface{}{
+			"status": "order",
+			"order":  map[string]interface{}{"order": order, "status": "filled", "statusTimestamp": 1700000001000},
+		}
+	})
+	info := newTestInfo(t, server)
+
+	result, err := info.OrderStatusTyped(testUser, 77, "")
+	if err != nil {
+		t.Fatalf("OrderStatusTyped: %v", err)
+	}
+	if !result.Found() || result.Order.Status != "filled" || result.Order.StatusTimestamp != 1700000001000 {
+		t.Fatalf("unexpected order status: %+v", result)
+	}
+	order := result.Order.Order
+	if order.Oid != 77 || order.Coin != "ETH" || order.OrigSz != "0.1" || order.Tif == nil || *order.Tif != types.TifGtc {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	if order.Cloid == nil || *order.Cloid != "0x00000000000000000000000000000007" {
+		t.Fatalf("order cloid = %v", order.Cloid)
+	}
+
+	result, err = info.OrderStatusTyped(testUser, 78, "")
+	if err != nil {
+		t.Fatalf("OrderStatusTyped unknown oid: %v", err)
+	}
+	if result.Found() || result.Status != "unknownOid" || result.Order != nil {
+		t.Fatalf("unexpected unknown oid status: %+v", result)
+	}
+
+	if got := server.infoRequests("orderStatus")[0]["user"]; got != testUser {
+		t.Fatalf("requested status for %v, want %s", got, testUser)
+	}
+}
+
+func TestAllUserFillsByTimeConcatenatesAndDedupsPages(t *testing.T) {
+	server := newMockServer(t)
+	// The first page is full and ends at time 3000; the second starts again at 3000, so it
+	// repeats the last fill of the first page before the remaining ones
+	server.handleInfo("userFillsByTime", func(payload map[string]interface{}) interface{} {
+		var fills []interface{}
+		switch payload["startTime"] {
+		case 1000.0:
+			for tid := 1; tid <= UserFillsByTimePageSize; tid++ {
+				fills = append(fills, fillFixture("ETH", "2000", "0.1", "B", tid, int64(1000+tid)))
+			}
+		case 3000.0:
+			for tid := UserFillsByTimePageSize; tid <= UserFillsByTimePageSize+5; tid++ {
+				fills = append(fills, fillFixture("ETH", "2000", "0.1", "B", tid, 3000))
+			}
+		default:
+			t.Errorf("unexpected startTime %v", payload["startTime"])
+		}
+		return fills
+	})
+	info := newTestInfo(t, server)
+
+	fills, err := info.AllUserFillsByTime(context.Background(), testUser, 1000, 5000)
+	if err != nil {
+		t.Fatalf("AllUserFillsByTime: %v", err)
+	}
+	if len(fills) != UserFillsByTimePageSize+5 {
+		t.Fatalf("got %d fills, want %d", len(fills), UserFillsByTimePageSize+5)
+	}
+	for idx, fill := range fills {
+		if fill.Tid != idx+1 {
+			t.Fatalf("fill %d has tid %d, want %d", idx, fill.Tid, idx+1)
+		}
+	}
+
+	requests := server.infoRequests("userFillsByTime")
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(requests))
+	}
+	for _, request := range requests {
+		if request["user"] != testUser || request["endTime"] != 5000.0 {
+			t.Fatalf("unexpected request %v", request)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := info.AllUserFillsByTime(ctx, testUser, 1000, 5000); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := len(server.infoRequests("userFillsByTime")); got != 2 {
+		t.Fatalf("a canceled context still sent %d requests", got-2)
+	}
+}
+
+func TestUniverseListsPerpSpotAndBuilderAssets(t *testing.T) {
+	server := newMockServer(t)
+	server.respondInfo("perpDexs", []interface{}{nil, map[string]interface{}{"name": "testdex"}})
+	server.handleInfo("meta", func(payload map[string]interface{}) interface{} {
+		if payload["dex"] != "testdex" {
+			t.Errorf("meta requested for dex %v, want testdex", payload["dex"])
+		}
+		return map[string]interface{}{"universe": []interface{}{
+			map[string]interface{}{"name": "testdex:FOO", "szDecimals": 2, "maxLeverage": 10},
+			map[string]interface{}{"name": "testdex:BAR", "szDecimals": 1, "maxLeverage": 5},
+		}}
+	})
+	info, err := NewInfo(server.URL, nil, true, testMeta(), testSpotMeta(), []string{"", "testdex"})
+	if err != nil {
+		t.Fatalf("NewInfo: %v", err)
+	}
+
+	universe, err := info.Universe()
+	if err != nil {
+		t.Fatalf("Universe: %v", err)
+	}
+	want := []types.UniverseEntry{
+		{Name: "ETH", Coin: "ETH", AssetID: 0, SzDecimals: 4, Kind: types.AssetKindPerp},
+		{Name: "BTC", Coin: "BTC", AssetID: 1, SzDecimals: 5, Kind: types.AssetKindPerp},
+		{Name: "PURR/USDC", Coin: "PURR/USDC", AssetID: 10000, SzDecimals: 0, Kind: types.AssetKindSpot},
+		{Name: "HFUN/USDC", Coin: "@1", AssetID: 10001, SzDecimals: 2, Kind: types.AssetKindSpot},
+		{Name: "testdex:FOO", Coin: "testdex:FOO", AssetID: 110000, SzDecimals: 2, Kind: types.AssetKindBuilder},
+		{Name: "testdex:BAR", Coin: "testdex:BAR", AssetID: 110001, SzDecimals: 1, Kind: types.AssetKindBuilder},
+	}
+	if !reflect.DeepEqual(universe, want) {
+		t.Fatalf("Universe() =\n%+v\nwant\n%+v", universe, want)
+	}
+
+	// Every entry resolves back to its asset ID by name
+	for _, entry := range universe {
+		if asset, err := info.NameToAsset(entry.Name); err != nil || asset != entry.AssetID {
+			t.Errorf("NameToAsset(%s) = %d, %v, want %d", entry.Name, asset, err, entry.AssetID)
+		}
+	}
+}
+
+func TestSubscribeUserEventsSurfacesLiquidations(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	events := make(chan types.UserEventsData, 4)
+	if err := info.SubscribeUserEvents(testUser, func(data types.UserEventsData) { events <- data }); err != nil {
+		t.Fatalf("SubscribeUserEvents: %v", err)
+	}
+	server.nextWebsocketMessage()
+	next := func() types.UserEventsData {
+		t.Helper()
+		select {
+		case data := <-events:
+			return data
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for user events")
+			return types.UserEventsData{}
+		}
+	}
+
+	liquidated := fillFixture("ETH", "1800", "0.5", "A", 1, 1)
+	liquidated["dir"] = "Market Order Liquidation: Close Long"
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "user",
+		"data": map[string]interface{}{"fills": []interface{}{
+			liquidated,
+			fillFixture("BTC", "60000", "0.01", "B", 2, 2),
+		}},
+	})
+	data := next()
+	if len(data.Fills) != 2 || data.Liquidation != nil {
+		t.Fatalf("unexpected fills event: %+v", data)
+	}
+	if !data.Fills[0].IsLiquidation() || data.Fills[1].IsLiquidation() {
+		t.Fatalf("liquidation flags = %v, %v; want true, false", data.Fills[0].IsLiquidation(), data.Fills[1].IsLiquidation())
+	}
+
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "user",
+		"data": map[string]interface{}{"liquidation": map[string]interface{}{
+			"lid": 7, "liquidator": testVault, "liquidated_user": testUser,
+			"liquidated_ntl_pos": "900.0", "liquidated_account_value": "40.0",
+		}},
+	})
+	data = next()
+	if data.Liquidation == nil || len(data.Fills) != 0 {
+		t.Fatalf("unexpected liquidation event: %+v", data)
+	}
+	want := types.Liquidation{Lid: 7, Liquidator: testVault, LiquidatedUser: testUser, LiquidatedNtlPos: "900.0", LiquidatedAccountValue: "40.0"}
+	if *data.Liquidation != want {
+		t.Fatalf("liquidation = %+v, want %+v", *data.Liquidation, want)
+	}
+}
+
+func TestExpectedFillPriceWalksSyntheticBook(t *testing.T) {
+	server := newMockServer(t)
+	level := func(px, sz string) map[string]interface{} {
+		return map[string]interface{}{"px": px, "sz": sz, "n": 1}
+	}
+	server.handleInfo("l2Book", func(payload map[string]interface{}) interface{} {
+		if payload["coin"] == "BTC" {
+			return map[string]interface{}{"coin": "BTC", "time": 1, "levels": []interface{}{[]interface{}{}, []interface{}{}}}
+		}
+		return map[string]interface{}{"coin": "ETH", "time": 1, "levels": []interface{}{
+			[]interface{}{level("2000", "1"), level("1999", "2")},
+			[]interface{}{level("2001", "1"), level("2002", "2"), level("2003", "1")},
+		}}
+	})
+	info := newTestInfo(t, server)
+
+	tests := []struct {
+		isBuy      bool
+		sz         float64
+		wantPx     float64
+		wantFilled float64
+	}{
+		{isBuy: true, sz: 0.5, wantPx: 2001, wantFilled: 0.5},
+		{isBuy: true, sz: 2, wantPx: 2001.5, wantFilled: 2},
+		// More than the book holds fills only the 4 available
+		{isBuy: true, sz: 10, wantPx: 2002, wantFilled: 4},
+		{isBuy: false, sz: 1.5, wantPx: (2000 + 0.5*1999) / 1.5, wantFilled: 1.5},
+	}
+	for _, tt := range tests {
+		avgPx, filledSz, err := info.ExpectedFillPrice("ETH", tt.isBuy, tt.sz)
+		if err != nil {
+			t.Fatalf("ExpectedFillPrice(buy=%v, %v): %v", tt.isBuy, tt.sz, err)
+		}
+		if !approxEqual(avgPx, tt.wantPx) || !approxEqual(filledSz, tt.wantFilled) {
+			t.Errorf("ExpectedFillPrice(buy=%v, %v) = %v, %v; want %v, %v", tt.isBuy, tt.sz, avgPx, filledSz, tt.wantPx, tt.wantFilled)
+		}
+	}
+
+	if _, _, err := info.ExpectedFillPrice("BTC", true, 1); err == nil || !strings.Contains(err.Error(), "no liquidity") {
+		t.Errorf("expected a no liquidity error for an empty book, got %v", err)
+	}
+	var validationErr *utils.ValidationError
+	if _, _, err := info.ExpectedFillPrice("ETH", true, 0); !errors.As(err, &validationErr) {
+		t.Errorf("expected a ValidationError for a zero size, got %v", err)
+	}
+}
+
+func TestSubscribeUserAllRoutesEachStream(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	fills := make(chan types.UserFillsData, 4)
+	fundings := make(chan types.UserFundingsData, 4)
+	ledger := make(chan types.UserLedgerUpdatesData, 4)
+	orders := make(chan []types.OrderUpdate, 4)
+	err := info.SubscribeUserAll(testUser, UserCallbacks{
+		Fills:         func(data types.UserFillsData) { fills <- data },
+		Fundings:      func(data types.UserFundingsData) { fundings <- data },
+		LedgerUpdates: func(data types.UserLedgerUpdatesData) { ledger <- data },
+		OrderUpdates:  func(updates []types.OrderUpdate) { orders <- updates },
+	})
+	if err != nil {
+		t.Fatalf("SubscribeUserAll: %v", err)
+	}
+	for _, want := range []string{"userFills", "userFundings", "userNonFundingLedgerUpdates", "orderUpdates"} {
+		message := server.nextWebsocketMessage()
+		if got := jsonField(t, message, "subscription", "type"); got != want {
+			t.Fatalf("subscribed to %v, want %s", got, want)
+		}
+		if want != "orderUpdates" && jsonField(t, message, "subscription", "user") != testUser {
+			t.Fatalf("%s subscription is not for %s: %v", want, testUser, message)
+		}
+	}
+
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "userFills",
+		"data":    map[string]interface{}{"user": testUser, "fills": []interface{}{fillFixture("ETH", "2000", "0.1", "B", 1, 1)}},
+	})
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "userFundings",
+		"data": map[string]interface{}{"user": testUser, "fundings": []interface{}{
+			map[string]interface{}{"time": 2, "coin": "ETH", "usdc": "-0.25", "szi": "0.5", "fundingRate": "0.0000125"},
+		}},
+	})
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "userNonFundingLedgerUpdates",
+		"data": map[string]interface{}{"user": testUser, "nonFundingLedgerUpdates": []interface{}{
+			map[string]interface{}{"time": 3, "hash": "0x0", "delta": map[string]interface{}{"type": "deposit", "usdc": "100.0"}},
+		}},
+	})
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "orderUpdates",
+		"data": []interface{}{map[string]interface{}{
+			"order":  openOrderFixture("ETH", "B", "1900.0", "0.1", 101),
+			"status": "open", "statusTimestamp": 4,
+		}},
+	})
+
+	timeout := time.After(2 * time.Second)
+	for received := 0; received < 4; received++ {
+		select {
+		case data := <-fills:
+			if len(data.Fills) != 1 || data.Fills[0].Tid != 1 {
+				t.Errorf("unexpected fills: %+v", data)
+			}
+		case data := <-fundings:
+			if len(data.Fundings) != 1 || data.Fundings[0].Usdc != "-0.25" {
+				t.Errorf("unexpected fundings: %+v", data)
+			}
+		case data := <-ledger:
+			if len(data.NonFundingLedgerUpdates) != 1 || data.NonFundingLedgerUpdates[0].DeltaType() != "deposit" {
+				t.Errorf("unexpected ledger updates: %+v", data)
+			}
+		case updates := <-orders:
+			if len(updates) != 1 || updates[0].Order.Oid != 101 || updates[0].Status != "open" {
+				t.Errorf("unexpected order updates: %+v", updates)
+			}
+		case <-timeout:
+			t.Fatalf("timed out after %d of 4 streams", received)
+		}
+	}
+
+	// Each frame reached only its own handler
+	select {
+	case <-fills:
+		t.Fatal("fills handler received a second frame")
+	case <-fundings:
+		t.Fatal("fundings handler received a second frame")
+	case <-ledger:
+		t.Fatal("ledger handler received a second frame")
+	case <-orders:
+		t.Fatal("order updates handler received a second frame")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTokenInfoLooksUpSpotTokensByName(t *testing.T) {
+	server := newMockServer(t)
+	spotMeta := testSpotMeta()
+	contract := "0xbaf265ef389da684513d98d68edf4eae00000000"
+	spotMeta.Tokens[2].EvmContract = &contract
+	// A second, non-canonical token reusing a canonical name
+	spotMeta.Tokens = append(spotMeta.Tokens, types.SpotTokenInfo{Name: "PURR", SzDecimals: 1, WeiDecimals: 6, Index: 3, TokenId: "0x0000000000000000000000000000abcd"})
+	info, err := NewInfo(server.URL, nil, true, testMeta(), spotMeta, nil)
+	if err != nil {
+		t.Fatalf("NewInfo: %v", err)
+	}
+
+	token, err := info.TokenInfo("HFUN")
+	if err != nil {
+		t.Fatalf("TokenInfo: %v", err)
+	}
+	if token.Index != 2 || token.TokenId != "0xbaf265ef389da684513d98d68edf4eae" || token.WeiDecimals != 8 || token.EvmContract == nil || *token.EvmContract != contract {
+		t.Fatalf("unexpected HFUN token: %+v", token)
+	}
+
+	// A shared name resolves to the canonical token unless the tokenId picks another
+	if token, err := info.TokenInfo("PURR"); err != nil || token.Index != 1 {
+		t.Fatalf("TokenInfo(PURR) = %+v, %v; want the canonical token 1", token, err)
+	}
+	if token, err := info.TokenInfo("PURR:0x0000000000000000000000000000ABCD"); err != nil || token.Index != 3 {
+		t.Fatalf("TokenInfo by tokenId = %+v, %v; want token 3", token, err)
+	}
+
+	// Returned tokens are copies and do not alias the cached metadata
+	token.WeiDecimals = 0
+	if again, _ := info.TokenInfo("HFUN"); again.WeiDecimals != 8 {
+		t.Fatal("modifying a returned token changed the cached spot meta")
+	}
+
+	for _, name := range []string{"DOGE", "HFUN:0x0000000000000000000000000000abcd", ""} {
+		if _, err := info.TokenInfo(name); err == nil || !strings.Contains(err.Error(), "spot token not found") {
+			t.Errorf("TokenInfo(%q) error = %v, want spot token not found", name, err)
+		}
+	}
+}
+
+func TestSpotAssetIdResolvesCanonicalAndAliasedPairs(t *testing.T) {
+	info := newTestInfo(t, newMockServer(t))
+
+	// HFUN/USDC is not canonical, so the API names it @1 and the pair name is an alias
+	for name, want := range map[string]int{"PURR/USDC": 10000, "HFUN/USDC": 10001, "@1": 10001} {
+		if got, err := info.SpotAssetId(name); err != nil || got != want {
+			t.Errorf("SpotAssetId(%q) = %d, %v; want %d", name, got, err, want)
+		}
+	}
+
+	if _, err := info.SpotAssetId("DOGE/USDC"); err == nil || !strings.Contains(err.Error(), "spot pair not found: DOGE/USDC") {
+		t.Errorf("unknown pair error = %v", err)
+	}
+	if _, err := info.SpotAssetId("ETH"); err == nil || !strings.Contains(err.Error(), "not a spot pair") {
+		t.Errorf("perp asset error = %v", err)
+	}
+}
@@ -0,0 +1,647 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+
+	"hyperliquid-go-sdk/pkg/types"
+	"hyperliquid-go-sdk/pkg/utils"
+)
+
+// testKeyHex is the private key of the account used by the mock exchange tests
+const testKeyHex = "0x0123456789012345678901234567890123456789012345678901234567890123"
+
+// rawResponse is a handler result written verbatim instead of JSON-encoded
+type rawResponse struct {
+	status int
+	body   string
+}
+
+// recordedRequest is a request received by a mockServer
+type recordedRequest struct {
+	Path    string
+	Header  http.Header
+	Payload map[string]interface{}
+}
+
+// mockHandler returns the response for a decoded request payload; rawResponse values are
+// written as-is and anything else is JSON-encoded with status 200
+type mockHandler func(payload map[string]interface{}) interface{}
+
+// mockServer stands in for the API: /info requests are routed by their type and /exchange
+// requests go to a single handler that accepts everything by default. /ws accepts websocket
+// connections whose inbound messages and pongs are queued for the test to inspect.
+type mockServer struct {
+	*httptest.Server
+	t        *testing.T
+	mutex    sync.Mutex
+	info     map[string]mockHandler
+	exchange mockHandler
+	paths    map[string]mockHandler
+	requests []recordedRequest
+
+	wsMutex     sync.Mutex
+	wsConns     []*websocket.Conn
+	wsRefused   atomic.Bool
+	wsConnected chan struct{}
+	wsReceived  chan map[string]interface{}
+	wsPongs     chan string
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	t.Helper()
+
+	m := &mockServer{
+		t:     t,
+		info:  make(map[string]mockHandler),
+		paths: make(map[string]mockHandler),
+		exchange: func(map[string]interface{}) interface{} {
+			return map[string]interface{}{"status": "ok", "response": map[string]interface{}{"type": "default"}}
+		},
+		wsConnected: make(chan struct{}, 16),
+		wsReceived:  make(chan map[string]interface{}, 256),
+		wsPongs:     make(chan string, 16),
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(func() {
+		m.dropWebsockets()
+		m.Close()
+	})
+
+	return m
+}
+
+// handleInfo sets the handler for /info requests of infoType
+func (m *mockServer) handleInfo(infoType string, handler mockHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.info[infoType] = handler
+}
+
+// respondInfo makes /info requests of infoType return response
+func (m *mockServer) respondInfo(infoType string, response interface{}) {
+	m.handleInfo(infoType, func(map[string]interface{}) interface{} { return response })
+}
+
+// handleExchange sets the handler for /exchange requests
+func (m *mockServer) handleExchange(handler mockHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.exchange = handler
+}
+
+// handlePath sets the handler for requests to any other path, such as /explorer
+func (m *mockServer) handlePath(path string, handler mockHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.paths[path] = handler
+}
+
+// recorded returns the requests received so far, optionally only those to path
+func (m *mockServer) recorded(path string) []recordedRequest {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var requests []recordedRequest
+	for _, req := range m.requests {
+		if path == "" || req.Path == path {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// infoRequests returns the /info payloads of infoType received so far
+func (m *mockServer) infoRequests(infoType string) []map[string]interface{} {
+	var payloads []map[string]interface{}
+	for _, req := range m.recorded("/info") {
+		if req.Payload["type"] == infoType {
+			payloads = append(payloads, req.Payload)
+		}
+	}
+	return payloads
+}
+
+// lastExchangePayload returns the most recent /exchange payload, failing the test if there is none
+func (m *mockServer) lastExchangePayload() map[string]interface{} {
+	m.t.Helper()
+
+	requests := m.recorded("/exchange")
+	if len(requests) == 0 {
+		m.t.Fatal("no /exchange request received")
+	}
+	return requests[len(requests)-1].Payload
+}
+
+func (m *mockServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/ws" {
+		m.serveWebsocket(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	m.mutex.Lock()
+	m.requests = append(m.requests, recordedRequest{Path: r.URL.Path, Header: r.Header.Clone(), Payload: payload})
+	var handler mockHandler
+	switch r.URL.Path {
+	case "/info":
+		infoType, _ := payload["type"].(string)
+		handler = m.info[infoType]
+	case "/exchange":
+		handler = m.exchange
+	default:
+		handler = m.paths[r.URL.Path]
+	}
+	m.mutex.Unlock()
+
+	if handler == nil {
+		http.Error(w, fmt.Sprintf("no handler for %s %v", r.URL.Path, payload["type"]), http.StatusNotFound)
+		return
+	}
+
+	switch response := handler(payload).(type) {
+	case rawResponse:
+		w.WriteHeader(response.status)
+		io.WriteString(w, response.body)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (m *mockServer) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	if m.wsRefused.Load() {
+		http.Error(w, "websocket unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetPongHandler(func(appData string) error {
+		m.wsPongs <- appData
+		return nil
+	})
+
+	m.wsMutex.Lock()
+	m.wsConns = append(m.wsConns, conn)
+	m.wsMutex.Unlock()
+	m.wsConnected <- struct{}{}
+
+	for {
+		var message map[string]interface{}
+		if err := conn.ReadJSON(&message); err != nil {
+			return
+		}
+		m.wsReceived <- message
+	}
+}
+
+// sendWebsocket writes frame as JSON to the most recent websocket connection
+func (m *mockServer) sendWebsocket(frame interface{}) {
+	m.t.Helper()
+
+	m.wsMutex.Lock()
+	defer m.wsMutex.Unlock()
+
+	if len(m.wsConns) == 0 {
+		m.t.Fatal("no websocket connection")
+	}
+	if err := m.wsConns[len(m.wsConns)-1].WriteJSON(frame); err != nil {
+		m.t.Fatalf("failed to write websocket frame: %v", err)
+	}
+}
+
+// pingWebsocket sends a ping control frame to the most recent websocket connection
+func (m *mockServer) pingWebsocket(appData string) {
+	m.t.Helper()
+
+	m.wsMutex.Lock()
+	defer m.wsMutex.Unlock()
+
+	if len(m.wsConns) == 0 {
+		m.t.Fatal("no websocket connection")
+	}
+	if err := m.wsConns[len(m.wsConns)-1].WriteControl(websocket.PingMessage, []byte(appData), time.Now().Add(time.Second)); err != nil {
+		m.t.Fatalf("failed to write ping: %v", err)
+	}
+}
+
+// dropWebsockets closes every websocket connection without a close frame, like a network failure
+func (m *mockServer) dropWebsockets() {
+	m.wsMutex.Lock()
+	defer m.wsMutex.Unlock()
+
+	for _, conn := range m.wsConns {
+		conn.Close()
+	}
+	m.wsConns = nil
+}
+
+// refuseWebsockets makes the server reject every later websocket handshake
+func (m *mockServer) refuseWebsockets() {
+	m.wsRefused.Store(true)
+}
+
+// waitWebsocketConnection waits until a new websocket connection has been accepted
+func (m *mockServer) waitWebsocketConnection() {
+	m.t.Helper()
+
+	select {
+	case <-m.wsConnected:
+	case <-time.After(2 * time.Second):
+		m.t.Fatal("timed out waiting for a websocket connection")
+	}
+}
+
+// nextWebsocketMessage waits for the next message the client sent over the websocket
+func (m *mockServer) nextWebsocketMessage() map[string]interface{} {
+	m.t.Helper()
+
+	select {
+	case message := <-m.wsReceived:
+		return message
+	case <-time.After(2 * time.Second):
+		m.t.Fatal("timed out waiting for a websocket message")
+		return nil
+	}
+}
+
+// newTestWebsocket returns a started WebsocketManager connected to the mock server
+// configure, if not nil, adjusts the manager before it starts.
+func newTestWebsocket(t *testing.T, server *mockServer, configure func(*WebsocketManager), opts ...WebsocketOption) *WebsocketManager {
+	t.Helper()
+
+	manager, err := NewWebsocketManager(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("NewWebsocketManager: %v", err)
+	}
+	manager.subscribeDelay = 0
+	if configure != nil {
+		configure(manager)
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { manager.Stop() })
+	server.waitWebsocketConnection()
+
+	return manager
+}
+
+// newTestInfoWithWebsocket returns an Info like newTestInfo whose websocket is connected to the mock server
+func newTestInfoWithWebsocket(t *testing.T, server *mockServer, configure func(*WebsocketManager), opts ...WebsocketOption) *Info {
+	t.Helper()
+
+	info := newTestInfo(t, server)
+	info.wsManager = newTestWebsocket(t, server, configure, opts...)
+	return info
+}
+
+// testMeta is the perp metadata used by the mock tests: ETH is asset 0 and BTC asset 1
+func testMeta() *types.Meta {
+	return &types.Meta{
+		Universe: []types.AssetInfo{
+			{Name: "ETH", SzDecimals: 4, MaxLeverage: 25},
+			{Name: "BTC", SzDecimals: 5, MaxLeverage: 40},
+		},
+	}
+}
+
+// testSpotMeta is the spot metadata used by the mock tests: PURR/USDC is the canonical
+// pair @0 (asset 10000) and HFUN/USDC the non-canonical pair @1 (asset 10001)
+func testSpotMeta() *types.SpotMeta {
+	return &types.SpotMeta{
+		Universe: []types.SpotAssetInfo{
+			{Name: "PURR/USDC", Tokens: []int{1, 0}, Index: 0, IsCanonical: true},
+			{Name: "@1", Tokens: []int{2, 0}, Index: 1},
+		},
+		Tokens: []types.SpotTokenInfo{
+			{Name: "USDC", SzDecimals: 8, WeiDecimals: 8, Index: 0, TokenId: "0x6d1e7cde53ba9467b783cb7c530ce054", IsCanonical: true},
+			{Name: "PURR", SzDecimals: 0, WeiDecimals: 5, Index: 1, TokenId: "0xc1fb593aeffbeb02f85e0308e9956a90", IsCanonical: true},
+			{Name: "HFUN", SzDecimals: 2, WeiDecimals: 8, Index: 2, TokenId: "0xbaf265ef389da684513d98d68edf4eae"},
+		},
+	}
+}
+
+// newTestInfo returns an Info without websocket backed by the mock server and the test metadata
+func newTestInfo(t *testing.T, server *mockServer) *Info {
+	t.Helper()
+
+	info, err := NewInfo(server.URL, nil, true, testMeta(), testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewInfo: %v", err)
+	}
+	return info
+}
+
+// testKey returns the private key of the test account
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := utils.ParsePrivateKey(testKeyHex)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	return key
+}
+
+// newTestExchange returns an Exchange signing with the test key against the mock server
+func newTestExchange(t *testing.T, server *mockServer) *Exchange {
+	t.Helper()
+
+	exchange, err := NewExchange(testKey(t), server.URL, nil, testMeta(), nil, nil, testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	return exchange
+}
+
+// jsonNumbersToInts converts the whole-number float64 values produced by encoding/json back
+// to ints, so that an action decoded by the mock server hashes like the one that was signed
+func jsonNumbersToInts(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			converted[k] = jsonNumbersToInts(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for idx, item := range value {
+			converted[idx] = jsonNumbersToInts(item)
+		}
+		return converted
+	case float64:
+		if value == float64(int64(value)) {
+			return int(value)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// recoverPayloadSigner recovers the signer of an L1 action payload received by the mock server
+func recoverPayloadSigner(t *testing.T, payload map[string]interface{}, isMainnet bool) string {
+	t.Helper()
+
+	action, ok := jsonNumbersToInts(payload["action"]).(map[string]interface{})
+	if !ok {
+		t.Fatalf("payload action is not an object: %v", payload["action"])
+	}
+	nonce, ok := payload["nonce"].(float64)
+	if !ok {
+		t.Fatalf("payload nonce is not a number: %v", payload["nonce"])
+	}
+
+	var vaultAddress *string
+	if vault, ok := payload["vaultAddress"].(string); ok {
+		vaultAddress = &vault
+	}
+	var expiresAfter *int64
+	if expires, ok := payload["expiresAfter"].(float64); ok {
+		value := int64(expires)
+		expiresAfter = &value
+	}
+
+	sig, ok := payload["signature"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("payload signature is not an object: %v", payload["signature"])
+	}
+	r, _ := sig["r"].(string)
+	s, _ := sig["s"].(string)
+	v, _ := sig["v"].(float64)
+
+	rBig, err := hexutil.DecodeBig(r)
+	if err != nil {
+		t.Fatalf("invalid signature r: %v", err)
+	}
+	sBig, err := hexutil.DecodeBig(s)
+	if err != nil {
+		t.Fatalf("invalid signature s: %v", err)
+	}
+	signature := make([]byte, 65)
+	rBig.FillBytes(signature[:32])
+	sBig.FillBytes(signature[32:64])
+	signature[64] = byte(v) - 27
+
+	hash := utils.ActionHash(action, vaultAddress, int64(nonce), expiresAfter)
+	typedData := utils.L1Payload(utils.ConstructPhantomAgent(hash, isMainnet))
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to hash typed data: %v", err)
+	}
+	digest := crypto.Keccak256(append(append([]byte{0x19, 0x01}, domainSeparator...), typedDataHash...))
+
+	publicKey, err := crypto.SigToPub(digest, signature)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*publicKey).Hex()
+}
+
+// assertSignedBy fails the test unless the L1 action payload was signed by address
+func assertSignedBy(t *testing.T, payload map[string]interface{}, address string) {
+	t.Helper()
+
+	if signer := recoverPayloadSigner(t, payload, false); !strings.EqualFold(signer, address) {
+		t.Fatalf("payload signed by %s, want %s", signer, address)
+	}
+}
+
+// orderResponse builds an /exchange order response with the given statuses
+func orderResponse(statuses ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"status": "ok",
+		"response": map[string]interface{}{
+			"type": "order",
+			"data": map[string]interface{}{"statuses": statuses},
+		},
+	}
+}
+
+// jsonField walks nested objects and arrays of a decoded JSON value by keys and indexes
+func jsonField(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected object at %q, got %T", k, v)
+			}
+			v = m[k]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || k >= len(a) {
+				t.Fatalf("expected array with index %d, got %v", k, v)
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
+
+// metaAndAssetCtxsResponse builds a metaAndAssetCtxs response for testMeta with one context per asset
+func metaAndAssetCtxsResponse(ctxs ...map[string]interface{}) []interface{} {
+	return []interface{}{
+		map[string]interface{}{"universe": testMeta().Universe},
+		ctxs,
+	}
+}
+
+// approxEqual reports whether two floats are equal within a small tolerance
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// l2BookFrame builds an l2Book websocket frame for coin with one bid and one ask level
+func l2BookFrame(coin string, bidPx, askPx string, time int64) map[string]interface{} {
+	return map[string]interface{}{
+		"channel": "l2Book",
+		"data": map[string]interface{}{
+			"coin": coin,
+			"time": time,
+			"levels": []interface{}{
+				[]interface{}{map[string]interface{}{"px": bidPx, "sz": "1", "n": 1}},
+				[]interface{}{map[string]interface{}{"px": askPx, "sz": "1", "n": 1}},
+			},
+		},
+	}
+}
+
+// testUser is the address used for user-scoped info requests and subscriptions
+const testUser = "0x14dc79964da2c08b23698b3d3cc7ca32193d9955"
+
+// fillFixture builds a fill as returned by userFills and the userFills stream
+func fillFixture(coin string, px, sz string, side string, tid int, time int64) map[string]interface{} {
+	return map[string]interface{}{
+		"coin": coin, "px": px, "sz": sz, "side": side, "time": time,
+		"startPosition": "0", "dir": "Open Long", "closedPnl": "0.0",
+		"oid": tid * 10, "crossed": true, "fee": "0.01", "tid": tid, "feeToken": "USDC",
+		"hash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+	}
+}
+
+// positionFixture builds an assetPositions entry for a one-way position in coin
+func positionFixture(coin string, szi string, leverage map[string]interface{}, marginUsed string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "oneWay",
+		"position": map[string]interface{}{
+			"coin": coin, "szi": szi, "entryPx": "2000", "positionValue": "2000",
+			"unrealizedPnl": "0", "returnOnEquity": "0", "leverage": leverage,
+			"liquidationPx": nil, "marginUsed": marginUsed, "maxLeverage": 25,
+			"cumFunding": map[string]interface{}{"allTime": "0", "sinceChange": "0", "sinceOpen": "0"},
+		},
+	}
+}
+
+// clearinghouseStateFixture builds a clearinghouseState response with the given account value and positions
+func clearinghouseStateFixture(accountValue, totalMarginUsed string, positions ...map[string]interface{}) map[string]interface{} {
+	summary := map[string]interface{}{
+		"accountValue": accountValue, "totalNtlPos": "0", "totalRawUsd": accountValue, "totalMarginUsed": totalMarginUsed,
+	}
+	if positions == nil {
+		positions = []map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"assetPositions":             positions,
+		"marginSummary":              summary,
+		"crossMarginSummary":         summary,
+		"crossMaintenanceMarginUsed": "0",
+		"withdrawable":               accountValue,
+		"time":                       1700000000000,
+	}
+}
+
+// testVault is the vault address used by tests acting on behalf of a vault
+const testVault = "0x1719884eb866cb12b2287399b15f7db5e7d775ea"
+
+// newTestVaultExchange returns an Exchange like newTestExchange acting on behalf of testVault
+func newTestVaultExchange(t *testing.T, server *mockServer) *Exchange {
+	t.Helper()
+
+	vault := testVault
+	exchange, err := NewExchange(testKey(t), server.URL, nil, testMeta(), &vault, nil, testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	return exchange
+}
+
+// openOrderFixture builds a resting order as returned by openOrders
+func openOrderFixture(coin string, side string, limitPx, sz string, oid int) map[string]interface{} {
+	return map[string]interface{}{
+		"coin": coin, "side": side, "limitPx": limitPx, "sz": sz, "origSz": sz,
+		"oid": oid, "timestamp": 1700000000000,
+	}
+}
+
+// respondAccount makes the mock server answer the four account snapshot queries
+func respondAccount(server *mockServer) {
+	server.respondInfo("clearinghouseState", clearinghouseStateFixture("1000.5", "100",
+		positionFixture("ETH", "0.5", map[string]interface{}{"type": "cross", "value": 10}, "100"),
+	))
+	server.respondInfo("spotClearinghouseState", map[string]interface{}{
+		"balances": []interface{}{
+			map[string]interface{}{"coin": "USDC", "token": 0, "hold": "0.0", "total": "250.0", "entryNtl": "0.0"},
+			map[string]interface{}{"coin": "PURR", "token": 1, "hold": "10.0", "total": "1200.0", "entryNtl": "240.0"},
+		},
+	})
+	server.respondInfo("openOrders", []interface{}{openOrderFixture("ETH", "B", "1900.0", "0.1", 101)})
+	server.respondInfo("userFills", []interface{}{fillFixture("ETH", "2000.0", "0.5", "B", 1, 1700000000000)})
+}
+
+// testAgentKeyHex is the private key of an agent approved for the test account
+const testAgentKeyHex = "0x5c5a6a0e5aa8f5b4a1e4e9ac0ad4c4c6ea3f1d2b7c8e9f0a1b2c3d4e5f60718a"
+
+// newTestAgentExchange returns an Exchange signing with the test agent key on behalf of the test account
+func newTestAgentExchange(t *testing.T, server *mockServer) *Exchange {
+	t.Helper()
+
+	agentKey, err := utils.ParsePrivateKey(testAgentKeyHex)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	account := utils.GetAddressFromPrivateKey(testKey(t))
+	exchange, err := NewExchange(agentKey, server.URL, nil, testMeta(), nil, &account, testSpotMeta(), nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	return exchange
+}
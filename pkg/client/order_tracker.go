@@ -0,0 +1,212 @@
+package client
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+// OrderState represents the lifecycle state of a tracked order
+type OrderState string
+
+const (
+	OrderStateOpen            OrderState = "open"
+	OrderStatePartiallyFilled OrderState = "partiallyFilled"
+	OrderStateFilled          OrderState = "filled"
+	OrderStateCanceled        OrderState = "canceled"
+)
+
+// IsTerminal returns true if the order can no longer change state
+func (s OrderState) IsTerminal() bool {
+	return s == OrderStateFilled || s == OrderStateCanceled
+}
+
+// TrackedOrder is a snapshot of an order's state as seen by an OrderTracker
+type TrackedOrder struct {
+	Cloid     string
+	Oid       int
+	Coin      string
+	State     OrderState
+	Status    string // last raw status from orderUpdates, e.g. "marginCanceled"
+	OrigSz    float64
+	FilledSz  float64
+	UpdatedAt int64
+}
+
+// trackedOrder holds an order's state along with the filled size reported by each stream
+// Fills and order updates can arrive in either order, so the larger of the two is used.
+type trackedOrder struct {
+	TrackedOrder
+	filledFromFills   float64
+	filledFromUpdates float64
+	statusTimestamp   int64
+}
+
+// OrderTracker maintains the state of orders placed with a cloid, keyed by cloid
+// Feed it from the orderUpdates and userFills streams, either with Track or by calling
+// HandleOrderUpdates and HandleUserFills directly. It is safe for concurrent use.
+type OrderTracker struct {
+	mutex      sync.RWMutex
+	orders     map[string]*trackedOrder
+	oidToCloid map[int]string
+}
+
+// NewOrderTracker creates an empty OrderTracker
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{
+		orders:     make(map[string]*trackedOrder),
+		oidToCloid: make(map[int]string),
+	}
+}
+
+// Track subscribes the tracker to the user's orderUpdates and userFills streams
+func (t *OrderTracker) Track(info *Info, user string) error {
+	if err := info.SubscribeOrderUpdates(user, t.HandleOrderUpdates); err != nil {
+		return err
+	}
+	return info.SubscribeUserFills(user, t.HandleUserFills)
+}
+
+// HandleOrderUpdates applies order status changes; orders without a cloid are ignored
+// Updates older than the order's last update are ignored, and terminal states are never left.
+func (t *OrderTracker) HandleOrderUpdates(updates []types.OrderUpdate) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, update := range updates {
+		if update.Order.Cloid == nil {
+			continue
+		}
+		cloid := *update.Order.Cloid
+
+		order, exists := t.orders[cloid]
+		if !exists {
+			order = &trackedOrder{TrackedOrder: TrackedOrder{Cloid: cloid}}
+			t.orders[cloid] = order
+		}
+		t.oidToCloid[update.Order.Oid] = cloid
+
+		order.Oid = update.Order.Oid
+		order.Coin = update.Order.Coin
+		if origSz, err := strconv.ParseFloat(update.Order.OrigSz, 64); err == nil {
+			order.OrigSz = origSz
+		}
+
+		if update.StatusTimestamp < order.statusTimestamp || order.State.IsTerminal() {
+			continue
+		}
+		order.statusTimestamp = update.StatusTimestamp
+		if update.StatusTimestamp > order.UpdatedAt {
+			order.UpdatedAt = update.StatusTimestamp
+		}
+		order.Status = update.Status
+
+		switch {
+		case update.Status == "filled":
+			order.filledFromUpdates = order.OrigSz
+			order.FilledSz = order.OrigSz
+			order.State = OrderStateFilled
+		case update.Status == "rejected" || strings.HasSuffix(strings.ToLower(update.Status), "canceled"):
+			order.State = OrderStateCanceled
+		default:
+			if remaining, err := strconv.ParseFloat(update.Order.Sz, 64); err == nil && remaining < order.OrigSz {
+				order.filledFromUpdates = order.OrigSz - remaining
+			}
+			order.updateFillState()
+		}
+	}
+}
+
+// HandleUserFills applies fills to tracked orders, matched by cloid or by oid
+// Snapshot messages contain historical fills and are ignored.
+func (t *OrderTracker) HandleUserFills(data types.UserFillsData) {
+	if data.IsSnapshot {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, fill := range data.Fills {
+		cloid, exists := t.oidToCloid[fill.Oid]
+		if fill.Cloid != nil {
+			cloid, exists = *fill.Cloid, true
+		}
+		if !exists {
+			continue
+		}
+
+		sz, err := strconv.ParseFloat(fill.Sz, 64)
+		if err != nil {
+			continue
+		}
+
+		order, exists := t.orders[cloid]
+		if !exists {
+			order = &trackedOrder{TrackedOrder: TrackedOrder{Cloid: cloid, Oid: fill.Oid, Coin: fill.Coin}}
+			t.orders[cloid] = order
+			t.oidToCloid[fill.Oid] = cloid
+		}
+		if order.State.IsTerminal() {
+			continue
+		}
+
+		order.filledFromFills += sz
+		if fill.Time > order.UpdatedAt {
+			order.UpdatedAt = fill.Time
+		}
+		order.updateFillState()
+	}
+}
+
+// updateFillState derives the filled size and state of a live order from both streams
+func (o *trackedOrder) updateFillState() {
+	o.FilledSz = math.Max(o.filledFromFills, o.filledFromUpdates)
+
+	switch {
+	case o.FilledSz <= 0:
+		o.State = OrderStateOpen
+	case o.OrigSz > 0 && o.FilledSz >= o.OrigSz:
+		o.State = OrderStateFilled
+	default:
+		o.State = OrderStatePartiallyFilled
+	}
+}
+
+// Order returns the tracked state of the order with the given cloid
+func (t *OrderTracker) Order(cloid string) (TrackedOrder, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	order, exists := t.orders[cloid]
+	if !exists {
+		return TrackedOrder{}, false
+	}
+	return order.TrackedOrder, true
+}
+
+// Orders returns the tracked state of all orders
+func (t *OrderTracker) Orders() []TrackedOrder {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	orders := make([]TrackedOrder, 0, len(t.orders))
+	for _, order := range t.orders {
+		orders = append(orders, order.TrackedOrder)
+	}
+	return orders
+}
+
+// Forget stops tracking the order with the given cloid
+func (t *OrderTracker) Forget(cloid string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if order, exists := t.orders[cloid]; exists {
+		delete(t.oidToCloid, order.Oid)
+		delete(t.orders, cloid)
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrderTrackerFollowsOrderLifecycle(t *testing.T) {
+	server := newMockServer(t)
+	info := newTestInfoWithWebsocket(t, server, nil)
+
+	tracker := NewOrderTracker()
+	if err := tracker.Track(info, testUser); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	for _, want := range []string{"orderUpdates", "userFills"} {
+		if got := jsonField(t, server.nextWebsocketMessage(), "subscription", "type"); got != want {
+			t.Fatalf("subscribed to %v, want %s", got, want)
+		}
+	}
+
+	const filledCloid = "0x00000000000000000000000000000001"
+	const canceledCloid = "0x00000000000000000000000000000002"
+	update := func(cloid interface{}, oid int, sz, status string, statusTimestamp int64) map[string]interface{} {
+		return map[string]interface{}{
+			"channel": "orderUpdates",
+			"data": []interface{}{map[string]interface{}{
+				"order": map[string]interface{}{
+					"coin": "ETH", "side": "B", "limitPx": "2000", "sz": sz, "origSz": "1.0",
+					"oid": oid, "timestamp": 1, "cloid": cloid,
+				},
+				"status":          status,
+				"statusTimestamp": statusTimestamp,
+			}},
+		}
+	}
+	waitFor := func(cloid string, state OrderState, filledSz float64) TrackedOrder {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			order, ok := tracker.Order(cloid)
+			if ok && order.State == state && approxEqual(order.FilledSz, filledSz) {
+				return order
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("order %s = %+v (tracked %v), want %s with %v filled", cloid, order, ok, state, filledSz)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	server.sendWebsocket(update(filledCloid, 10, "1.0", "open", 1))
+	order := waitFor(filledCloid, OrderStateOpen, 0)
+	if order.Oid != 10 || order.Coin != "ETH" || order.OrigSz != 1 {
+		t.Fatalf("unexpected open order: %+v", order)
+	}
+
+	// fillFixture uses oid tid*10, so this fill belongs to the order above
+	server.sendWebsocket(map[string]interface{}{
+		"channel": "userFills",
+		"data": map[string]interface{}{
+			"user":  testUser,
+			"fills": []interface{}{fillFixture("ETH", "2000", "0.4", "B", 1, 2)},
+		},
+	})
+	waitFor(filledCloid, OrderStatePartiallyFilled, 0.4)
+
+	server.sendWebsocket(update(filledCloid, 10, "0.0", "filled", 3))
+	waitFor(filledCloid, OrderStateFilled, 1)
+
+	server.sendWebsocket(update(canceledCloid, 20, "1.0", "open", 4))
+	waitFor(canceledCloid, OrderStateOpen, 0)
+	server.sendWebsocket(update(canceledCloid, 20, "1.0", "marginCanceled", 5))
+	if order := waitFor(canceledCloid, OrderStateCanceled, 0); order.Status != "marginCanceled" {
+		t.Fatalf("canceled order status = %s, want marginCanceled", order.Status)
+	}
+
+	// Stale and post-terminal updates do not reopen orders, and orders without a cloid are ignored
+	server.sendWebsocket(update(canceledCloid, 20, "1.0", "open", 6))
+	server.sendWebsocket(update(nil, 30, "1.0", "open", 7))
+	server.sendWebsocket(update(filledCloid, 10, "1.0", "open", 2))
+	const sentinelCloid = "0x00000000000000000000000000000003"
+	server.sendWebsocket(update(sentinelCloid, 40, "1.0", "open", 8))
+	waitFor(sentinelCloid, OrderStateOpen, 0)
+
+	waitFor(canceledCloid, OrderStateCanceled, 0)
+	waitFor(filledCloid, OrderStateFilled, 1)
+	if got := len(tracker.Orders()); got != 3 {
+		t.Fatalf("tracking %d orders, want 3", got)
+	}
+
+	tracker.Forget(filledCloid)
+	if _, ok := tracker.Order(filledCloid); ok {
+		t.Fatal("forgotten order is still tracked")
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -13,24 +14,76 @@ import (
 	"hyperliquid-go-sdk/pkg/utils"
 )
 
+// defaultSubscribeDelay is the default pause between consecutive subscribe messages
+const defaultSubscribeDelay = 50 * time.Millisecond
+
 // WebsocketManager manages WebSocket connections for real-time data
 type WebsocketManager struct {
-	baseURL         string
-	wsURL           string
-	conn            *websocket.Conn
-	subscriptions   map[string]func(interface{})
-	isRunning       bool
-	mutex           sync.RWMutex
-	reconnectDelay  time.Duration
-	maxReconnects   int
-	currentRetries  int
-	pingInterval    time.Duration
-	pongTimeout     time.Duration
-	done            chan struct{}
+	baseURL          string
+	wsURL            string
+	conn             *websocket.Conn
+	subscriptions    map[string]subscriptionEntry
+	subscriptionList []types.Subscription
+	isRunning        bool
+	mutex            sync.RWMutex
+	reconnectDelay   time.Duration
+	maxReconnects    int
+	currentRetries   int
+	pingInterval     time.Duration
+	pongTimeout      time.Duration
+	done             chan struct{}
+	noReconnect      bool
+	onReadError      func(error)
+	subscribeDelay   time.Duration
+	gapDetectors     map[string]*gapDetector
+	onFatal          func(error)
+	fatalOnce        sync.Once
+}
+
+// subscriptionEntry pairs a registered subscription with its callback
+type subscriptionEntry struct {
+	subscription types.Subscription
+	callback     func(interface{})
+}
+
+// WebsocketOption configures optional WebsocketManager behavior
+type WebsocketOption func(*WebsocketManager)
+
+// DisableReconnect stops the read pump on the first read error instead of reconnecting
+// Useful for short-lived connections that grab a snapshot and close
+func DisableReconnect() WebsocketOption {
+	return func(w *WebsocketManager) {
+		w.noReconnect = true
+	}
+}
+
+// OnReadError sets a callback invoked with the error that terminated the read pump
+func OnReadError(callback func(error)) WebsocketOption {
+	return func(w *WebsocketManager) {
+		w.onReadError = callback
+	}
+}
+
+// OnFatal sets a callback invoked once when the manager permanently gives up on the connection,
+// i.e. every reconnection attempt failed. The manager is stopped by then, so the owner can decide
+// whether to exit or create a new client.
+func OnFatal(callback func(error)) WebsocketOption {
+	return func(w *WebsocketManager) {
+		w.onFatal = callback
+	}
+}
+
+// WithSubscribeDelay sets the pause between consecutive subscribe messages
+// Subscribing to many channels at once, or replaying them after a reconnect, is paced so the
+// server does not drop the connection for flooding. A zero delay sends them back to back.
+func WithSubscribeDelay(delay time.Duration) WebsocketOption {
+	return func(w *WebsocketManager) {
+		w.subscribeDelay = delay
+	}
 }
 
 // NewWebsocketManager creates a new WebSocket manager
-func NewWebsocketManager(baseURL string) (*WebsocketManager, error) {
+func NewWebsocketManager(baseURL string, opts ...WebsocketOption) (*WebsocketManager, error) {
 	var wsURL string
 	
 	switch baseURL {
@@ -58,16 +111,24 @@ func NewWebsocketManager(baseURL string) (*WebsocketManager, error) {
 		wsURL = u.String()
 	}
 	
-	return &WebsocketManager{
+	manager := &WebsocketManager{
 		baseURL:        baseURL,
 		wsURL:          wsURL,
-		subscriptions:  make(map[string]func(interface{})),
+		subscriptions:  make(map[string]subscriptionEntry),
+		gapDetectors:   make(map[string]*gapDetector),
 		reconnectDelay: 5 * time.Second,
 		maxReconnects:  10,
 		pingInterval:   30 * time.Second,
 		pongTimeout:    10 * time.Second,
+		subscribeDelay: defaultSubscribeDelay,
 		done:           make(chan struct{}),
-	}, nil
+	}
+	
+	for _, opt := range opts {
+		opt(manager)
+	}
+	
+	return manager, nil
 }
 
 // Start starts the WebSocket connection
@@ -129,54 +190,142 @@ func (w *WebsocketManager) connect() error {
 	w.currentRetries = 0
 	
 	// Set read deadline for pong messages
-	w.conn.SetReadDeadline(time.Now().Add(w.pongTimeout))
-	w.conn.SetPongHandler(func(string) error {
-		w.conn.SetReadDeadline(time.Now().Add(w.pongTimeout))
+	conn.SetReadDeadline(time.Now().Add(w.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(w.pongTimeout))
 		return nil
 	})
 	
+	// Answer server pings so strict intermediaries don't consider the connection dead
+	conn.SetPingHandler(func(appData string) error {
+		conn.SetReadDeadline(time.Now().Add(w.pongTimeout))
+		err := conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(w.pongTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+	
 	return nil
 }
 
+// errReconnectsExhausted is returned by reconnect once maxReconnects attempts have been made
+var errReconnectsExhausted = errors.New("maximum reconnection attempts reached")
+
 // reconnect attempts to reconnect the WebSocket
 func (w *WebsocketManager) reconnect() error {
+	w.mutex.Lock()
 	if w.currentRetries >= w.maxReconnects {
-		return fmt.Errorf("maximum reconnection attempts reached")
+		w.mutex.Unlock()
+		return errReconnectsExhausted
 	}
-	
 	w.currentRetries++
-	log.Printf("WebSocket reconnection attempt %d/%d", w.currentRetries, w.maxReconnects)
+	attempt := w.currentRetries
+	w.mutex.Unlock()
+	
+	log.Printf("WebSocket reconnection attempt %d/%d", attempt, w.maxReconnects)
 	
 	time.Sleep(w.reconnectDelay)
 	
+	// Swap the connection under the lock so concurrent Subscribe calls don't race the new conn
+	w.mutex.Lock()
 	if err := w.connect(); err != nil {
+		w.mutex.Unlock()
 		return fmt.Errorf("reconnection failed: %w", err)
 	}
+	subscriptions := append([]types.Subscription(nil), w.subscriptionList...)
+	w.mutex.Unlock()
+	
+	// Resubscribe to all active subscriptions; entries keep their callbacks, so typed
+	// helpers (SubscribeL2Book, SubscribeCandle, ...) keep decoding after a reconnect.
+	// The lock is only held per message, so dispatch and Subscribe continue during the pacing.
+	for idx, subscription := range subscriptions {
+		if idx > 0 && w.subscribeDelay > 0 {
+			time.Sleep(w.subscribeDelay)
+		}
+		if err := w.resubscribe(subscription); err != nil {
+			log.Printf("Failed to resubscribe to %s: %v", subscription.Type, err)
+		}
+	}
 	
-	// Resubscribe to all active subscriptions
-	w.mutex.RLock()
-	subscriptions := make([]string, 0, len(w.subscriptions))
-	for sub := range w.subscriptions {
-		subscriptions = append(subscriptions, sub)
+	log.Printf("WebSocket reconnected successfully")
+	return nil
+}
+
+// resubscribe sends the subscribe message for sub again if it is still registered
+func (w *WebsocketManager) resubscribe(sub types.Subscription) error {
+	subKey, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
 	}
-	w.mutex.RUnlock()
 	
-	for _, sub := range subscriptions {
-		var subscription types.Subscription
-		if err := json.Unmarshal([]byte(sub), &subscription); err == nil {
-			w.sendSubscription(subscription)
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	
+	if _, exists := w.subscriptions[string(subKey)]; !exists || w.conn == nil {
+		return nil
+	}
+	
+	return w.sendSubscription(sub)
+}
+
+// reconnectWithRetries keeps attempting to reconnect until it succeeds, the manager is stopped,
+// or the maximum number of attempts is reached
+func (w *WebsocketManager) reconnectWithRetries() error {
+	var lastErr error
+	for {
+		err := w.reconnect()
+		if err == nil {
+			return nil
 		}
+		if errors.Is(err, errReconnectsExhausted) {
+			if lastErr == nil {
+				lastErr = err
+			}
+			return fmt.Errorf("giving up after %d reconnection attempts: %w", w.maxReconnects, lastErr)
+		}
+		lastErr = err
+		
+		w.mutex.RLock()
+		isRunning := w.isRunning
+		w.mutex.RUnlock()
+		if !isRunning {
+			return nil
+		}
+		
+		log.Printf("WebSocket reconnection attempt failed: %v", err)
 	}
+}
+
+// fail marks the manager as stopped after an unrecoverable error and notifies the owner once
+func (w *WebsocketManager) fail(err error) {
+	w.mutex.Lock()
+	w.isRunning = false
+	onFatal := w.onFatal
+	w.mutex.Unlock()
 	
-	log.Printf("WebSocket reconnected successfully")
-	return nil
+	w.fatalOnce.Do(func() {
+		if onFatal != nil {
+			onFatal(err)
+		}
+	})
+}
+
+// SetOnFatal sets the OnFatal callback on a manager that is already running
+func (w *WebsocketManager) SetOnFatal(callback func(error)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	
+	w.onFatal = callback
 }
 
 // readPump handles incoming WebSocket messages
 func (w *WebsocketManager) readPump() {
+	// conn is the connection being read; Stop and reconnect replace w.conn under the lock
+	var conn *websocket.Conn
 	defer func() {
-		if w.conn != nil {
-			w.conn.Close()
+		if conn != nil {
+			conn.Close()
 		}
 	}()
 	
@@ -185,7 +334,14 @@ func (w *WebsocketManager) readPump() {
 		case <-w.done:
 			return
 		default:
-			_, message, err := w.conn.ReadMessage()
+			w.mutex.RLock()
+			conn = w.conn
+			w.mutex.RUnlock()
+			if conn == nil {
+				return
+			}
+			
+			_, message, err := conn.ReadMessage()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("WebSocket error: %v", err)
@@ -196,12 +352,24 @@ func (w *WebsocketManager) readPump() {
 				isRunning := w.isRunning
 				w.mutex.RUnlock()
 				
-				if isRunning {
-					if err := w.reconnect(); err != nil {
-						log.Printf("Failed to reconnect WebSocket: %v", err)
-						return
+				if !isRunning {
+					return
+				}
+				
+				if w.noReconnect {
+					w.mutex.Lock()
+					w.isRunning = false
+					w.mutex.Unlock()
+					
+					if w.onReadError != nil {
+						w.onReadError(err)
 					}
-				} else {
+					return
+				}
+				
+				if err := w.reconnectWithRetries(); err != nil {
+					log.Printf("Failed to reconnect WebSocket: %v", err)
+					w.fail(err)
 					return
 				}
 				continue
@@ -252,17 +420,33 @@ func (w *WebsocketManager) handleMessage(message []byte) {
 	
 	// Call all matching callbacks
 	w.mutex.RLock()
-	for subKey, callback := range w.subscriptions {
-		var subscription types.Subscription
-		if err := json.Unmarshal([]byte(subKey), &subscription); err == nil {
-			if w.matchesSubscription(subscription, channel, msgData) {
-				go callback(msgData)
+	for subKey, entry := range w.subscriptions {
+		if w.matchesSubscription(entry.subscription, channel, msgData) {
+			// Gap detection runs before dispatch since callbacks may run out of order
+			if detector, exists := w.gapDetectors[subKey]; exists {
+				detector.observe(msgData)
 			}
+			go entry.callback(msgData)
 		}
 	}
 	w.mutex.RUnlock()
 }
 
+// decodeWsData decodes the data field of a WebSocket message into v
+func decodeWsData(msg interface{}, v interface{}) error {
+	msgData, ok := msg.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected message type: %T", msg)
+	}
+	
+	data, err := json.Marshal(msgData["data"])
+	if err != nil {
+		return fmt.Errorf("failed to marshal message data: %w", err)
+	}
+	
+	return json.Unmarshal(data, v)
+}
+
 // matchesSubscription checks if a message matches a subscription
 func (w *WebsocketManager) matchesSubscription(sub types.Subscription, channel string, msgData map[string]interface{}) bool {
 	switch sub.Type {
@@ -294,7 +478,15 @@ func (w *WebsocketManager) matchesSubscription(sub types.Subscription, channel s
 				}
 			}
 		}
-	case "userEvents", "userFills", "orderUpdates", "userFundings", "userNonFundingLedgerUpdates", "webData2":
+	case "userEvents":
+		// userEvents are delivered on the "user" channel without a user field;
+		// only one user can be subscribed per connection
+		return channel == "user" || channel == "userEvents"
+	case "orderUpdates":
+		// orderUpdates data is a list of updates without a user field;
+		// only one user can be subscribed per connection
+		return channel == "orderUpdates"
+	case "userFills", "userFundings", "userNonFundingLedgerUpdates", "webData2":
 		if channel == "user" || channel == sub.Type {
 			if data, ok := msgData["data"].(map[string]interface{}); ok {
 				if user, ok := data["user"].(string); ok {
@@ -303,7 +495,13 @@ func (w *WebsocketManager) matchesSubscription(sub types.Subscription, channel s
 			}
 		}
 	case "candle":
-		return channel == "candle" // Additional filtering may be needed for coin and interval
+		if channel == "candle" {
+			if data, ok := msgData["data"].(map[string]interface{}); ok {
+				coin, _ := data["s"].(string)
+				interval, _ := data["i"].(string)
+				return coin == sub.Coin && interval == sub.Interval
+			}
+		}
 	case "activeAssetCtx":
 		if channel == "activeAssetCtx" {
 			if data, ok := msgData["data"].(map[string]interface{}); ok {
@@ -328,7 +526,24 @@ func (w *WebsocketManager) matchesSubscription(sub types.Subscription, channel s
 }
 
 // Subscribe subscribes to WebSocket channels
+// Subscribe messages are paced by the subscribe delay; the lock is released while waiting
+// so incoming messages keep being dispatched.
 func (w *WebsocketManager) Subscribe(subscriptions []types.Subscription, callback func(interface{})) error {
+	for idx, sub := range subscriptions {
+		if idx > 0 && w.subscribeDelay > 0 {
+			time.Sleep(w.subscribeDelay)
+		}
+		
+		if err := w.subscribe(sub, callback); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// subscribe registers a single subscription and sends its subscribe message
+func (w *WebsocketManager) subscribe(sub types.Subscription, callback func(interface{})) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 	
@@ -336,23 +551,16 @@ func (w *WebsocketManager) Subscribe(subscriptions []types.Subscription, callbac
 		return fmt.Errorf("WebSocket manager is not running")
 	}
 	
-	for _, sub := range subscriptions {
-		// Convert coin names to proper format if needed
-		if sub.Coin != "" {
-			// Handle name to coin conversion if needed
-			sub.Coin = sub.Coin
-		}
-		
-		subKey, err := json.Marshal(sub)
-		if err != nil {
-			return fmt.Errorf("failed to marshal subscription: %w", err)
-		}
-		
-		w.subscriptions[string(subKey)] = callback
-		
-		if err := w.sendSubscription(sub); err != nil {
-			return fmt.Errorf("failed to send subscription: %w", err)
-		}
+	subKey, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	
+	w.subscriptions[string(subKey)] = subscriptionEntry{subscription: sub, callback: callback}
+	w.rebuildSubscriptionList()
+	
+	if err := w.sendSubscription(sub); err != nil {
+		return fmt.Errorf("failed to send subscription: %w", err)
 	}
 	
 	return nil
@@ -374,6 +582,8 @@ func (w *WebsocketManager) Unsubscribe(subscriptions []types.Subscription) error
 		}
 		
 		delete(w.subscriptions, string(subKey))
+		delete(w.gapDetectors, string(subKey))
+		w.rebuildSubscriptionList()
 		
 		if err := w.sendUnsubscription(sub); err != nil {
 			log.Printf("Failed to send unsubscription: %v", err)
@@ -383,6 +593,34 @@ func (w *WebsocketManager) Unsubscribe(subscriptions []types.Subscription) error
 	return nil
 }
 
+// WatchGaps calls onGap when a message for sub carries a time older than the latest one seen,
+// or, if maxJump is positive, more than maxJump newer. Either suggests dropped messages and a
+// need to resync, e.g. by refetching the book. Times are read from the data's time field, or
+// from each element's time for array payloads such as trades. Jumps are normal on quiet
+// channels, so choose maxJump per channel or pass 0 to only detect regressions.
+// The subscription must already be registered with Subscribe.
+func (w *WebsocketManager) WatchGaps(sub types.Subscription, maxJump time.Duration, onGap func(GapEvent)) error {
+	subKey, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	
+	if _, exists := w.subscriptions[string(subKey)]; !exists {
+		return fmt.Errorf("not subscribed to %s", string(subKey))
+	}
+	
+	w.gapDetectors[string(subKey)] = &gapDetector{
+		subscription: sub,
+		maxJump:      maxJump,
+		onGap:        onGap,
+	}
+	
+	return nil
+}
+
 // sendSubscription sends a subscription message
 func (w *WebsocketManager) sendSubscription(sub types.Subscription) error {
 	message := map[string]interface{}{
@@ -416,13 +654,13 @@ func (w *WebsocketManager) GetSubscriptions() []types.Subscription {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 	
-	var subscriptions []types.Subscription
-	for subKey := range w.subscriptions {
-		var sub types.Subscription
-		if err := json.Unmarshal([]byte(subKey), &sub); err == nil {
-			subscriptions = append(subscriptions, sub)
-		}
+	return append([]types.Subscription(nil), w.subscriptionList...)
+}
+
+// rebuildSubscriptionList refreshes the cached subscription list; caller must hold the write lock
+func (w *WebsocketManager) rebuildSubscriptionList() {
+	w.subscriptionList = make([]types.Subscription, 0, len(w.subscriptions))
+	for _, entry := range w.subscriptions {
+		w.subscriptionList = append(w.subscriptionList, entry.subscription)
 	}
-	
-	return subscriptions
 }
\ No newline at end of file
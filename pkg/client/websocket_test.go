@@ -0,0 +1,159 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+func TestDisableReconnectStopsOnReadError(t *testing.T) {
+	server := newMockServer(t)
+	readErrors := make(chan error, 2)
+	manager := newTestWebsocket(t, server, func(w *WebsocketManager) {
+		w.reconnectDelay = time.Millisecond
+	}, DisableReconnect(), OnReadError(func(err error) { readErrors <- err }))
+
+	server.dropWebsockets()
+
+	select {
+	case err := <-readErrors:
+		if err == nil {
+			t.Fatal("OnReadError called with a nil error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the read error")
+	}
+
+	select {
+	case <-server.wsConnected:
+		t.Fatal("manager reconnected with reconnect disabled")
+	case err := <-readErrors:
+		t.Fatalf("OnReadError called again: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if manager.IsConnected() {
+		t.Fatal("manager still reports connected after the read pump stopped")
+	}
+}
+
+func TestFatalHookFiresOnceAfterReconnectsExhausted(t *testing.T) {
+	server := newMockServer(t)
+	fatal := make(chan error, 4)
+	manager := newTestWebsocket(t, server, func(w *WebsocketManager) {
+		w.reconnectDelay = time.Millisecond
+		w.maxReconnects = 2
+	}, OnFatal(func(err error) { fatal <- err }))
+
+	server.refuseWebsockets()
+	server.dropWebsockets()
+
+	select {
+	case err := <-fatal:
+		if err == nil || !strings.Contains(err.Error(), "giving up after 2 reconnection attempts") {
+			t.Fatalf("fatal error = %v, want the exhausted reconnects", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the fatal hook")
+	}
+	if manager.IsConnected() {
+		t.Fatal("manager still reports connected after giving up")
+	}
+
+	// Later failures do not report again
+	manager.fail(errors.New("second failure"))
+	select {
+	case err := <-fatal:
+		t.Fatalf("fatal hook called again: %v", err)
+	case <-server.wsConnected:
+		t.Fatal("manager reconnected after giving up")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServerPingIsAnsweredAndExtendsDeadline(t *testing.T) {
+	server := newMockServer(t)
+	readErrors := make(chan error, 1)
+	newTestWebsocket(t, server, func(w *WebsocketManager) {
+		w.pongTimeout = 300 * time.Millisecond
+	}, DisableReconnect(), OnReadError(func(err error) { readErrors <- err }))
+
+	// Pings every 100ms keep the connection alive well past the 300ms read deadline
+	for idx := 0; idx < 8; idx++ {
+		appData := fmt.Sprintf("ping-%d", idx)
+		server.pingWebsocket(appData)
+
+		select {
+		case pong := <-server.wsPongs:
+			if pong != appData {
+				t.Fatalf("pong payload = %q, want %q", pong, appData)
+			}
+		case err := <-readErrors:
+			t.Fatalf("connection dropped while being pinged: %v", err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the pong")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Without pings or messages the deadline expires
+	select {
+	case err := <-readErrors:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("read error = %v, want a timeout", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read deadline never expired")
+	}
+}
+
+func TestGetSubscriptionsReflectsSubscribeAndUnsubscribe(t *testing.T) {
+	server := newMockServer(t)
+	manager := newTestWebsocket(t, server, nil)
+
+	if subs := manager.GetSubscriptions(); len(subs) != 0 {
+		t.Fatalf("new manager has subscriptions: %v", subs)
+	}
+
+	eth := types.Subscription{Type: "l2Book", Coin: "ETH"}
+	btc := types.Subscription{Type: "trades", Coin: "BTC"}
+	if err := manager.Subscribe([]types.Subscription{eth, btc}, func(interface{}) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	// Subscribing again replaces the callback instead of adding an entry
+	if err := manager.Subscribe([]types.Subscription{eth}, func(interface{}) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	subs := manager.GetSubscriptions()
+	if len(subs) != 2 || !containsSubscription(subs, eth) || !containsSubscription(subs, btc) {
+		t.Fatalf("GetSubscriptions() = %v, want %v and %v", subs, eth, btc)
+	}
+
+	// The returned slice is a copy
+	subs[0] = types.Subscription{Type: "allMids"}
+	if containsSubscription(manager.GetSubscriptions(), types.Subscription{Type: "allMids"}) {
+		t.Fatal("modifying the returned slice changed the registry")
+	}
+
+	if err := manager.Unsubscribe([]types.Subscription{eth}); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if subs := manager.GetSubscriptions(); len(subs) != 1 || subs[0] != btc {
+		t.Fatalf("GetSubscriptions() after unsubscribe = %v, want [%v]", subs, btc)
+	}
+}
+
+func containsSubscription(subs []types.Subscription, sub types.Subscription) bool {
+	for _, s := range subs {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,13 @@
 package types
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // Side represents the side of an order (Buy/Sell)
@@ -60,6 +65,15 @@ func NewCloidFromInt(cloid int64) *Cloid {
 	return &Cloid{rawCloid: fmt.Sprintf("0x%032x", cloid)}
 }
 
+// NewRandomCloid creates a new Cloid from 16 random bytes
+func NewRandomCloid() (*Cloid, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate cloid: %w", err)
+	}
+	return &Cloid{rawCloid: "0x" + hex.EncodeToString(buf)}, nil
+}
+
 // String returns the raw cloid string
 func (c *Cloid) String() string {
 	if c == nil {
@@ -104,10 +118,49 @@ type MarginTier struct {
 	MaxLeverage int    `json:"maxLeverage"`
 }
 
+// AssetKind identifies the market an asset ID belongs to
+type AssetKind string
+
+const (
+	AssetKindPerp    AssetKind = "perp"
+	AssetKindSpot    AssetKind = "spot"
+	AssetKindBuilder AssetKind = "builder"
+)
+
+// UniverseEntry describes one tradable asset: its display name, wire coin, asset ID and size decimals
+type UniverseEntry struct {
+	Name       string    `json:"name"`
+	Coin       string    `json:"coin"`
+	AssetID    int       `json:"assetId"`
+	SzDecimals int       `json:"szDecimals"`
+	Kind       AssetKind `json:"kind"`
+}
+
 // AssetInfo represents metadata about an asset
 type AssetInfo struct {
-	Name       string `json:"name"`
-	SzDecimals int    `json:"szDecimals"`
+	Name          string `json:"name"`
+	SzDecimals    int    `json:"szDecimals"`
+	MaxLeverage   int    `json:"maxLeverage,omitempty"`
+	MarginTableID int    `json:"marginTableId,omitempty"`
+	IsDelisted    bool   `json:"isDelisted,omitempty"`
+}
+
+// MaxLeverageFor returns the max leverage allowed for a position of the given notional
+// Tiers apply from their lower bound upwards; 0 is returned if there are no tiers.
+func (t MarginTable) MaxLeverageFor(notional float64) int {
+	maxLeverage := 0
+	bestLowerBound := -1.0
+	for _, tier := range t.MarginTiers {
+		lowerBound, err := strconv.ParseFloat(tier.LowerBound, 64)
+		if err != nil {
+			continue
+		}
+		if lowerBound <= notional && lowerBound > bestLowerBound {
+			bestLowerBound = lowerBound
+			maxLeverage = tier.MaxLeverage
+		}
+	}
+	return maxLeverage
 }
 
 type MarginTable struct {
@@ -214,6 +267,24 @@ type OrderRequest struct {
 	Cloid      *Cloid    `json:"cloid,omitempty"`
 }
 
+// Notional returns the order notional value (size * limit price)
+func (o OrderRequest) Notional() float64 {
+	notional := o.Sz * o.LimitPx
+	if notional < 0 {
+		return -notional
+	}
+	return notional
+}
+
+// RequiredMargin returns the margin needed to open the order at the given leverage
+// Leverage below 1 is treated as 1x so the result never understates the requirement
+func (o OrderRequest) RequiredMargin(leverage int) float64 {
+	if leverage < 1 {
+		leverage = 1
+	}
+	return o.Notional() / float64(leverage)
+}
+
 // OrderWire represents the wire format of an order
 type OrderWire struct {
 	A int           `json:"a" msgpack:"a"`                     // asset
@@ -243,8 +314,8 @@ type ModifyRequest struct {
 
 // ModifyWire represents the wire format of a modify request
 type ModifyWire struct {
-	Oid   int       `json:"oid"`
-	Order OrderWire `json:"order"`
+	Oid   int       `json:"oid" msgpack:"oid"`
+	Order OrderWire `json:"order" msgpack:"order"`
 }
 
 // CancelRequest represents a request to cancel an order
@@ -259,26 +330,175 @@ type CancelByCloidRequest struct {
 	Cloid *Cloid `json:"cloid"`
 }
 
-// CrossLeverage represents cross leverage
+// Leverage types
+const (
+	LeverageTypeCross    = "cross"
+	LeverageTypeIsolated = "isolated"
+)
+
+// CrossLeverage represents cross leverage; see Leverage.AsCross
 type CrossLeverage struct {
 	Type  string `json:"type"`
 	Value int    `json:"value"`
 }
 
-// IsolatedLeverage represents isolated leverage
+// IsolatedLeverage represents isolated leverage; see Leverage.AsIsolated
 type IsolatedLeverage struct {
 	Type   string `json:"type"`
 	Value  int    `json:"value"`
 	RawUsd string `json:"rawUsd"`
 }
 
-// Leverage represents leverage (cross or isolated)
+// Leverage represents leverage as returned by the API, either cross or isolated
+// RawUsd is only set for isolated leverage; use AsCross or AsIsolated for the specific shape.
 type Leverage struct {
 	Type   string `json:"type"`
 	Value  int    `json:"value"`
 	RawUsd string `json:"rawUsd,omitempty"`
 }
 
+// UnmarshalJSON decodes either leverage shape, keeping rawUsd only for isolated leverage
+func (l *Leverage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type   string          `json:"type"`
+		Value  int             `json:"value"`
+		RawUsd json.RawMessage `json:"rawUsd"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*l = Leverage{Type: raw.Type, Value: raw.Value}
+	if raw.Type != LeverageTypeIsolated || len(raw.RawUsd) == 0 || string(raw.RawUsd) == "null" {
+		return nil
+	}
+
+	// rawUsd is a decimal string, but accept a bare number as well
+	if err := json.Unmarshal(raw.RawUsd, &l.RawUsd); err != nil {
+		var number json.Number
+		if numErr := json.Unmarshal(raw.RawUsd, &number); numErr != nil {
+			return fmt.Errorf("invalid rawUsd %s: %w", raw.RawUsd, err)
+		}
+		l.RawUsd = number.String()
+	}
+	return nil
+}
+
+// IsCross returns true for cross leverage
+func (l Leverage) IsCross() bool {
+	return l.Type == LeverageTypeCross
+}
+
+// IsIsolated returns true for isolated leverage
+func (l Leverage) IsIsolated() bool {
+	return l.Type == LeverageTypeIsolated
+}
+
+// AsCross returns the leverage as CrossLeverage if it is cross
+func (l Leverage) AsCross() (CrossLeverage, bool) {
+	if !l.IsCross() {
+		return CrossLeverage{}, false
+	}
+	return CrossLeverage{Type: l.Type, Value: l.Value}, true
+}
+
+// AsIsolated returns the leverage as IsolatedLeverage if it is isolated
+func (l Leverage) AsIsolated() (IsolatedLeverage, bool) {
+	if !l.IsIsolated() {
+		return IsolatedLeverage{}, false
+	}
+	return IsolatedLeverage{Type: l.Type, Value: l.Value, RawUsd: l.RawUsd}, true
+}
+
+// CumFunding represents cumulative funding for a position
+type CumFunding struct {
+	AllTime     string `json:"allTime"`
+	SinceChange string `json:"sinceChange"`
+	SinceOpen   string `json:"sinceOpen"`
+}
+
+// Position represents an open perpetual position
+type Position struct {
+	Coin           string     `json:"coin"`
+	Szi            string     `json:"szi"`
+	EntryPx        *string    `json:"entryPx,omitempty"`
+	PositionValue  string     `json:"positionValue"`
+	UnrealizedPnl  string     `json:"unrealizedPnl"`
+	ReturnOnEquity string     `json:"returnOnEquity"`
+	Leverage       Leverage   `json:"leverage"`
+	LiquidationPx  *string    `json:"liquidationPx,omitempty"`
+	MarginUsed     string     `json:"marginUsed"`
+	MaxLeverage    int        `json:"maxLeverage"`
+	CumFunding     CumFunding `json:"cumFunding"`
+}
+
+// AssetPosition represents a position entry in the clearinghouse state
+type AssetPosition struct {
+	Type     string   `json:"type"`
+	Position Position `json:"position"`
+}
+
+// MarginSummary represents an account's margin totals
+type MarginSummary struct {
+	AccountValue    string `json:"accountValue"`
+	TotalNtlPos     string `json:"totalNtlPos"`
+	TotalRawUsd     string `json:"totalRawUsd"`
+	TotalMarginUsed string `json:"totalMarginUsed"`
+}
+
+// AccountValueFloat returns the account value as a float64; a missing value is zero
+func (m MarginSummary) AccountValueFloat() (float64, error) {
+	return parseSummaryField("accountValue", m.AccountValue)
+}
+
+// TotalNtlPosFloat returns the total position notional as a float64; a missing value is zero
+func (m MarginSummary) TotalNtlPosFloat() (float64, error) {
+	return parseSummaryField("totalNtlPos", m.TotalNtlPos)
+}
+
+// TotalRawUsdFloat returns the raw USD balance as a float64; a missing value is zero
+func (m MarginSummary) TotalRawUsdFloat() (float64, error) {
+	return parseSummaryField("totalRawUsd", m.TotalRawUsd)
+}
+
+// TotalMarginUsedFloat returns the total margin used as a float64; a missing value is zero
+func (m MarginSummary) TotalMarginUsedFloat() (float64, error) {
+	return parseSummaryField("totalMarginUsed", m.TotalMarginUsed)
+}
+
+// parseSummaryField parses a margin summary value, treating an absent summary as zero
+func parseSummaryField(name string, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return parsed, nil
+}
+
+// ClearinghouseState represents a user's perpetuals account state
+// Margin summaries may be absent for new or spot-only accounts and are then zero-valued.
+type ClearinghouseState struct {
+	AssetPositions             []AssetPosition `json:"assetPositions"`
+	MarginSummary              MarginSummary   `json:"marginSummary"`
+	CrossMarginSummary         MarginSummary   `json:"crossMarginSummary"`
+	CrossMaintenanceMarginUsed string          `json:"crossMaintenanceMarginUsed"`
+	Withdrawable               string          `json:"withdrawable"`
+	Time                       int64           `json:"time"`
+}
+
+// HasEquity returns true if the account has a positive perp account value
+// A missing or unparseable marginSummary counts as no equity.
+func (s *ClearinghouseState) HasEquity() bool {
+	if s == nil || s.MarginSummary.AccountValue == "" {
+		return false
+	}
+	accountValue, err := strconv.ParseFloat(s.MarginSummary.AccountValue, 64)
+	return err == nil && accountValue > 0
+}
+
 // L2Level represents a level 2 order book entry
 type L2Level struct {
 	Px string `json:"px"`
@@ -298,32 +518,67 @@ type Trade struct {
 
 // Fill represents a fill
 type Fill struct {
-	Coin          string `json:"coin"`
-	Px            string `json:"px"`
-	Sz            string `json:"sz"`
-	Side          Side   `json:"side"`
-	Time          int64  `json:"time"`
-	StartPosition string `json:"startPosition"`
-	Dir           string `json:"dir"`
-	ClosedPnl     string `json:"closedPnl"`
-	Hash          string `json:"hash"`
-	Oid           int    `json:"oid"`
-	Crossed       bool   `json:"crossed"`
-	Fee           string `json:"fee"`
-	Tid           int    `json:"tid"`
-	FeeToken      string `json:"feeToken"`
+	Coin          string           `json:"coin"`
+	Px            string           `json:"px"`
+	Sz            string           `json:"sz"`
+	Side          Side             `json:"side"`
+	Time          int64            `json:"time"`
+	StartPosition string           `json:"startPosition"`
+	Dir           string           `json:"dir"`
+	ClosedPnl     string           `json:"closedPnl"`
+	Hash          string           `json:"hash"`
+	Oid           int              `json:"oid"`
+	Crossed       bool             `json:"crossed"`
+	Fee           string           `json:"fee"`
+	Tid           int              `json:"tid"`
+	FeeToken      string           `json:"feeToken"`
+	Cloid         *string          `json:"cloid,omitempty"`
+	Liquidation   *FillLiquidation `json:"liquidation,omitempty"`
+}
+
+// FillLiquidation describes the liquidation a fill was part of
+type FillLiquidation struct {
+	LiquidatedUser *string `json:"liquidatedUser,omitempty"`
+	MarkPx         string  `json:"markPx"`
+	Method         string  `json:"method"` // "market" or "backstop"
+}
+
+// IsLiquidation returns true if the fill was part of a liquidation, either of this
+// account or one it took over as liquidator
+func (f Fill) IsLiquidation() bool {
+	return f.Liquidation != nil || strings.Contains(f.Dir, "Liquidation")
+}
+
+// Candle represents a candlestick
+type Candle struct {
+	OpenTime  int64  `json:"t"`
+	CloseTime int64  `json:"T"`
+	Coin      string `json:"s"`
+	Interval  string `json:"i"`
+	Open      string `json:"o"`
+	Close     string `json:"c"`
+	High      string `json:"h"`
+	Low       string `json:"l"`
+	Volume    string `json:"v"`
+	NumTrades int    `json:"n"`
+}
+
+// CandleMsg represents a candle message
+type CandleMsg struct {
+	Channel string `json:"channel"`
+	Data    Candle `json:"data"`
 }
 
 // BuilderInfo represents builder information
 type BuilderInfo struct {
-	B string `json:"b"` // Public address of the builder
-	F int    `json:"f"` // Amount of fee in tenths of basis points
+	B string `json:"b" msgpack:"b"` // Public address of the builder
+	F int    `json:"f" msgpack:"f"` // Amount of fee in tenths of basis points
 }
 
 // ScheduleCancelAction represents a schedule cancel action
 type ScheduleCancelAction struct {
-	Type string `json:"type"`
-	Time *int64 `json:"time,omitempty"`
+	Type string `json:"type" msgpack:"type"`
+	Time *int64 `json:"time,omitempty" msgpack:"time,omitempty"`
 }
 
 // ActiveAssetCtx represents active asset context
@@ -399,9 +654,20 @@ type TradesMsg struct {
 	Data    []Trade `json:"data"`
 }
 
+// Liquidation represents a liquidation event from the userEvents stream
+type Liquidation struct {
+	Lid                    int64  `json:"lid"`
+	Liquidator             string `json:"liquidator"`
+	LiquidatedUser         string `json:"liquidated_user"`
+	LiquidatedNtlPos       string `json:"liquidated_ntl_pos"`
+	LiquidatedAccountValue string `json:"liquidated_account_value"`
+}
+
 // UserEventsData represents user events data
+// Each message carries one kind of event; Liquidation is set for liquidation events.
 type UserEventsData struct {
-	Fills []Fill `json:"fills,omitempty"`
+	Fills       []Fill       `json:"fills,omitempty"`
+	Liquidation *Liquidation `json:"liquidation,omitempty"`
 }
 
 // UserEventsMsg represents a user events message
@@ -417,6 +683,43 @@ type UserFillsData struct {
 	Fills      []Fill `json:"fills"`
 }
 
+// UserFunding represents a funding payment delivered on the userFundings stream
+type UserFunding struct {
+	Time        int64  `json:"time"`
+	Coin        string `json:"coin"`
+	Usdc        string `json:"usdc"` // negative when funding was paid
+	Szi         string `json:"szi"`
+	FundingRate string `json:"fundingRate"`
+}
+
+// UserFundingsData represents user fundings data
+type UserFundingsData struct {
+	User       string        `json:"user"`
+	IsSnapshot bool          `json:"isSnapshot"`
+	Fundings   []UserFunding `json:"fundings"`
+}
+
+// LedgerUpdate represents a non-funding ledger update such as a deposit, withdrawal or transfer
+// The delta's fields depend on its type, e.g. "deposit", "withdraw" or "accountClassTransfer".
+type LedgerUpdate struct {
+	Time  int64                  `json:"time"`
+	Hash  string                 `json:"hash"`
+	Delta map[string]interface{} `json:"delta"`
+}
+
+// DeltaType returns the kind of ledger update
+func (l LedgerUpdate) DeltaType() string {
+	deltaType, _ := l.Delta["type"].(string)
+	return deltaType
+}
+
+// UserLedgerUpdatesData represents user non-funding ledger updates data
+type UserLedgerUpdatesData struct {
+	User                    string         `json:"user"`
+	IsSnapshot              bool           `json:"isSnapshot"`
+	NonFundingLedgerUpdates []LedgerUpdate `json:"nonFundingLedgerUpdates"`
+}
+
 // UserFillsMsg represents a user fills message
 type UserFillsMsg struct {
 	Channel string        `json:"channel"`
@@ -458,3 +761,430 @@ type PerpDexSchemaInput struct {
 	CollateralToken int     `json:"collateralToken"`
 	OracleUpdater   *string `json:"oracleUpdater,omitempty"`
 }
+
+// TransferResult represents the outcome of a transfer action
+type TransferResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RestingOrderStatus represents an order that was placed on the book
+type RestingOrderStatus struct {
+	Oid   int     `json:"oid"`
+	Cloid *string `json:"cloid,omitempty"`
+}
+
+// FilledOrderStatus represents an order that was filled immediately
+type FilledOrderStatus struct {
+	TotalSz string  `json:"totalSz"`
+	AvgPx   string  `json:"avgPx"`
+	Oid     int     `json:"oid"`
+	Cloid   *string `json:"cloid,omitempty"`
+}
+
+// Cloid returns the client order ID echoed back for a resting or filled order, or nil
+func (s OrderStatus) Cloid() *string {
+	if s.Resting != nil {
+		return s.Resting.Cloid
+	}
+	if s.Filled != nil {
+		return s.Filled.Cloid
+	}
+	return nil
+}
+
+// AvgPxFloat returns the average fill price as a float64
+func (f *FilledOrderStatus) AvgPxFloat() (float64, error) {
+	avgPx, err := strconv.ParseFloat(f.AvgPx, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid avgPx %q: %w", f.AvgPx, err)
+	}
+	return avgPx, nil
+}
+
+// TotalSzFloat returns the total filled size as a float64
+func (f *FilledOrderStatus) TotalSzFloat() (float64, error) {
+	totalSz, err := strconv.ParseFloat(f.TotalSz, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid totalSz %q: %w", f.TotalSz, err)
+	}
+	return totalSz, nil
+}
+
+// OrderStatus represents the status of a single order in an order response
+type OrderStatus struct {
+	Resting *RestingOrderStatus `json:"resting,omitempty"`
+	Filled  *FilledOrderStatus  `json:"filled,omitempty"`
+	Error   *string             `json:"error,omitempty"`
+}
+
+// OrderResponseData represents the data of an order response
+type OrderResponseData struct {
+	Statuses []OrderStatus `json:"statuses"`
+}
+
+// OrderResponseBody represents the body of an order response
+type OrderResponseBody struct {
+	Type string            `json:"type"`
+	Data OrderResponseData `json:"data"`
+}
+
+// OrderResponse represents the typed response of an order action
+type OrderResponse struct {
+	Status   string            `json:"status"`
+	Response OrderResponseBody `json:"response"`
+}
+
+// VaultFollower represents a depositor in a vault
+type VaultFollower struct {
+	User          string `json:"user"`
+	VaultEquity   string `json:"vaultEquity"`
+	Pnl           string `json:"pnl"`
+	AllTimePnl    string `json:"allTimePnl"`
+	DaysFollowing int    `json:"daysFollowing"`
+	LockupUntil   int64  `json:"lockupUntil"`
+}
+
+// ExplorerTx represents an L1 transaction as returned by the explorer endpoint
+type ExplorerTx struct {
+	Action map[string]interface{} `json:"action"`
+	Block  int64                  `json:"block"`
+	Error  *string                `json:"error"`
+	Hash   string                 `json:"hash"`
+	Time   int64                  `json:"time"`
+	User   string                 `json:"user"`
+}
+
+// Succeeded returns true if the transaction was applied without error
+func (t ExplorerTx) Succeeded() bool {
+	return t.Error == nil
+}
+
+// ExplorerBlock represents an L1 block as returned by the explorer endpoint
+type ExplorerBlock struct {
+	Height    int64        `json:"height"`
+	BlockTime int64        `json:"blockTime"`
+	Hash      string       `json:"hash"`
+	Proposer  string       `json:"proposer"`
+	NumTxs    int          `json:"numTxs"`
+	Txs       []ExplorerTx `json:"txs"`
+}
+
+// DelegateDelta represents a delegation or undelegation to a validator
+type DelegateDelta struct {
+	Validator    string `json:"validator"`
+	Amount       string `json:"amount"`
+	IsUndelegate bool   `json:"isUndelegate"`
+}
+
+// StakingTransferDelta represents a deposit to or withdrawal from staking balance
+type StakingTransferDelta struct {
+	Amount string `json:"amount"`
+	Phase  string `json:"phase,omitempty"`
+}
+
+// DelegatorDelta represents the change recorded by a staking ledger entry
+// Exactly one field is set
+type DelegatorDelta struct {
+	Delegate   *DelegateDelta        `json:"delegate,omitempty"`
+	CDeposit   *StakingTransferDelta `json:"cDeposit,omitempty"`
+	Withdrawal *StakingTransferDelta `json:"withdrawal,omitempty"`
+}
+
+// DelegatorLedgerEntry represents an entry in a user's staking history
+type DelegatorLedgerEntry struct {
+	Time  int64          `json:"time"`
+	Hash  string         `json:"hash"`
+	Delta DelegatorDelta `json:"delta"`
+}
+
+// SpotBalance represents a user's balance of a spot token
+type SpotBalance struct {
+	Coin     string `json:"coin"`
+	Token    int    `json:"token"`
+	Hold     string `json:"hold"`
+	Total    string `json:"total"`
+	EntryNtl string `json:"entryNtl"`
+}
+
+// SpotClearinghouseState represents a user's spot account state
+type SpotClearinghouseState struct {
+	Balances []SpotBalance `json:"balances"`
+}
+
+// OpenOrder represents a resting order
+type OpenOrder struct {
+	Coin      string  `json:"coin"`
+	LimitPx   string  `json:"limitPx"`
+	Oid       int     `json:"oid"`
+	Side      Side    `json:"side"`
+	Sz        string  `json:"sz"`
+	OrigSz    string  `json:"origSz"`
+	Timestamp int64   `json:"timestamp"`
+	Cloid     *string `json:"cloid,omitempty"`
+}
+
+// OrderUpdate represents an order status change from the orderUpdates subscription
+// Status is e.g. "open", "filled", "canceled", "triggered", "rejected" or "marginCanceled".
+type OrderUpdate struct {
+	Order           OpenOrder `json:"order"`
+	Status          string    `json:"status"`
+	StatusTimestamp int64     `json:"statusTimestamp"`
+}
+
+// TriggerCondition describes when a trigger order fires, e.g. "Price above 3000"
+type TriggerCondition string
+
+// TriggerConditionNone is reported for orders without a trigger
+const TriggerConditionNone TriggerCondition = "N/A"
+
+// IsNone returns true if the order has no trigger condition
+func (c TriggerCondition) IsNone() bool {
+	return c == "" || c == TriggerConditionNone
+}
+
+// FrontendOpenOrder represents a resting order with frontend details such as tif and trigger
+type FrontendOpenOrder struct {
+	OpenOrder
+	OrderType        string           `json:"orderType"`
+	Tif              *Tif             `json:"tif,omitempty"`
+	ReduceOnly       bool             `json:"reduceOnly"`
+	IsTrigger        bool             `json:"isTrigger"`
+	IsPositionTpsl   bool             `json:"isPositionTpsl"`
+	TriggerPx        string           `json:"triggerPx"`
+	TriggerCondition TriggerCondition `json:"triggerCondition"`
+	Tpsl             *Tpsl            `json:"-"` // derived from OrderType for trigger orders
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, deriving Tpsl from the order type
+func (o *FrontendOpenOrder) UnmarshalJSON(data []byte) error {
+	type frontendOpenOrder FrontendOpenOrder
+	var decoded frontendOpenOrder
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*o = FrontendOpenOrder(decoded)
+
+	switch {
+	case strings.HasPrefix(o.OrderType, "Take Profit"):
+		tpsl := TpslTp
+		o.Tpsl = &tpsl
+	case strings.HasPrefix(o.OrderType, "Stop"):
+		tpsl := TpslSl
+		o.Tpsl = &tpsl
+	}
+
+	return nil
+}
+
+// OrderHistoryEntry represents an order together with its latest status
+// Status is e.g. "open", "filled", "canceled", "triggered", "rejected" or "marginCanceled".
+type OrderHistoryEntry struct {
+	Order           FrontendOpenOrder `json:"order"`
+	Status          string            `json:"status"`
+	StatusTimestamp int64             `json:"statusTimestamp"`
+}
+
+// OrderStatusResult represents an orderStatus response
+// Status is "order" when the order was found, or "unknownOid" when it wasn't.
+type OrderStatusResult struct {
+	Status string             `json:"status"`
+	Order  *OrderHistoryEntry `json:"order,omitempty"`
+}
+
+// Found returns true if the queried order exists
+func (r *OrderStatusResult) Found() bool {
+	return r.Status == "order" && r.Order != nil
+}
+
+// AccountSnapshot bundles a user's perp state, spot balances, open orders and recent fills
+type AccountSnapshot struct {
+	User       string                  `json:"user"`
+	State      *ClearinghouseState     `json:"state"`
+	SpotState  *SpotClearinghouseState `json:"spotState"`
+	OpenOrders []OpenOrder             `json:"openOrders"`
+	Fills      []Fill                  `json:"fills"`
+}
+
+// ReplaceResult holds the responses of the steps of a cancel-and-replace
+// Rollback is set only if the new order failed and the original was placed again.
+type ReplaceResult struct {
+	Cancel   map[string]interface{}
+	Order    map[string]interface{}
+	Rollback map[string]interface{}
+}
+
+// UserSnapshot bundles a user's perp state, open orders and fills fetched together
+type UserSnapshot struct {
+	User       string              `json:"user"`
+	State      *ClearinghouseState `json:"state"`
+	OpenOrders []OpenOrder         `json:"openOrders"`
+	Fills      []Fill              `json:"fills"`
+}
+
+// ExtraAgent represents an agent approved to trade on behalf of an account
+type ExtraAgent struct {
+	Name       string `json:"name"`
+	Address    string `json:"address"`
+	ValidUntil int64  `json:"validUntil"`
+}
+
+// UserFees represents a user's current fee rates
+type UserFees struct {
+	UserCrossRate          string `json:"userCrossRate"`
+	UserAddRate            string `json:"userAddRate"`
+	UserSpotCrossRate      string `json:"userSpotCrossRate"`
+	UserSpotAddRate        string `json:"userSpotAddRate"`
+	ActiveReferralDiscount string `json:"activeReferralDiscount"`
+}
+
+// FundingDelta represents a single funding payment on a position
+type FundingDelta struct {
+	Coin        string `json:"coin"`
+	FundingRate string `json:"fundingRate"`
+	Szi         string `json:"szi"`
+	Usdc        string `json:"usdc"` // negative when funding was paid
+	NSamples    *int   `json:"nSamples,omitempty"`
+}
+
+// UserFundingEntry represents an entry in a user's funding history
+type UserFundingEntry struct {
+	Time  int64        `json:"time"`
+	Hash  string       `json:"hash"`
+	Delta FundingDelta `json:"delta"`
+}
+
+// TwapState represents a TWAP order and its execution progress
+type TwapState struct {
+	TwapID      int64  `json:"twapId"`
+	Coin        string `json:"coin"`
+	Side        string `json:"side"`
+	TotalSz     string `json:"sz"`
+	ExecutedSz  string `json:"executedSz"`
+	ExecutedNtl string `json:"executedNtl"`
+	Minutes     int    `json:"minutes"`
+	ReduceOnly  bool   `json:"reduceOnly"`
+	Randomize   bool   `json:"randomize"`
+	Timestamp   int64  `json:"timestamp"`
+	Status      string `json:"status"`
+}
+
+// ReferredBy identifies the referrer of an account
+type ReferredBy struct {
+	Referrer string `json:"referrer"`
+	Code     string `json:"code"`
+}
+
+// ReferralState represents a user's referral status and rewards
+type ReferralState struct {
+	ReferredBy       *ReferredBy `json:"referredBy,omitempty"`
+	CumVlm           string      `json:"cumVlm"`
+	UnclaimedRewards string      `json:"unclaimedRewards"`
+	ClaimedRewards   string      `json:"claimedRewards"`
+	BuilderRewards   string      `json:"builderRewards"`
+}
+
+// UnclaimedRewardsFloat returns the claimable referral rewards as a float64; a missing value is zero
+func (r ReferralState) UnclaimedRewardsFloat() (float64, error) {
+	return parseSummaryField("unclaimedRewards", r.UnclaimedRewards)
+}
+
+// ClaimedRewardsFloat returns the referral rewards claimed so far as a float64; a missing value is zero
+func (r ReferralState) ClaimedRewardsFloat() (float64, error) {
+	return parseSummaryField("claimedRewards", r.ClaimedRewards)
+}
+
+// OrderBook maintains a local copy of an L2 order book fed by l2Book messages
+// l2Book messages are full snapshots, so each applied update replaces both sides.
+// It is safe for concurrent use.
+type OrderBook struct {
+	mutex sync.RWMutex
+	coin  string
+	bids  []L2Level
+	asks  []L2Level
+	time  int64
+}
+
+// NewOrderBook creates an empty order book for a coin
+func NewOrderBook(coin string) *OrderBook {
+	return &OrderBook{coin: coin}
+}
+
+// ApplyL2BookData replaces the book with a snapshot
+// Snapshots for another coin or older than the current book are ignored; returns whether it was applied
+func (b *OrderBook) ApplyL2BookData(data L2BookData) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if data.Coin != b.coin || data.Time < b.time {
+		return false
+	}
+
+	b.bids = append([]L2Level(nil), data.Levels[0]...)
+	b.asks = append([]L2Level(nil), data.Levels[1]...)
+	b.time = data.Time
+	return true
+}
+
+// Levels returns copies of the bid and ask levels, best first
+func (b *OrderBook) Levels() ([]L2Level, []L2Level) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return append([]L2Level(nil), b.bids...), append([]L2Level(nil), b.asks...)
+}
+
+// Time returns the time of the last applied snapshot
+func (b *OrderBook) Time() int64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.time
+}
+
+// BestBid returns the best bid price and size; ok is false if there are no bids
+func (b *OrderBook) BestBid() (px float64, sz float64, ok bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return topOfBook(b.bids)
+}
+
+// BestAsk returns the best ask price and size; ok is false if there are no asks
+func (b *OrderBook) BestAsk() (px float64, sz float64, ok bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return topOfBook(b.asks)
+}
+
+// Mid returns the midpoint of the best bid and ask; ok is false if either side is empty
+// Both sides are read under one lock so the mid never mixes two snapshots.
+func (b *OrderBook) Mid() (float64, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	bidPx, _, bidOk := topOfBook(b.bids)
+	askPx, _, askOk := topOfBook(b.asks)
+	if !bidOk || !askOk {
+		return 0, false
+	}
+	return (bidPx + askPx) / 2, true
+}
+
+// topOfBook parses the first level of one side of the book
+func topOfBook(levels []L2Level) (float64, float64, bool) {
+	if len(levels) == 0 {
+		return 0, 0, false
+	}
+
+	px, err := strconv.ParseFloat(levels[0].Px, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	sz, err := strconv.ParseFloat(levels[0].Sz, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return px, sz, true
+}
@@ -0,0 +1,561 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestFilledOrderStatusParsing(t *testing.T) {
+	var response OrderResponse
+	body := `{"status":"ok","response":{"type":"order","data":{"statuses":[{"filled":{"totalSz":"0.02","avgPx":"1891.4","oid":77738308}}]}}}`
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	statuses := response.Response.Data.Statuses
+	if len(statuses) != 1 || statuses[0].Filled == nil {
+		t.Fatalf("expected one filled status, got %+v", statuses)
+	}
+	filled := statuses[0].Filled
+
+	avgPx, err := filled.AvgPxFloat()
+	if err != nil || avgPx != 1891.4 {
+		t.Fatalf("AvgPxFloat() = %v, %v; want 1891.4", avgPx, err)
+	}
+	totalSz, err := filled.TotalSzFloat()
+	if err != nil || totalSz != 0.02 {
+		t.Fatalf("TotalSzFloat() = %v, %v; want 0.02", totalSz, err)
+	}
+	if filled.Oid != 77738308 {
+		t.Fatalf("Oid = %d, want 77738308", filled.Oid)
+	}
+}
+
+func TestFilledOrderStatusInvalidNumbers(t *testing.T) {
+	filled := FilledOrderStatus{TotalSz: "", AvgPx: "n/a"}
+
+	if _, err := filled.AvgPxFloat(); err == nil {
+		t.Error("expected an error for an invalid avgPx")
+	}
+	if _, err := filled.TotalSzFloat(); err == nil {
+		t.Error("expected an error for an empty totalSz")
+	}
+}
+
+func TestOrderRequestNotionalAndRequiredMargin(t *testing.T) {
+	order := OrderRequest{Coin: "ETH", IsBuy: true, Sz: 2.5, LimitPx: 2000}
+
+	if got := order.Notional(); got != 5000 {
+		t.Fatalf("Notional() = %v, want 5000", got)
+	}
+	if got := order.RequiredMargin(10); got != 500 {
+		t.Fatalf("RequiredMargin(10) = %v, want 500", got)
+	}
+
+	// Zero or negative leverage is treated as 1x rather than dividing by zero
+	for _, leverage := range []int{0, -5} {
+		if got := order.RequiredMargin(leverage); got != 5000 {
+			t.Fatalf("RequiredMargin(%d) = %v, want 5000", leverage, got)
+		}
+	}
+
+	negative := OrderRequest{Sz: -2, LimitPx: 100}
+	if got := negative.Notional(); got != 200 {
+		t.Fatalf("Notional() of a negative size = %v, want 200", got)
+	}
+}
+
+func bookData(coin string, time int64, bids, asks [][2]string) L2BookData {
+	data := L2BookData{Coin: coin, Time: time}
+	for _, level := range bids {
+		data.Levels[0] = append(data.Levels[0], L2Level{Px: level[0], Sz: level[1], N: 1})
+	}
+	for _, level := range asks {
+		data.Levels[1] = append(data.Levels[1], L2Level{Px: level[0], Sz: level[1], N: 1})
+	}
+	return data
+}
+
+func TestOrderBookAppliesSnapshots(t *testing.T) {
+	book := NewOrderBook("ETH")
+	if _, ok := book.Mid(); ok {
+		t.Fatal("empty book has a mid")
+	}
+
+	updates := []struct {
+		data    L2BookData
+		applied bool
+		bid     float64
+		ask     float64
+	}{
+		{data: bookData("ETH", 100, [][2]string{{"1999.5", "2"}, {"1999", "5"}}, [][2]string{{"2000.5", "1"}}), applied: true, bid: 1999.5, ask: 2000.5},
+		{data: bookData("ETH", 200, [][2]string{{"2000", "1.5"}}, [][2]string{{"2001", "3"}, {"2002", "4"}}), applied: true, bid: 2000, ask: 2001},
+		// Older snapshots and other coins are ignored
+		{data: bookData("ETH", 150, [][2]string{{"1900", "1"}}, [][2]string{{"1901", "1"}}), applied: false, bid: 2000, ask: 2001},
+		{data: bookData("BTC", 300, [][2]string{{"60000", "1"}}, [][2]string{{"60001", "1"}}), applied: false, bid: 2000, ask: 2001},
+		{data: bookData("ETH", 300, [][2]string{{"2003", "0.5"}}, [][2]string{{"2004", "0.25"}}), applied: true, bid: 2003, ask: 2004},
+	}
+
+	for idx, update := range updates {
+		if applied := book.ApplyL2BookData(update.data); applied != update.applied {
+			t.Fatalf("update %d: applied = %v, want %v", idx, applied, update.applied)
+		}
+		bid, _, bidOk := book.BestBid()
+		ask, _, askOk := book.BestAsk()
+		if !bidOk || !askOk || bid != update.bid || ask != update.ask {
+			t.Fatalf("update %d: top of book = %v/%v, want %v/%v", idx, bid, ask, update.bid, update.ask)
+		}
+	}
+
+	if _, sz, _ := book.BestAsk(); sz != 0.25 {
+		t.Fatalf("best ask size = %v, want 0.25", sz)
+	}
+	if mid, ok := book.Mid(); !ok || mid != 2003.5 {
+		t.Fatalf("Mid() = %v, %v; want 2003.5", mid, ok)
+	}
+	if book.Time() != 300 {
+		t.Fatalf("Time() = %d, want 300", book.Time())
+	}
+
+	// A one-sided snapshot empties the other side
+	book.ApplyL2BookData(bookData("ETH", 400, [][2]string{{"2005", "1"}}, nil))
+	if _, _, ok := book.BestAsk(); ok {
+		t.Fatal("asks remain after a snapshot without asks")
+	}
+	if _, ok := book.Mid(); ok {
+		t.Fatal("one-sided book has a mid")
+	}
+}
+
+func TestOrderBookMidReadsOneSnapshot(t *testing.T) {
+	book := NewOrderBook("ETH")
+	low := func(time int64) L2BookData {
+		return bookData("ETH", time, [][2]string{{"100", "1"}}, [][2]string{{"101", "1"}})
+	}
+	high := func(time int64) L2BookData {
+		return bookData("ETH", time, [][2]string{{"200", "1"}}, [][2]string{{"201", "1"}})
+	}
+	book.ApplyL2BookData(low(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for time := int64(2); time < 2000; time++ {
+			if time%2 == 0 {
+				book.ApplyL2BookData(high(time))
+			} else {
+				book.ApplyL2BookData(low(time))
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if mid, ok := book.Mid(); !ok || (mid != 100.5 && mid != 200.5) {
+			t.Fatalf("Mid() = %v, %v; want the mid of a single snapshot", mid, ok)
+		}
+	}
+}
+
+func TestFrontendOpenOrderDecodesTifAndTpsl(t *testing.T) {
+	body := `[
+		{"coin":"ETH","side":"B","limitPx":"2000","sz":"0.1","origSz":"0.1","oid":1,"timestamp":1,"orderType":"Limit","tif":"Alo","reduceOnly":false,"isTrigger":false,"isPositionTpsl":false,"triggerPx":"0.0","triggerCondition":"N/A"},
+		{"coin":"ETH","side":"A","limitPx":"2100","sz":"0.1","origSz":"0.1","oid":2,"timestamp":2,"orderType":"Limit","tif":"Ioc","reduceOnly":false,"isTrigger":false,"isPositionTpsl":false,"triggerPx":"0.0","triggerCondition":"N/A"},
+		{"coin":"ETH","side":"B","limitPx":"1900","sz":"0.1","origSz":"0.1","oid":3,"timestamp":3,"orderType":"Limit","tif":"Gtc","reduceOnly":false,"isTrigger":false,"isPositionTpsl":false,"triggerPx":"0.0","triggerCondition":"N/A"},
+		{"coin":"BTC","side":"A","limitPx":"65000","sz":"0.01","origSz":"0.01","oid":4,"timestamp":4,"orderType":"Take Profit Market","tif":null,"reduceOnly":true,"isTrigger":true,"isPositionTpsl":true,"triggerPx":"65000","triggerCondition":"Price above 65000"},
+		{"coin":"BTC","side":"A","limitPx":"55000","sz":"0.01","origSz":"0.01","oid":5,"timestamp":5,"orderType":"Stop Limit","reduceOnly":true,"isTrigger":true,"isPositionTpsl":false,"triggerPx":"56000","triggerCondition":"Price below 56000"}
+	]`
+
+	var orders []FrontendOpenOrder
+	if err := json.Unmarshal([]byte(body), &orders); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(orders) != 5 {
+		t.Fatalf("decoded %d orders, want 5", len(orders))
+	}
+
+	for i, want := range []Tif{TifAlo, TifIoc, TifGtc} {
+		order := orders[i]
+		if order.Tif == nil || *order.Tif != want {
+			t.Errorf("order %d tif = %v, want %s", order.Oid, order.Tif, want)
+		}
+		if order.Tpsl != nil || !order.TriggerCondition.IsNone() {
+			t.Errorf("limit order %d has trigger details: %+v", order.Oid, order)
+		}
+	}
+
+	for i, want := range []Tpsl{TpslTp, TpslSl} {
+		order := orders[3+i]
+		if order.Tif != nil {
+			t.Errorf("trigger order %d tif = %s, want none", order.Oid, *order.Tif)
+		}
+		if order.Tpsl == nil || *order.Tpsl != want {
+			t.Errorf("trigger order %d tpsl = %v, want %s", order.Oid, order.Tpsl, want)
+		}
+		if order.TriggerCondition.IsNone() {
+			t.Errorf("trigger order %d has no trigger condition", order.Oid)
+		}
+	}
+	if orders[3].TriggerCondition != "Price above 65000" || !orders[3].IsPositionTpsl {
+		t.Errorf("unexpected take profit order: %+v", orders[3])
+	}
+	if orders[0].Coin != "ETH" || orders[0].LimitPx != "2000" {
+		t.Errorf("embedded open order fields not decoded: %+v", orders[0].OpenOrder)
+	}
+}
+
+// msgpackShape decodes a msgpack value and re-encodes it through JSON so it can be compared
+// with a decoded JSON value
+func msgpackShape(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+
+	packed, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("msgpack.Marshal: %v", err)
+	}
+	var decoded interface{}
+	if err := msgpack.Unmarshal(packed, &decoded); err != nil {
+		t.Fatalf("msgpack.Unmarshal: %v", err)
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var shape interface{}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	return shape
+}
+
+// msgpackKeys returns the top-level keys of a msgpack-encoded struct in encoding order
+func msgpackKeys(t *testing.T, v interface{}) []string {
+	t.Helper()
+
+	packed, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("msgpack.Marshal: %v", err)
+	}
+	decoder := msgpack.NewDecoder(bytes.NewReader(packed))
+	n, err := decoder.DecodeMapLen()
+	if err != nil {
+		t.Fatalf("DecodeMapLen: %v", err)
+	}
+	keys := make([]string, n)
+	for i := range keys {
+		if keys[i], err = decoder.DecodeString(); err != nil {
+			t.Fatalf("DecodeString: %v", err)
+		}
+		if err := decoder.Skip(); err != nil {
+			t.Fatalf("Skip: %v", err)
+		}
+	}
+	return keys
+}
+
+func TestWireTypesJSONAndMsgpackShapes(t *testing.T) {
+	cloid := "0x00000000000000000000000000000007"
+	limit := OrderWire{A: 0, B: true, P: "2000", S: "0.1", R: false, T: OrderTypeWire{Limit: &LimitOrderType{Tif: TifGtc}}}
+	trigger := OrderWire{
+		A: 1, B: false, P: "60000", S: "0.01", R: true, C: &cloid,
+		T: OrderTypeWire{Trigger: &TriggerOrderTypeWire{IsMarket: true, TriggerPx: "61000", Tpsl: TpslSl}},
+	}
+	scheduleTime := int64(1700000000000)
+
+	tests := []struct {
+		name string
+		wire interface{}
+		want string
+	}{
+		{
+			name: "limit order without cloid",
+			wire: limit,
+			want: `{"a":0,"b":true,"p":"2000","s":"0.1","r":false,"t":{"limit":{"tif":"Gtc"}}}`,
+		},
+		{
+			name: "trigger order with cloid",
+			wire: trigger,
+			want: `{"a":1,"b":false,"p":"60000","s":"0.01","r":true,"t":{"trigger":{"isMarket":true,"triggerPx":"61000","tpsl":"sl"}},"c":"0x00000000000000000000000000000007"}`,
+		},
+		{
+			name: "modify",
+			wire: ModifyWire{Oid: 42, Order: limit},
+			want: `{"oid":42,"order":{"a":0,"b":true,"p":"2000","s":"0.1","r":false,"t":{"limit":{"tif":"Gtc"}}}}`,
+		},
+		{
+			name: "builder",
+			wire: BuilderInfo{B: "0x0000000000000000000000000000000000000001", F: 10},
+			want: `{"b":"0x0000000000000000000000000000000000000001","f":10}`,
+		},
+		{
+			name: "schedule cancel with time",
+			wire: ScheduleCancelAction{Type: "scheduleCancel", Time: &scheduleTime},
+			want: `{"type":"scheduleCancel","time":1700000000000}`,
+		},
+		{
+			name: "schedule cancel without time",
+			wire: ScheduleCancelAction{Type: "scheduleCancel"},
+			want: `{"type":"scheduleCancel"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		var want interface{}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatalf("%s: bad expectation: %v", tt.name, err)
+		}
+
+		data, err := json.Marshal(tt.wire)
+		if err != nil {
+			t.Fatalf("%s: json.Marshal: %v", tt.name, err)
+		}
+		var fromJSON interface{}
+		if err := json.Unmarshal(data, &fromJSON); err != nil {
+			t.Fatalf("%s: json.Unmarshal: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(fromJSON, want) {
+			t.Errorf("%s: JSON = %s, want %s", tt.name, data, tt.want)
+		}
+
+		if fromMsgpack := msgpackShape(t, tt.wire); !reflect.DeepEqual(fromMsgpack, want) {
+			t.Errorf("%s: msgpack = %v, want %s", tt.name, fromMsgpack, tt.want)
+		}
+	}
+
+	// The action hash depends on the msgpack key order, which must follow the API field order
+	if got, want := msgpackKeys(t, limit), []string{"a", "b", "p", "s", "r", "t"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("limit order msgpack keys = %v, want %v", got, want)
+	}
+	if got, want := msgpackKeys(t, trigger), []string{"a", "b", "p", "s", "r", "t", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trigger order msgpack keys = %v, want %v", got, want)
+	}
+	if got, want := msgpackKeys(t, trigger.T.Trigger), []string{"isMarket", "triggerPx", "tpsl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trigger msgpack keys = %v, want %v", got, want)
+	}
+}
+
+func TestClearinghouseStateWithoutMarginSummary(t *testing.T) {
+	var state ClearinghouseState
+	if err := json.Unmarshal([]byte(`{"assetPositions":[],"withdrawable":"0.0","time":1700000000000}`), &state); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if state.MarginSummary.AccountValue != "" || state.CrossMarginSummary.TotalMarginUsed != "" {
+		t.Fatalf("missing margin summaries decoded as %+v, %+v", state.MarginSummary, state.CrossMarginSummary)
+	}
+	if state.HasEquity() {
+		t.Fatal("HasEquity = true for a state without marginSummary")
+	}
+	if (*ClearinghouseState)(nil).HasEquity() {
+		t.Fatal("HasEquity = true for a nil state")
+	}
+
+	tests := map[string]bool{"12.5": true, "0.0": false, "-3": false, "n/a": false}
+	for accountValue, want := range tests {
+		body := `{"assetPositions":[],"marginSummary":{"accountValue":"` + accountValue + `","totalNtlPos":"0","totalRawUsd":"0","totalMarginUsed":"0"},"withdrawable":"0","time":1}`
+		var state ClearinghouseState
+		if err := json.Unmarshal([]byte(body), &state); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if got := state.HasEquity(); got != want {
+			t.Errorf("HasEquity with accountValue %q = %v, want %v", accountValue, got, want)
+		}
+	}
+}
+
+func TestReferralStateDecodesRewards(t *testing.T) {
+	var state ReferralState
+	data := `{"referredBy":{"referrer":"0xabc","code":"ABC"},"cumVlm":"100.0","unclaimedRewards":"1.25","claimedRewards":"3.5","builderRewards":"0.0"}`
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if state.ReferredBy == nil || state.ReferredBy.Referrer != "0xabc" || state.ReferredBy.Code != "ABC" {
+		t.Fatalf("referredBy = %+v", state.ReferredBy)
+	}
+	if unclaimed, err := state.UnclaimedRewardsFloat(); err != nil || unclaimed != 1.25 {
+		t.Fatalf("UnclaimedRewardsFloat = %v, %v", unclaimed, err)
+	}
+	if claimed, err := state.ClaimedRewardsFloat(); err != nil || claimed != 3.5 {
+		t.Fatalf("ClaimedRewardsFloat = %v, %v", claimed, err)
+	}
+
+	// An account without a referrer or rewards decodes to zero amounts
+	var empty ReferralState
+	if err := json.Unmarshal([]byte(`{"referredBy":null,"cumVlm":"0.0"}`), &empty); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if unclaimed, err := empty.UnclaimedRewardsFloat(); err != nil || unclaimed != 0 || empty.ReferredBy != nil {
+		t.Fatalf("empty state = %+v, unclaimed %v, %v", empty, unclaimed, err)
+	}
+
+	if _, err := (ReferralState{ClaimedRewards: "abc"}).ClaimedRewardsFloat(); err == nil {
+		t.Fatal("expected an error for a malformed claimedRewards")
+	}
+}
+
+func TestExplorerBlockAndTxDecodeSampleResponses(t *testing.T) {
+	const sample = `{
+		"height": 512345678,
+		"blockTime": 1700000000123,
+		"hash": "0x9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4d3c2b1a0",
+		"proposer": "0x5ac99df645f3414876c816caa18b2d234024b487",
+		"numTxs": 2,
+		"txs": [
+			{
+				"action": {"type": "order", "orders": [{"a": 0, "b": true, "p": "2000", "s": "0.1", "r": false, "t": {"limit": {"tif": "Gtc"}}}], "grouping": "na"},
+				"block": 512345678,
+				"error": null,
+				"hash": "0x1f0e5d3c9b7a2f4e6d8c0b1a3e5f7d9c2b4a6e8f0d1c3b5a7e9f2d4c6b8a0e1f",
+				"time": 1700000000123,
+				"user": "0x14dc79964da2c08b23698b3d3cc7ca32193d9955"
+			},
+			{
+				"action": {"type": "cancel", "cancels": [{"a": 0, "o": 42}]},
+				"block": 512345678,
+				"error": "Order was never placed, already canceled, or filled.",
+				"hash": "0x2a1b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f809",
+				"time": 1700000000123,
+				"user": "0x14dc79964da2c08b23698b3d3cc7ca32193d9955"
+			}
+		]
+	}`
+
+	var block ExplorerBlock
+	if err := json.Unmarshal([]byte(sample), &block); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if block.Height != 512345678 || block.BlockTime != 1700000000123 || block.NumTxs != 2 || len(block.Txs) != 2 {
+		t.Fatalf("unexpected block: %+v", block)
+	}
+	if block.Proposer != "0x5ac99df645f3414876c816caa18b2d234024b487" {
+		t.Errorf("proposer = %s", block.Proposer)
+	}
+
+	placed, canceled := block.Txs[0], block.Txs[1]
+	if !placed.Succeeded() || placed.Action["type"] != "order" || placed.Block != block.Height {
+		t.Errorf("unexpected order tx: %+v", placed)
+	}
+	if canceled.Succeeded() || *canceled.Error != "Order was never placed, already canceled, or filled." {
+		t.Errorf("cancel tx error = %v, want the rejection message", canceled.Error)
+	}
+}
+
+func TestFillIsLiquidation(t *testing.T) {
+	const liquidatedFill = `{"coin":"ETH","px":"1800.0","sz":"0.5","side":"A","time":1700000000000,"startPosition":"0.5","dir":"Market Order Liquidation: Close Long","closedPnl":"-100.0","hash":"0x0","oid":10,"crossed":true,"fee":"0.45","tid":1,"feeToken":"USDC","liquidation":{"liquidatedUser":"0x14dc79964da2c08b23698b3d3cc7ca32193d9955","markPx":"1799.5","method":"market"}}`
+	const normalFill = `{"coin":"ETH","px":"2000.0","sz":"0.5","side":"B","time":1700000000000,"startPosition":"0.0","dir":"Open Long","closedPnl":"0.0","hash":"0x0","oid":20,"crossed":false,"fee":"0.1","tid":2,"feeToken":"USDC"}`
+
+	var liquidated, normal Fill
+	if err := json.Unmarshal([]byte(liquidatedFill), &liquidated); err != nil {
+		t.Fatalf("Unmarshal liquidation fill: %v", err)
+	}
+	if err := json.Unmarshal([]byte(normalFill), &normal); err != nil {
+		t.Fatalf("Unmarshal normal fill: %v", err)
+	}
+
+	if !liquidated.IsLiquidation() {
+		t.Error("liquidation fill is not flagged")
+	}
+	if liquidated.Liquidation.Method != "market" || liquidated.Liquidation.MarkPx != "1799.5" {
+		t.Errorf("liquidation = %+v", liquidated.Liquidation)
+	}
+	if normal.IsLiquidation() || normal.Liquidation != nil {
+		t.Error("normal fill is flagged as a liquidation")
+	}
+
+	// Either signal alone is enough
+	if !(Fill{Dir: "Market Order Liquidation: Close Short"}).IsLiquidation() {
+		t.Error("liquidation dir without details is not flagged")
+	}
+	if !(Fill{Dir: "Close Long", Liquidation: &FillLiquidation{Method: "backstop"}}).IsLiquidation() {
+		t.Error("liquidator fill with details is not flagged")
+	}
+}
+
+func TestMarginSummaryFloatAccessors(t *testing.T) {
+	sample := `{
+		"assetPositions": [],
+		"marginSummary": {"accountValue": "13109.482328", "totalNtlPos": "4245.2", "totalRawUsd": "17354.682328", "totalMarginUsed": "424.52"},
+		"crossMarginSummary": {"accountValue": "12900.5", "totalNtlPos": "4000.0", "totalRawUsd": "-3000.25", "totalMarginUsed": "400.0"},
+		"crossMaintenanceMarginUsed": "106.13",
+		"withdrawable": "12684.962328",
+		"time": 1708622398623
+	}`
+	var state ClearinghouseState
+	if err := json.Unmarshal([]byte(sample), &state); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	check := func(name string, accessor func() (float64, error), want float64) {
+		t.Helper()
+		got, err := accessor()
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	check("marginSummary.AccountValueFloat", state.MarginSummary.AccountValueFloat, 13109.482328)
+	check("marginSummary.TotalNtlPosFloat", state.MarginSummary.TotalNtlPosFloat, 4245.2)
+	check("marginSummary.TotalRawUsdFloat", state.MarginSummary.TotalRawUsdFloat, 17354.682328)
+	check("marginSummary.TotalMarginUsedFloat", state.MarginSummary.TotalMarginUsedFloat, 424.52)
+	check("crossMarginSummary.AccountValueFloat", state.CrossMarginSummary.AccountValueFloat, 12900.5)
+	check("crossMarginSummary.TotalNtlPosFloat", state.CrossMarginSummary.TotalNtlPosFloat, 4000)
+	check("crossMarginSummary.TotalRawUsdFloat", state.CrossMarginSummary.TotalRawUsdFloat, -3000.25)
+	check("crossMarginSummary.TotalMarginUsedFloat", state.CrossMarginSummary.TotalMarginUsedFloat, 400)
+
+	_, err := MarginSummary{TotalNtlPos: "1,000"}.TotalNtlPosFloat()
+	if err == nil || !strings.Contains(err.Error(), "totalNtlPos") {
+		t.Errorf("expected an error naming totalNtlPos, got %v", err)
+	}
+}
+
+func TestLeverageDecodesCrossAndIsolatedShapes(t *testing.T) {
+	decode := func(body string) Leverage {
+		t.Helper()
+		var position Position
+		if err := json.Unmarshal([]byte(`{"coin":"ETH","szi":"1.0","leverage":`+body+`}`), &position); err != nil {
+			t.Fatalf("Unmarshal %s: %v", body, err)
+		}
+		return position.Leverage
+	}
+
+	cross := decode(`{"type":"cross","value":20}`)
+	if !cross.IsCross() || cross.IsIsolated() || cross.Value != 20 || cross.RawUsd != "" {
+		t.Fatalf("unexpected cross leverage: %+v", cross)
+	}
+	if got, ok := cross.AsCross(); !ok || got != (CrossLeverage{Type: "cross", Value: 20}) {
+		t.Errorf("AsCross = %+v, %v", got, ok)
+	}
+	if _, ok := cross.AsIsolated(); ok {
+		t.Error("AsIsolated succeeded for cross leverage")
+	}
+
+	isolated := decode(`{"type":"isolated","value":10,"rawUsd":"-1530.65"}`)
+	if !isolated.IsIsolated() || isolated.IsCross() || isolated.Value != 10 || isolated.RawUsd != "-1530.65" {
+		t.Fatalf("unexpected isolated leverage: %+v", isolated)
+	}
+	if got, ok := isolated.AsIsolated(); !ok || got != (IsolatedLeverage{Type: "isolated", Value: 10, RawUsd: "-1530.65"}) {
+		t.Errorf("AsIsolated = %+v, %v", got, ok)
+	}
+	if _, ok := isolated.AsCross(); ok {
+		t.Error("AsCross succeeded for isolated leverage")
+	}
+
+	// rawUsd is only kept for isolated leverage, and a bare number is accepted
+	if got := decode(`{"type":"cross","value":5,"rawUsd":"12.0"}`); got.RawUsd != "" {
+		t.Errorf("cross leverage kept rawUsd %q", got.RawUsd)
+	}
+	if got := decode(`{"type":"isolated","value":3,"rawUsd":-42.5}`); got.RawUsd != "-42.5" {
+		t.Errorf("numeric rawUsd decoded as %q, want -42.5", got.RawUsd)
+	}
+	var leverage Leverage
+	if err := json.Unmarshal([]byte(`{"type":"isolated","value":3,"rawUsd":true}`), &leverage); err == nil {
+		t.Error("expected an error for a boolean rawUsd")
+	}
+}
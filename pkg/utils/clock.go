@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time used for timestamps and nonces
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock backed by the wall clock
+type realClock struct{}
+
+// Now returns the current wall clock time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+var (
+	clockMutex   sync.RWMutex
+	currentClock Clock = realClock{}
+)
+
+// SetClock replaces the package clock used by GetTimestampMS
+// Passing nil restores the wall clock. Intended for deterministic tests.
+func SetClock(c Clock) {
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+
+	if c == nil {
+		c = realClock{}
+	}
+	currentClock = c
+}
+
+// Now returns the current time according to the package clock
+func Now() time.Time {
+	clockMutex.RLock()
+	defer clockMutex.RUnlock()
+
+	return currentClock.Now()
+}
@@ -1,6 +1,10 @@
 package utils
 
 const (
+	// SDK version, reported in the default User-Agent
+	SDKVersion       = "0.1.0"
+	DefaultUserAgent = "hyperliquid-go-sdk/" + SDKVersion
+
 	// API URLs
 	MainnetAPIURL = "https://api.hyperliquid.xyz"
 	TestnetAPIURL = "https://api.hyperliquid-testnet.xyz"
@@ -10,6 +14,14 @@ const (
 	MainnetWSURL = "wss://api.hyperliquid.xyz/ws"
 	TestnetWSURL = "wss://api.hyperliquid-testnet.xyz/ws"
 
+	// Explorer URLs
+	MainnetExplorerURL = "https://rpc.hyperliquid.xyz/explorer"
+	TestnetExplorerURL = "https://rpc.hyperliquid-testnet.xyz/explorer"
+
+	// HyperEVM JSON-RPC URLs
+	MainnetEVMRPCURL = "https://rpc.hyperliquid.xyz/evm"
+	TestnetEVMRPCURL = "https://rpc.hyperliquid-testnet.xyz/evm"
+
 	// Chain configurations
 	MainnetChainName = "Mainnet"
 	TestnetChainName = "Testnet"
@@ -18,6 +30,9 @@ const (
 	SignatureChainID = "0x66eee"
 	EIP712ChainID    = 1337  // EIP712 chain ID for Hyperliquid signing (matches Python SDK)
 
+	// Minimum order value in USD accepted by the exchange
+	DefaultMinNotional = 10.0
+
 	// Decimal places
 	USDDecimals = 6
 	SzDecimals  = 8
@@ -1,10 +1,34 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
+// Sentinel errors for known exchange rejection reasons, matched with errors.Is
+var (
+	ErrInsufficientMargin = errors.New("insufficient margin")
+	ErrPostOnlyWouldMatch = errors.New("post only order would have immediately matched")
+	ErrInvalidPrice       = errors.New("invalid price")
+	ErrTwapNotRunning     = errors.New("twap was never placed, already canceled, or filled")
+)
+
+// ErrAssetNotTrading is returned by the opt-in order preflight for delisted or halted assets
+var ErrAssetNotTrading = errors.New("asset is not trading")
+
+// knownRejections maps lowercase substrings of exchange rejection messages to sentinel errors
+var knownRejections = []struct {
+	substring string
+	err       error
+}{
+	{"insufficient margin", ErrInsufficientMargin},
+	{"post only order would have immediately matched", ErrPostOnlyWouldMatch},
+	{"invalid price", ErrInvalidPrice},
+	{"never placed, already canceled, or filled", ErrTwapNotRunning},
+}
+
 // APIError represents errors returned by the API
 type APIError struct {
 	StatusCode int               `json:"status_code"`
@@ -36,6 +60,14 @@ func (e *ServerError) Error() string {
 	return fmt.Sprintf("Server error %d: %s", e.StatusCode, e.Message)
 }
 
+// NewAPIError creates an API error for a response that could not be decoded as expected
+func NewAPIError(statusCode int, message string) *APIError {
+	return &APIError{
+		StatusCode: statusCode,
+		Message:    message,
+	}
+}
+
 // NewClientError creates a new client error
 func NewClientError(statusCode int, code *string, message string, headers http.Header, data interface{}) *ClientError {
 	return &ClientError{
@@ -73,4 +105,38 @@ func NewValidationError(field, message string) *ValidationError {
 		Field:   field,
 		Message: message,
 	}
-}
\ No newline at end of file
+}
+
+// ExchangeError represents an action rejected by the exchange inside a successful HTTP response
+type ExchangeError struct {
+	Action  string
+	Message string
+}
+
+func (e *ExchangeError) Error() string {
+	return fmt.Sprintf("exchange rejected %s: %s", e.Action, e.Message)
+}
+
+// Unwrap returns the sentinel error matching the rejection reason, if known
+func (e *ExchangeError) Unwrap() error {
+	return ClassifyRejection(e.Message)
+}
+
+// ClassifyRejection returns the sentinel error for a known rejection message, or nil
+func ClassifyRejection(message string) error {
+	lower := strings.ToLower(message)
+	for _, rejection := range knownRejections {
+		if strings.Contains(lower, rejection.substring) {
+			return rejection.err
+		}
+	}
+	return nil
+}
+
+// NewExchangeError creates a new exchange error
+func NewExchangeError(action, message string) *ExchangeError {
+	return &ExchangeError{
+		Action:  action,
+		Message: message,
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExchangeErrorMatchesRejectionSentinels(t *testing.T) {
+	tests := []struct {
+		message string
+		want    error
+	}{
+		{message: "Insufficient margin to place order. asset=0", want: ErrInsufficientMargin},
+		{message: "Order has invalid price.", want: ErrInvalidPrice},
+		{message: "Post only order would have immediately matched, bbo was 1999.9@2000.1. asset=0", want: ErrPostOnlyWouldMatch},
+		{message: "Cannot cancel TWAP: TWAP was never placed, already canceled, or filled.", want: ErrTwapNotRunning},
+	}
+
+	for _, tt := range tests {
+		err := error(NewExchangeError("order", tt.message))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("errors.Is(%q, %v) = false", tt.message, tt.want)
+		}
+		for _, other := range []error{ErrInsufficientMargin, ErrInvalidPrice, ErrPostOnlyWouldMatch, ErrTwapNotRunning} {
+			if other != tt.want && errors.Is(err, other) {
+				t.Errorf("%q also matches %v", tt.message, other)
+			}
+		}
+	}
+
+	unknown := NewExchangeError("order", "Order must have minimum value of $10.")
+	if unknown.Unwrap() != nil {
+		t.Errorf("unknown rejection unwraps to %v", unknown.Unwrap())
+	}
+}
+
+func TestCheckOrderResponseClassifiesRejection(t *testing.T) {
+	err := CheckOrderResponse(map[string]interface{}{
+		"status": "ok",
+		"response": map[string]interface{}{
+			"type": "order",
+			"data": map[string]interface{}{
+				"statuses": []interface{}{
+					map[string]interface{}{"resting": map[string]interface{}{"oid": 1}},
+					map[string]interface{}{"error": "Insufficient margin to place order. asset=1"},
+				},
+			},
+		},
+	})
+	if !errors.Is(err, ErrInsufficientMargin) {
+		t.Fatalf("CheckOrderResponse = %v, want ErrInsufficientMargin", err)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/crypto"
+
+	"hyperliquid-go-sdk/pkg/types"
 )
 
 // ParsePrivateKey parses a private key from hex string
@@ -30,6 +34,11 @@ func ParsePrivateKey(privateKeyHex string) (*ecdsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
+// FormatPrivateKey formats a private key as a 0x-prefixed, zero-padded 32-byte hex string
+func FormatPrivateKey(privateKey *ecdsa.PrivateKey) string {
+	return "0x" + hex.EncodeToString(crypto.FromECDSA(privateKey))
+}
+
 // GetAddressFromPrivateKey gets the Ethereum address from a private key
 func GetAddressFromPrivateKey(privateKey *ecdsa.PrivateKey) string {
 	publicKey := privateKey.Public()
@@ -86,6 +95,108 @@ func ValidateAddress(address string) bool {
 	return err == nil
 }
 
+// ValidateTxHash validates a transaction hash: 0x followed by 64 hex characters
+func ValidateTxHash(hash string) bool {
+	if !strings.HasPrefix(hash, "0x") || len(hash) != 66 {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash[2:])
+	return err == nil
+}
+
+// ValidateDecimalAmount checks that amount is a positive decimal string with at most maxDecimals decimals
+func ValidateDecimalAmount(amount string, maxDecimals int) error {
+	if amount == "" {
+		return NewValidationError("amount", "must not be empty")
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(amount, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		if strings.HasPrefix(amount, "-") {
+			return NewValidationError("amount", fmt.Sprintf("must be positive, got %q", amount))
+		}
+		return NewValidationError("amount", fmt.Sprintf("%q is not a decimal number", amount))
+	}
+
+	if len(fracPart) > maxDecimals {
+		return NewValidationError("amount", fmt.Sprintf("%q has %d decimals, at most %d allowed", amount, len(fracPart), maxDecimals))
+	}
+
+	if strings.Trim(intPart+fracPart, "0") == "" {
+		return NewValidationError("amount", fmt.Sprintf("must be positive, got %q", amount))
+	}
+
+	return nil
+}
+
+// TokenToWei converts a human token amount to its integer wei representation
+// Returns an error if amount has more precision than weiDecimals allows
+func TokenToWei(amount float64, weiDecimals int) (string, error) {
+	if amount < 0 || !isFinite(amount) {
+		return "", fmt.Errorf("invalid token amount: %f", amount)
+	}
+
+	// The shortest decimal representation avoids binary float noise (1.1 -> "1.1")
+	intPart, fracPart, _ := strings.Cut(strconv.FormatFloat(amount, 'f', -1, 64), ".")
+	if len(fracPart) > weiDecimals {
+		return "", fmt.Errorf("token amount %s has more than %d decimals", strconv.FormatFloat(amount, 'f', -1, 64), weiDecimals)
+	}
+
+	wei := strings.TrimLeft(intPart+fracPart+strings.Repeat("0", weiDecimals-len(fracPart)), "0")
+	if wei == "" {
+		wei = "0"
+	}
+
+	return wei, nil
+}
+
+// WeiToToken converts an integer wei amount to a human token amount
+func WeiToToken(wei string, weiDecimals int) (float64, error) {
+	decimal, err := weiToDecimalString(wei, weiDecimals)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(decimal, 64)
+}
+
+// weiToDecimalString inserts the decimal point into an integer wei amount
+func weiToDecimalString(wei string, weiDecimals int) (string, error) {
+	if wei == "" || !isDigits(wei) {
+		return "", fmt.Errorf("invalid wei amount: %q", wei)
+	}
+
+	if len(wei) <= weiDecimals {
+		wei = strings.Repeat("0", weiDecimals-len(wei)+1) + wei
+	}
+
+	intPart := wei[:len(wei)-weiDecimals]
+	fracPart := strings.TrimRight(wei[len(wei)-weiDecimals:], "0")
+	if fracPart == "" {
+		return intPart, nil
+	}
+	return intPart + "." + fracPart, nil
+}
+
+// TokenAmountToString formats a human token amount as an exact decimal string within weiDecimals
+func TokenAmountToString(amount float64, weiDecimals int) (string, error) {
+	wei, err := TokenToWei(amount, weiDecimals)
+	if err != nil {
+		return "", err
+	}
+	return weiToDecimalString(wei, weiDecimals)
+}
+
+// isDigits reports whether s contains only ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // NormalizeAddress normalizes an address to lowercase
 func NormalizeAddress(address string) string {
 	return strings.ToLower(address)
@@ -141,6 +252,33 @@ func RoundToSignificantFigures(f float64, sigFigs int) float64 {
 	return round(f*factor) / factor
 }
 
+// SplitSize splits a total size into chunks no larger than maxChunk, rounded to szDecimals
+// The final chunk carries any remainder
+func SplitSize(total float64, maxChunk float64, szDecimals int) ([]float64, error) {
+	if total <= 0 {
+		return nil, fmt.Errorf("total size must be positive: %f", total)
+	}
+
+	multiplier := pow10(szDecimals)
+	totalUnits := round(total * multiplier)
+	// Round off float error (0.29 * 100 = 28.999...) before truncating to the size increment
+	chunkUnits := math.Floor(math.Round(maxChunk*multiplier*1e6) / 1e6)
+	if chunkUnits <= 0 {
+		return nil, fmt.Errorf("max chunk size %f is below the minimum size increment", maxChunk)
+	}
+
+	var chunks []float64
+	for remaining := totalUnits; remaining > 0; remaining -= chunkUnits {
+		units := chunkUnits
+		if remaining < chunkUnits {
+			units = remaining
+		}
+		chunks = append(chunks, units/multiplier)
+	}
+
+	return chunks, nil
+}
+
 // CalculateSlippagePrice calculates price with slippage
 func CalculateSlippagePrice(price float64, slippage float64, isBuy bool) float64 {
 	if isBuy {
@@ -199,6 +337,43 @@ func ValidateCoinName(coin string) bool {
 	return true
 }
 
+// ParseBuilderFeeRate converts a percentage fee rate such as "0.001%" to tenths of a basis point
+func ParseBuilderFeeRate(rate string) (int, error) {
+	trimmed := strings.TrimSpace(rate)
+	if !strings.HasSuffix(trimmed, "%") {
+		return 0, NewValidationError("maxFeeRate", fmt.Sprintf("fee rate %q must be a percentage, e.g. \"0.001%%\"", rate))
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, "%"), 64)
+	if err != nil || percent < 0 {
+		return 0, NewValidationError("maxFeeRate", fmt.Sprintf("invalid fee rate %q", rate))
+	}
+
+	// 1 tenth of a basis point is 0.001%
+	return int(round(percent * 1000)), nil
+}
+
+// CandleIntervals lists the candle intervals supported by the exchange
+var CandleIntervals = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "8h", "12h", "1d", "3d", "1w", "1M"}
+
+// ValidCandleInterval returns true if interval is a supported candle interval
+func ValidCandleInterval(interval string) bool {
+	for _, valid := range CandleIntervals {
+		if interval == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCandleInterval returns a ValidationError listing the valid intervals if interval is unsupported
+func ValidateCandleInterval(interval string) error {
+	if !ValidCandleInterval(interval) {
+		return NewValidationError("interval", fmt.Sprintf("invalid candle interval %q, must be one of %s", interval, strings.Join(CandleIntervals, ", ")))
+	}
+	return nil
+}
+
 // GetOrderSideString returns human-readable order side
 func GetOrderSideString(isBuy bool) string {
 	if isBuy {
@@ -227,6 +402,205 @@ func CalculatePnL(entryPrice, currentPrice, size float64, isBuy bool) float64 {
 	return (entryPrice - currentPrice) * size
 }
 
+// EstimateFunding estimates the funding payment for holding a position over a number of hours
+// A positive result is paid by the position holder, a negative result is received.
+// Longs pay shorts when the funding rate is positive.
+func EstimateFunding(notional float64, fundingRatePerHour float64, hours float64, isLong bool) float64 {
+	payment := abs(notional) * fundingRatePerHour * hours
+	if isLong {
+		return payment
+	}
+	return -payment
+}
+
+// minNotionalTolerance absorbs float error in sz * px so an order exactly at the minimum passes
+const minNotionalTolerance = 1e-9
+
+// MeetsMinNotional returns true if an order of sz at px is worth at least minNotional
+func MeetsMinNotional(sz, px, minNotional float64) bool {
+	return math.Abs(sz*px) >= minNotional-minNotionalTolerance
+}
+
+// MinSizeForNotional returns the smallest size with szDecimals decimals worth at least minNotional at px
+func MinSizeForNotional(px, minNotional float64, szDecimals int) (float64, error) {
+	if px <= 0 {
+		return 0, fmt.Errorf("price must be positive: %f", px)
+	}
+
+	multiplier := pow10(szDecimals)
+	sz := math.Ceil(minNotional/px*multiplier-minNotionalTolerance) / multiplier
+	if !MeetsMinNotional(sz, px, minNotional) {
+		sz += 1 / multiplier
+	}
+	return sz, nil
+}
+
+// IsolatedLeverageForMargin returns the lowest whole leverage at which a position of notional
+// needs no more than margin as initial margin
+func IsolatedLeverageForMargin(notional float64, margin float64) (int, error) {
+	if notional <= 0 {
+		return 0, fmt.Errorf("notional must be positive: %f", notional)
+	}
+	if margin <= 0 {
+		return 0, fmt.Errorf("margin must be positive: %f", margin)
+	}
+
+	leverage := int(math.Ceil(notional/margin - minNotionalTolerance))
+	if leverage < 1 {
+		leverage = 1
+	}
+	return leverage, nil
+}
+
+// MarginUtilization returns the share of the perp account value used as margin, e.g. 0.25 for 25%
+// An account with no value and no margin used has zero utilization; margin used against a
+// zero or negative account value is reported as an error since the ratio is meaningless.
+func MarginUtilization(state *types.ClearinghouseState) (float64, error) {
+	accountValue, marginUsed, err := parseMarginSummary(state)
+	if err != nil {
+		return 0, err
+	}
+
+	if accountValue <= 0 {
+		if marginUsed == 0 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("margin used %g against non-positive account value %g", marginUsed, accountValue)
+	}
+
+	return marginUsed / accountValue, nil
+}
+
+// FreeMargin returns the perp account value not used as margin, floored at zero
+func FreeMargin(state *types.ClearinghouseState) (float64, error) {
+	accountValue, marginUsed, err := parseMarginSummary(state)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Max(accountValue-marginUsed, 0), nil
+}
+
+// parseMarginSummary parses the account value and total margin used; missing values count as zero
+func parseMarginSummary(state *types.ClearinghouseState) (accountValue float64, marginUsed float64, err error) {
+	if state == nil {
+		return 0, 0, fmt.Errorf("clearinghouse state is nil")
+	}
+
+	if accountValue, err = state.MarginSummary.AccountValueFloat(); err != nil {
+		return 0, 0, err
+	}
+	if marginUsed, err = state.MarginSummary.TotalMarginUsedFloat(); err != nil {
+		return 0, 0, err
+	}
+
+	return accountValue, marginUsed, nil
+}
+
+// DepthLevel is an order book level with parsed values and the cumulative depth up to and including it
+type DepthLevel struct {
+	Px     float64
+	Sz     float64
+	N      int
+	CumSz  float64
+	CumNtl float64
+}
+
+// BookDepth parses an L2 book into bids sorted by descending price and asks sorted by ascending
+// price, each level carrying the cumulative size and notional from the top of the book.
+// Levels with unparseable px or sz are skipped.
+func BookDepth(book types.L2BookData) (bids []DepthLevel, asks []DepthLevel) {
+	bids = depthLevels(book.Levels[0], func(a, b float64) bool { return a > b })
+	asks = depthLevels(book.Levels[1], func(a, b float64) bool { return a < b })
+	return bids, asks
+}
+
+// BookFillPrice walks the side of book a taker of sz would hit, asks for a buy and bids for a sell,
+// and returns the average fill price and how much of sz the book can absorb.
+// filledSz is less than sz when the book is too thin; avgPx is zero if the side is empty.
+func BookFillPrice(book types.L2BookData, isBuy bool, sz float64) (avgPx float64, filledSz float64) {
+	bids, asks := BookDepth(book)
+	levels := bids
+	if isBuy {
+		levels = asks
+	}
+
+	var notional float64
+	for _, level := range levels {
+		if filledSz >= sz {
+			break
+		}
+		take := math.Min(level.Sz, sz-filledSz)
+		filledSz += take
+		notional += take * level.Px
+	}
+
+	if filledSz == 0 {
+		return 0, 0
+	}
+	return notional / filledSz, filledSz
+}
+
+// depthLevels parses and sorts one side of the book and accumulates its depth
+func depthLevels(levels []types.L2Level, better func(a, b float64) bool) []DepthLevel {
+	depth := make([]DepthLevel, 0, len(levels))
+	for _, level := range levels {
+		px, err := strconv.ParseFloat(level.Px, 64)
+		if err != nil {
+			continue
+		}
+		sz, err := strconv.ParseFloat(level.Sz, 64)
+		if err != nil {
+			continue
+		}
+		depth = append(depth, DepthLevel{Px: px, Sz: sz, N: level.N})
+	}
+
+	sort.SliceStable(depth, func(a, b int) bool {
+		return better(depth[a].Px, depth[b].Px)
+	})
+
+	var cumSz, cumNtl float64
+	for idx := range depth {
+		cumSz += depth[idx].Sz
+		cumNtl += depth[idx].Sz * depth[idx].Px
+		depth[idx].CumSz = cumSz
+		depth[idx].CumNtl = cumNtl
+	}
+
+	return depth
+}
+
+// AverageFillPrice returns the size-weighted average price across fills, e.g. the pieces of one order
+// totalSz is signed: positive for buys ("B") and negative for sells ("A").
+// Fills with unparseable px or sz are skipped.
+func AverageFillPrice(fills []types.Fill) (avgPx float64, totalSz float64) {
+	var notional, absSz float64
+	for _, fill := range fills {
+		px, err := strconv.ParseFloat(fill.Px, 64)
+		if err != nil {
+			continue
+		}
+		sz, err := strconv.ParseFloat(fill.Sz, 64)
+		if err != nil {
+			continue
+		}
+
+		notional += px * sz
+		absSz += sz
+		if fill.Side == "B" {
+			totalSz += sz
+		} else {
+			totalSz -= sz
+		}
+	}
+
+	if absSz == 0 {
+		return 0, 0
+	}
+	return notional / absSz, totalSz
+}
+
 // CalculateROE calculates return on equity as a percentage
 func CalculateROE(pnl, margin float64) float64 {
 	if margin == 0 {
@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+func TestSplitSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      float64
+		maxChunk   float64
+		szDecimals int
+		want       []float64
+	}{
+		{name: "even split", total: 3, maxChunk: 1, szDecimals: 0, want: []float64{1, 1, 1}},
+		{name: "remainder", total: 0.35, maxChunk: 0.1, szDecimals: 4, want: []float64{0.1, 0.1, 0.1, 0.05}},
+		{name: "smaller than chunk", total: 0.5, maxChunk: 2, szDecimals: 2, want: []float64{0.5}},
+		{name: "chunk truncated to size increment", total: 1, maxChunk: 0.456, szDecimals: 2, want: []float64{0.45, 0.45, 0.1}},
+		{name: "chunk exact despite float error", total: 0.58, maxChunk: 0.29, szDecimals: 2, want: []float64{0.29, 0.29}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitSize(tt.total, tt.maxChunk, tt.szDecimals)
+			if err != nil {
+				t.Fatalf("SplitSize: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("SplitSize(%v, %v, %d) = %v, want %v", tt.total, tt.maxChunk, tt.szDecimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSizeRejectsInvalidSizes(t *testing.T) {
+	if _, err := SplitSize(0, 1, 2); err == nil {
+		t.Error("expected an error for a zero total")
+	}
+	if _, err := SplitSize(1, 0.001, 2); err == nil {
+		t.Error("expected an error for a chunk below the size increment")
+	}
+}
+
+func TestEstimateFunding(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   float64
+		isLong bool
+		want   float64
+	}{
+		{name: "long pays positive funding", rate: 0.0001, isLong: true, want: 8},
+		{name: "short receives positive funding", rate: 0.0001, isLong: false, want: -8},
+		{name: "long receives negative funding", rate: -0.0001, isLong: true, want: -8},
+		{name: "short pays negative funding", rate: -0.0001, isLong: false, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateFunding(10000, tt.rate, 8, tt.isLong)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("EstimateFunding = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The sign follows the side, not the sign of the notional
+	if got := EstimateFunding(-10000, 0.0001, 8, true); math.Abs(got-8) > 1e-9 {
+		t.Fatalf("EstimateFunding with negative notional = %v, want 8", got)
+	}
+}
+
+func TestValidateDecimalAmount(t *testing.T) {
+	valid := []string{"1", "0.5", "100.123456", "007", "10"}
+	for _, amount := range valid {
+		if err := ValidateDecimalAmount(amount, USDDecimals); err != nil {
+			t.Errorf("ValidateDecimalAmount(%q): %v", amount, err)
+		}
+	}
+
+	tests := []struct {
+		amount  string
+		message string
+	}{
+		{amount: "", message: "must not be empty"},
+		{amount: "abc", message: "is not a decimal number"},
+		{amount: "1e5", message: "is not a decimal number"},
+		{amount: "1.", message: "is not a decimal number"},
+		{amount: ".5", message: "is not a decimal number"},
+		{amount: " 1", message: "is not a decimal number"},
+		{amount: "-1", message: "must be positive"},
+		{amount: "0", message: "must be positive"},
+		{amount: "0.000", message: "must be positive"},
+		{amount: "1.1234567", message: "has 7 decimals, at most 6 allowed"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateDecimalAmount(tt.amount, USDDecimals)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("ValidateDecimalAmount(%q) = %v, want a ValidationError", tt.amount, err)
+			continue
+		}
+		if validationErr.Field != "amount" || !strings.Contains(validationErr.Message, tt.message) {
+			t.Errorf("ValidateDecimalAmount(%q) = %v, want a message containing %q", tt.amount, err, tt.message)
+		}
+	}
+}
+
+func TestTokenToWei(t *testing.T) {
+	tests := []struct {
+		amount      float64
+		weiDecimals int
+		want        string
+	}{
+		{amount: 1.5, weiDecimals: 6, want: "1500000"},
+		{amount: 0.000001, weiDecimals: 6, want: "1"},
+		{amount: 0, weiDecimals: 6, want: "0"},
+		{amount: 1.1, weiDecimals: 18, want: "1100000000000000000"},
+		{amount: 250, weiDecimals: 18, want: "250000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		got, err := TokenToWei(tt.amount, tt.weiDecimals)
+		if err != nil {
+			t.Errorf("TokenToWei(%v, %d): %v", tt.amount, tt.weiDecimals, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("TokenToWei(%v, %d) = %q, want %q", tt.amount, tt.weiDecimals, got, tt.want)
+		}
+	}
+
+	for _, amount := range []float64{0.0000001, -1, math.NaN(), math.Inf(1)} {
+		if got, err := TokenToWei(amount, 6); err == nil {
+			t.Errorf("TokenToWei(%v, 6) = %q, want an error", amount, got)
+		}
+	}
+}
+
+func TestWeiToToken(t *testing.T) {
+	tests := []struct {
+		wei         string
+		weiDecimals int
+		want        float64
+	}{
+		{wei: "1500000", weiDecimals: 6, want: 1.5},
+		{wei: "1", weiDecimals: 6, want: 0.000001},
+		{wei: "1100000000000000000", weiDecimals: 18, want: 1.1},
+		{wei: "1", weiDecimals: 18, want: 1e-18},
+	}
+
+	for _, tt := range tests {
+		got, err := WeiToToken(tt.wei, tt.weiDecimals)
+		if err != nil {
+			t.Errorf("WeiToToken(%q, %d): %v", tt.wei, tt.weiDecimals, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("WeiToToken(%q, %d) = %v, want %v", tt.wei, tt.weiDecimals, got, tt.want)
+		}
+	}
+
+	for _, wei := range []string{"", "-1", "1.5", "abc"} {
+		if got, err := WeiToToken(wei, 6); err == nil {
+			t.Errorf("WeiToToken(%q, 6) = %v, want an error", wei, got)
+		}
+	}
+}
+
+func TestAverageFillPricePartialFills(t *testing.T) {
+	buys := []types.Fill{
+		{Coin: "ETH", Px: "2000", Sz: "0.1", Side: "B", Oid: 7},
+		{Coin: "ETH", Px: "2001", Sz: "0.3", Side: "B", Oid: 7},
+		{Coin: "ETH", Px: "2004", Sz: "0.1", Side: "B", Oid: 7},
+	}
+	avgPx, totalSz := AverageFillPrice(buys)
+	if math.Abs(avgPx-2001.4) > 1e-9 || math.Abs(totalSz-0.5) > 1e-9 {
+		t.Fatalf("AverageFillPrice(buys) = %v, %v; want 2001.4, 0.5", avgPx, totalSz)
+	}
+
+	sells := []types.Fill{
+		{Coin: "BTC", Px: "60000", Sz: "0.02", Side: "A", Oid: 8},
+		{Coin: "BTC", Px: "59900", Sz: "0.03", Side: "A", Oid: 8},
+		{Coin: "BTC", Px: "bad", Sz: "1", Side: "A", Oid: 8},
+	}
+	avgPx, totalSz = AverageFillPrice(sells)
+	if math.Abs(avgPx-59940) > 1e-9 || math.Abs(totalSz+0.05) > 1e-9 {
+		t.Fatalf("AverageFillPrice(sells) = %v, %v; want 59940, -0.05", avgPx, totalSz)
+	}
+
+	if avgPx, totalSz := AverageFillPrice(nil); avgPx != 0 || totalSz != 0 {
+		t.Fatalf("AverageFillPrice(nil) = %v, %v; want 0, 0", avgPx, totalSz)
+	}
+}
+
+func TestValidCandleInterval(t *testing.T) {
+	for _, interval := range []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "8h", "12h", "1d", "3d", "1w", "1M"} {
+		if !ValidCandleInterval(interval) {
+			t.Errorf("ValidCandleInterval(%q) = false, want true", interval)
+		}
+		if err := ValidateCandleInterval(interval); err != nil {
+			t.Errorf("ValidateCandleInterval(%q): %v", interval, err)
+		}
+	}
+
+	for _, interval := range []string{"", "1", "2m", "1H", "1D", "1W", "1mo", "60m", " 1m"} {
+		if ValidCandleInterval(interval) {
+			t.Errorf("ValidCandleInterval(%q) = true, want false", interval)
+		}
+		err := ValidateCandleInterval(interval)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "interval" {
+			t.Errorf("ValidateCandleInterval(%q) = %v, want an interval ValidationError", interval, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), strings.Join(CandleIntervals, ", ")) {
+			t.Errorf("ValidateCandleInterval(%q) error does not list the valid intervals: %v", interval, err)
+		}
+	}
+}
+
+func TestMarginUtilizationAndFreeMargin(t *testing.T) {
+	var state types.ClearinghouseState
+	sample := `{
+		"assetPositions": [],
+		"marginSummary": {"accountValue": "10000.0", "totalNtlPos": "25000.0", "totalRawUsd": "-15000.0", "totalMarginUsed": "2500.0"},
+		"crossMarginSummary": {"accountValue": "9000.0", "totalNtlPos": "20000.0", "totalRawUsd": "-11000.0", "totalMarginUsed": "2000.0"},
+		"crossMaintenanceMarginUsed": "600.0",
+		"withdrawable": "7500.0",
+		"time": 1700000000000
+	}`
+	if err := json.Unmarshal([]byte(sample), &state); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if ratio, err := MarginUtilization(&state); err != nil || ratio != 0.25 {
+		t.Fatalf("MarginUtilization = %v, %v; want 0.25", ratio, err)
+	}
+	if free, err := FreeMargin(&state); err != nil || free != 7500 {
+		t.Fatalf("FreeMargin = %v, %v; want 7500", free, err)
+	}
+
+	// An empty account is unused rather than an error
+	empty := &types.ClearinghouseState{}
+	if ratio, err := MarginUtilization(empty); err != nil || ratio != 0 {
+		t.Fatalf("MarginUtilization(empty) = %v, %v; want 0", ratio, err)
+	}
+	if free, err := FreeMargin(empty); err != nil || free != 0 {
+		t.Fatalf("FreeMargin(empty) = %v, %v; want 0", free, err)
+	}
+
+	// Margin used against a wiped-out account has no meaningful ratio, and free margin floors at zero
+	wiped := &types.ClearinghouseState{MarginSummary: types.MarginSummary{AccountValue: "0.0", TotalMarginUsed: "150.0"}}
+	if _, err := MarginUtilization(wiped); err == nil {
+		t.Fatal("expected an error for margin used against a zero account value")
+	}
+	if free, err := FreeMargin(wiped); err != nil || free != 0 {
+		t.Fatalf("FreeMargin(wiped) = %v, %v; want 0", free, err)
+	}
+
+	for _, bad := range []*types.ClearinghouseState{nil, {MarginSummary: types.MarginSummary{AccountValue: "abc"}}} {
+		if _, err := MarginUtilization(bad); err == nil {
+			t.Errorf("MarginUtilization(%+v) succeeded, want an error", bad)
+		}
+		if _, err := FreeMargin(bad); err == nil {
+			t.Errorf("FreeMargin(%+v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestMeetsMinNotionalBelowAtAndAbove(t *testing.T) {
+	tests := []struct {
+		sz, px float64
+		want   bool
+	}{
+		{sz: 0.0049, px: 2000, want: false},
+		{sz: 0.005, px: 2000, want: true},
+		{sz: 0.0051, px: 2000, want: true},
+		// 0.1 + 0.2 is slightly above 0.3, so the product lands a hair off 10
+		{sz: 0.1 + 0.2, px: 100.0 / 3, want: true},
+		// Sells carry a negative size in some callers; the notional is still positive
+		{sz: -0.005, px: 2000, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := MeetsMinNotional(tt.sz, tt.px, 10); got != tt.want {
+			t.Errorf("MeetsMinNotional(%v, %v, 10) = %v, want %v", tt.sz, tt.px, got, tt.want)
+		}
+	}
+}
+
+func TestMinSizeForNotionalRoundsUpToSzDecimals(t *testing.T) {
+	tests := []struct {
+		px         float64
+		szDecimals int
+		want       float64
+	}{
+		{px: 2000, szDecimals: 4, want: 0.005},
+		{px: 3000, szDecimals: 4, want: 0.0034},
+		{px: 3, szDecimals: 0, want: 4},
+		{px: 0.25, szDecimals: 2, want: 40},
+	}
+
+	for _, tt := range tests {
+		got, err := MinSizeForNotional(tt.px, 10, tt.szDecimals)
+		if err != nil {
+			t.Errorf("MinSizeForNotional(%v, 10, %d): %v", tt.px, tt.szDecimals, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("MinSizeForNotional(%v, 10, %d) = %v, want %v", tt.px, tt.szDecimals, got, tt.want)
+		}
+		if !MeetsMinNotional(got, tt.px, 10) {
+			t.Errorf("MinSizeForNotional(%v, 10, %d) = %v, which is below the minimum", tt.px, tt.szDecimals, got)
+		}
+	}
+
+	if _, err := MinSizeForNotional(0, 10, 4); err == nil {
+		t.Error("expected an error for a zero price")
+	}
+}
+
+func TestBookDepthSortsAndAccumulates(t *testing.T) {
+	book := types.L2BookData{
+		Coin: "ETH",
+		Levels: [2][]types.L2Level{
+			{{Px: "1999", Sz: "2", N: 2}, {Px: "2000", Sz: "1", N: 1}, {Px: "bad", Sz: "5", N: 1}, {Px: "1998", Sz: "3", N: 4}},
+			{{Px: "2002", Sz: "2", N: 1}, {Px: "2001", Sz: "0.5", N: 1}, {Px: "2003", Sz: "4", N: 3}},
+		},
+	}
+
+	bids, asks := BookDepth(book)
+	wantBids := []DepthLevel{
+		{Px: 2000, Sz: 1, N: 1, CumSz: 1, CumNtl: 2000},
+		{Px: 1999, Sz: 2, N: 2, CumSz: 3, CumNtl: 5998},
+		{Px: 1998, Sz: 3, N: 4, CumSz: 6, CumNtl: 11992},
+	}
+	wantAsks := []DepthLevel{
+		{Px: 2001, Sz: 0.5, N: 1, CumSz: 0.5, CumNtl: 1000.5},
+		{Px: 2002, Sz: 2, N: 1, CumSz: 2.5, CumNtl: 5004.5},
+		{Px: 2003, Sz: 4, N: 3, CumSz: 6.5, CumNtl: 13016.5},
+	}
+	if !reflect.DeepEqual(bids, wantBids) {
+		t.Errorf("bids = %+v, want %+v", bids, wantBids)
+	}
+	if !reflect.DeepEqual(asks, wantAsks) {
+		t.Errorf("asks = %+v, want %+v", asks, wantAsks)
+	}
+
+	bids, asks = BookDepth(types.L2BookData{})
+	if len(bids) != 0 || len(asks) != 0 {
+		t.Errorf("empty book depth = %v, %v", bids, asks)
+	}
+}
+
+func TestIsolatedLeverageForMargin(t *testing.T) {
+	tests := []struct {
+		notional, margin float64
+		want             int
+	}{
+		{notional: 2000, margin: 200, want: 10},
+		{notional: 2000, margin: 300, want: 7},
+		{notional: 0.1 * 3 * 1000, margin: 30, want: 10},
+		{notional: 100, margin: 500, want: 1},
+	}
+	for _, tt := range tests {
+		got, err := IsolatedLeverageForMargin(tt.notional, tt.margin)
+		if err != nil || got != tt.want {
+			t.Errorf("IsolatedLeverageForMargin(%v, %v) = %d, %v; want %d", tt.notional, tt.margin, got, err, tt.want)
+		}
+		if err == nil && tt.notional/float64(got) > tt.margin+1e-9 {
+			t.Errorf("IsolatedLeverageForMargin(%v, %v) = %d needs %v margin", tt.notional, tt.margin, got, tt.notional/float64(got))
+		}
+	}
+
+	for _, bad := range [][2]float64{{0, 100}, {-100, 100}, {100, 0}, {100, -1}} {
+		if _, err := IsolatedLeverageForMargin(bad[0], bad[1]); err == nil {
+			t.Errorf("IsolatedLeverageForMargin(%v, %v) succeeded, want an error", bad[0], bad[1])
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import "math"
+
+// roundingTolerance returns the largest difference between a value and its rounded form
+// that is still treated as float noise rather than real precision loss.
+// A fixed minimum alone is too strict for large magnitudes, where adjacent float64
+// values are further apart than the minimum itself.
+func roundingTolerance(x float64, minimum float64) float64 {
+	ax := math.Abs(x)
+	ulp := math.Nextafter(ax, math.Inf(1)) - ax
+	return math.Max(minimum, 4*ulp)
+}
+
+// isFinite reports whether x is neither NaN nor infinite
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// decimalValue returns n scaled down by 10^decimals, with decimals in [0, 8] and n bounded so the
+// decimal value is exactly what a user would type as a price or size
+func decimalValue(n int64, decimals uint8) (float64, int, bool) {
+	d := int(decimals % 9)
+	if n > 1e15 || n < -1e15 {
+		return 0, 0, false
+	}
+	x, err := strconv.ParseFloat(strconv.FormatInt(n, 10)+"e-"+strconv.Itoa(d), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return x, d, true
+}
+
+func TestFloatToWireBoundaries(t *testing.T) {
+	tests := []struct {
+		x    float64
+		want string
+	}{
+		{x: 0, want: "0"},
+		{x: math.Copysign(0, -1), want: "0"},
+		{x: 0.1 + 0.2, want: "0.3"},
+		{x: 1e-8, want: "0.00000001"},
+		{x: 123456.78901234, want: "123456.78901234"},
+		{x: 1e15, want: "1000000000000000"},
+		{x: 98765.4321, want: "98765.4321"},
+	}
+
+	for _, tt := range tests {
+		got, err := FloatToWire(tt.x)
+		if err != nil {
+			t.Errorf("FloatToWire(%v): %v", tt.x, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FloatToWire(%v) = %q, want %q", tt.x, got, tt.want)
+		}
+	}
+
+	for _, x := range []float64{1e-9, 0.123456789, math.NaN(), math.Inf(1)} {
+		if got, err := FloatToWire(x); err == nil {
+			t.Errorf("FloatToWire(%v) = %q, want an error", x, got)
+		}
+	}
+}
+
+func FuzzFloatToWire(f *testing.F) {
+	for _, x := range []float64{0, 1, -1, 0.1, 0.3, 1e-8, 1.5e-9, 123456.78901234, 1e15, 1e300, 0.1 + 0.2} {
+		f.Add(x)
+	}
+
+	f.Fuzz(func(t *testing.T, x float64) {
+		if !isFinite(x) {
+			if _, err := FloatToWire(x); err == nil {
+				t.Fatalf("FloatToWire(%v) accepted a non-finite value", x)
+			}
+			return
+		}
+
+		wire, err := FloatToWire(x)
+		if err != nil {
+			// An error must mean x really has more than 8 decimals, not float noise. Values within
+			// a tenth of the 1e-12 tolerance of an 8-decimal value are noise and must be accepted.
+			scaled := x * 1e8
+			if math.Abs(scaled-math.Round(scaled)) < 1e-5*math.Max(1, math.Abs(scaled)*1e-12) {
+				t.Fatalf("FloatToWire(%v) rejected a value with at most 8 decimals: %v", x, err)
+			}
+			return
+		}
+
+		parsed, perr := strconv.ParseFloat(wire, 64)
+		if perr != nil {
+			t.Fatalf("FloatToWire(%v) = %q, which does not parse: %v", x, wire, perr)
+		}
+		if math.Abs(parsed-x) >= roundingTolerance(x, 1e-12) {
+			t.Fatalf("FloatToWire(%v) = %q, which does not round-trip", x, wire)
+		}
+		if wire == "-0" || strings.HasSuffix(wire, ".") || (strings.Contains(wire, ".") && strings.HasSuffix(wire, "0")) {
+			t.Fatalf("FloatToWire(%v) = %q is not normalized", x, wire)
+		}
+	})
+}
+
+func FuzzFloatToWireDecimal(f *testing.F) {
+	f.Add(int64(1), uint8(1))
+	f.Add(int64(3), uint8(1))
+	f.Add(int64(123456789), uint8(8))
+	f.Add(int64(-999999999999999), uint8(8))
+	f.Add(int64(1000000000000000), uint8(0))
+
+	f.Fuzz(func(t *testing.T, n int64, decimals uint8) {
+		x, _, ok := decimalValue(n, decimals)
+		if !ok {
+			return
+		}
+
+		wire, err := FloatToWire(x)
+		if err != nil {
+			t.Fatalf("FloatToWire(%v) rejected a value with at most 8 decimals: %v", x, err)
+		}
+		if parsed, _ := strconv.ParseFloat(wire, 64); parsed != x {
+			t.Fatalf("FloatToWire(%v) = %q, which parses to %v", x, wire, parsed)
+		}
+	})
+}
+
+func FuzzFloatToInt(f *testing.F) {
+	f.Add(int64(1), uint8(6))
+	f.Add(int64(100000001), uint8(8))
+	f.Add(int64(-25500000), uint8(6))
+	f.Add(int64(4503599627370495), uint8(8))
+
+	f.Fuzz(func(t *testing.T, n int64, decimals uint8) {
+		x, power, ok := decimalValue(n, decimals)
+		if !ok {
+			return
+		}
+
+		got, err := FloatToInt(x, power)
+		if err != nil {
+			t.Fatalf("FloatToInt(%v, %d) rejected an exact decimal: %v", x, power, err)
+		}
+		if got != n {
+			t.Fatalf("FloatToInt(%v, %d) = %d, want %d", x, power, got, n)
+		}
+
+		// One extra decimal beyond the power is genuine precision loss unless it is zero
+		if n%10 != 0 && power > 0 {
+			if _, err := FloatToInt(x, power-1); err == nil {
+				t.Fatalf("FloatToInt(%v, %d) accepted a value with too many decimals", x, power-1)
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+// ParseTransferResult extracts the status and error message from a transfer response
+func ParseTransferResult(result map[string]interface{}) types.TransferResult {
+	var transferResult types.TransferResult
+
+	if status, ok := result["status"].(string); ok {
+		transferResult.Status = status
+	}
+
+	// Rejections carry the reason as a plain string in the response field
+	if transferResult.Status == "err" {
+		if response, ok := result["response"].(string); ok {
+			transferResult.Error = response
+		} else {
+			transferResult.Error = fmt.Sprintf("%v", result["response"])
+		}
+	}
+
+	if errMsg, ok := result["error"].(string); ok && transferResult.Error == "" {
+		transferResult.Error = errMsg
+	}
+
+	return transferResult
+}
+
+// CheckTransferResult returns an ExchangeError if the transfer response indicates failure
+// It is CheckActionResult under its original name.
+func CheckTransferResult(actionType string, result map[string]interface{}) error {
+	return CheckActionResult(actionType, result)
+}
+
+// CheckActionResult returns an ExchangeError naming actionType if an action response indicates failure
+// Use it for actions whose response only carries a status, e.g. transfers, leverage updates
+// and scheduled cancels.
+func CheckActionResult(actionType string, result map[string]interface{}) error {
+	transferResult := ParseTransferResult(result)
+	if transferResult.Status == "ok" {
+		return nil
+	}
+
+	if transferResult.Error == "" {
+		transferResult.Error = fmt.Sprintf("unexpected status %q", transferResult.Status)
+	}
+
+	return NewExchangeError(actionType, transferResult.Error)
+}
+
+// ParseOrderResponse decodes an order action response into a typed OrderResponse
+// Returns an ExchangeError if the exchange rejected the whole action
+func ParseOrderResponse(result map[string]interface{}) (*types.OrderResponse, error) {
+	if status, _ := result["status"].(string); status != "ok" {
+		errMsg := ParseTransferResult(result).Error
+		if errMsg == "" {
+			errMsg = fmt.Sprintf("unexpected status %q", status)
+		}
+		return nil, NewExchangeError("order", errMsg)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order response: %w", err)
+	}
+
+	var orderResponse types.OrderResponse
+	if err := json.Unmarshal(data, &orderResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode order response: %w", err)
+	}
+
+	return &orderResponse, nil
+}
+
+// CheckOrderResponse returns an ExchangeError for the first rejected order in an order response
+// The error unwraps to a sentinel (e.g. ErrPostOnlyWouldMatch) when the reason is known
+func CheckOrderResponse(result map[string]interface{}) error {
+	orderResponse, err := ParseOrderResponse(result)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range orderResponse.Response.Data.Statuses {
+		if status.Error != nil {
+			return NewExchangeError("order", *status.Error)
+		}
+	}
+
+	return nil
+}
+
+// CheckCancelResponse returns an ExchangeError for the first order a cancel response failed to cancel
+// Each status is either "success" or an object carrying the error.
+func CheckCancelResponse(result map[string]interface{}) error {
+	if err := CheckActionResult("cancel", result); err != nil {
+		return err
+	}
+
+	response, _ := result["response"].(map[string]interface{})
+	data, _ := response["data"].(map[string]interface{})
+	statuses, _ := data["statuses"].([]interface{})
+
+	for _, status := range statuses {
+		switch status := status.(type) {
+		case string:
+			if status != "success" {
+				return NewExchangeError("cancel", fmt.Sprintf("unexpected status %q", status))
+			}
+		case map[string]interface{}:
+			if errMsg, ok := status["error"].(string); ok {
+				return NewExchangeError("cancel", errMsg)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckTwapCancelResponse returns an ExchangeError unless a twapCancel response confirms the cancel
+// A TWAP that already finished or was never placed unwraps to ErrTwapNotRunning.
+func CheckTwapCancelResponse(result map[string]interface{}) error {
+	if err := CheckActionResult("twapCancel", result); err != nil {
+		return err
+	}
+
+	response, _ := result["response"].(map[string]interface{})
+	data, _ := response["data"].(map[string]interface{})
+
+	switch status := data["status"].(type) {
+	case string:
+		if status == "success" {
+			return nil
+		}
+		return NewExchangeError("twapCancel", fmt.Sprintf("unexpected status %q", status))
+	case map[string]interface{}:
+		if errMsg, ok := status["error"].(string); ok {
+			return NewExchangeError("twapCancel", errMsg)
+		}
+	}
+
+	return NewExchangeError("twapCancel", fmt.Sprintf("unexpected response %v", result["response"]))
+}
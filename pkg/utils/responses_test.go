@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTransferResult(t *testing.T) {
+	ok := ParseTransferResult(map[string]interface{}{
+		"status":   "ok",
+		"response": map[string]interface{}{"type": "default"},
+	})
+	if ok.Status != "ok" || ok.Error != "" {
+		t.Fatalf("unexpected result for a successful transfer: %+v", ok)
+	}
+
+	rejected := ParseTransferResult(map[string]interface{}{
+		"status":   "err",
+		"response": "Insufficient balance for withdrawal",
+	})
+	if rejected.Status != "err" || rejected.Error != "Insufficient balance for withdrawal" {
+		t.Fatalf("unexpected result for a rejected transfer: %+v", rejected)
+	}
+}
+
+func TestCheckActionResult(t *testing.T) {
+	if err := CheckActionResult("usdSend", map[string]interface{}{"status": "ok"}); err != nil {
+		t.Fatalf("unexpected error for a successful transfer: %v", err)
+	}
+
+	err := CheckActionResult("usdSend", map[string]interface{}{
+		"status":   "err",
+		"response": "Insufficient balance for transfer",
+	})
+	var exchangeErr *ExchangeError
+	if !errors.As(err, &exchangeErr) {
+		t.Fatalf("expected an ExchangeError, got %v", err)
+	}
+	if exchangeErr.Action != "usdSend" || exchangeErr.Message != "Insufficient balance for transfer" {
+		t.Fatalf("unexpected error: %+v", exchangeErr)
+	}
+
+	// A response without a recognised status is not treated as success
+	if err := CheckActionResult("usdSend", map[string]interface{}{}); err == nil {
+		t.Fatal("expected an error for a response without status")
+	}
+}
+
+func TestParseOrderResponse(t *testing.T) {
+	response, err := ParseOrderResponse(map[string]interface{}{
+		"status": "ok",
+		"response": map[string]interface{}{
+			"type": "order",
+			"data": map[string]interface{}{
+				"statuses": []interface{}{
+					map[string]interface{}{"filled": map[string]interface{}{"totalSz": "1.5", "avgPx": "30.25", "oid": 5}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseOrderResponse: %v", err)
+	}
+
+	filled := response.Response.Data.Statuses[0].Filled
+	if avgPx, err := filled.AvgPxFloat(); err != nil || avgPx != 30.25 {
+		t.Fatalf("AvgPxFloat() = %v, %v; want 30.25", avgPx, err)
+	}
+	if totalSz, err := filled.TotalSzFloat(); err != nil || totalSz != 1.5 {
+		t.Fatalf("TotalSzFloat() = %v, %v; want 1.5", totalSz, err)
+	}
+
+	var exchangeErr *ExchangeError
+	_, err = ParseOrderResponse(map[string]interface{}{"status": "err", "response": "Invalid asset"})
+	if !errors.As(err, &exchangeErr) || exchangeErr.Message != "Invalid asset" {
+		t.Fatalf("expected an ExchangeError for a rejected action, got %v", err)
+	}
+}
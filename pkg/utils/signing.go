@@ -107,6 +107,10 @@ var (
 
 // FloatToWire converts a float to wire format string matching Python SDK exactly
 func FloatToWire(x float64) (string, error) {
+	if !isFinite(x) {
+		return "", fmt.Errorf("float_to_wire requires a finite value: %f", x)
+	}
+
 	// Convert to string with 8 decimal places to match Python SDK
 	rounded := fmt.Sprintf("%.8f", x)
 
@@ -116,7 +120,7 @@ func FloatToWire(x float64) (string, error) {
 		return "", err
 	}
 
-	if abs(parsed-x) >= 1e-12 {
+	if abs(parsed-x) >= roundingTolerance(x, 1e-12) {
 		return "", fmt.Errorf("float_to_wire causes rounding: %f", x)
 	}
 
@@ -158,10 +162,17 @@ func FloatToUSDInt(x float64) (int64, error) {
 
 // FloatToInt converts float to int with given power
 func FloatToInt(x float64, power int) (int64, error) {
+	if !isFinite(x) {
+		return 0, fmt.Errorf("float_to_int requires a finite value: %f", x)
+	}
+
 	withDecimals := x * pow10(power)
+	if abs(withDecimals) >= 1<<63 {
+		return 0, fmt.Errorf("float_to_int overflows int64: %f", x)
+	}
 	rounded := round(withDecimals)
 
-	if abs(rounded-withDecimals) >= 1e-3 {
+	if abs(rounded-withDecimals) >= roundingTolerance(withDecimals, 1e-3) {
 		return 0, fmt.Errorf("float_to_int causes rounding: %f", x)
 	}
 
@@ -187,7 +198,7 @@ func round(x float64) float64 {
 
 // GetTimestampMS returns current timestamp in milliseconds
 func GetTimestampMS() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+	return Now().UnixNano() / int64(time.Millisecond)
 }
 
 func addressToBytes(address string) []byte {
@@ -201,9 +212,23 @@ type OrderedLimitOrderType struct {
 	Tif string `msgpack:"tif"`
 }
 
+// OrderedTriggerOrderType represents a trigger order type with deterministic key ordering
+type OrderedTriggerOrderType struct {
+	IsMarket  bool   `msgpack:"isMarket"`
+	TriggerPx string `msgpack:"triggerPx"`
+	Tpsl      string `msgpack:"tpsl"`
+}
+
 // OrderedOrderType represents an order type with deterministic key ordering
 type OrderedOrderType struct {
-	Limit *OrderedLimitOrderType `msgpack:"limit,omitempty"`
+	Limit   *OrderedLimitOrderType   `msgpack:"limit,omitempty"`
+	Trigger *OrderedTriggerOrderType `msgpack:"trigger,omitempty"`
+}
+
+// OrderedBuilderInfo represents builder info with deterministic key ordering
+type OrderedBuilderInfo struct {
+	B string `msgpack:"b"`
+	F int    `msgpack:"f"`
 }
 
 // OrderedOrderWire represents an order with deterministic key ordering for msgpack
@@ -229,142 +254,290 @@ type OrderedCancelByCloidWire struct {
 	Cloid string `msgpack:"cloid"` // client order id
 }
 
+// OrderedTwapCancelAction represents a twapCancel action with deterministic key ordering for msgpack
+type OrderedTwapCancelAction struct {
+	Type string `msgpack:"type"`
+	A    int    `msgpack:"a"` // asset
+	T    int    `msgpack:"t"` // twap id
+}
+
+// OrderedRegisterReferrerAction represents a registerReferrer action with deterministic key ordering for msgpack
+type OrderedRegisterReferrerAction struct {
+	Type string `msgpack:"type"`
+	Code string `msgpack:"code"`
+}
+
 // OrderedActionMap represents an action with deterministic key ordering for msgpack
 type OrderedActionMap struct {
 	Type     string              `msgpack:"type"`
 	Orders   []OrderedOrderWire  `msgpack:"orders,omitempty"`
 	Cancels  interface{}         `msgpack:"cancels,omitempty"`
 	Grouping string              `msgpack:"grouping,omitempty"`
+	Builder  *OrderedBuilderInfo `msgpack:"builder,omitempty"`
 }
 
 // ActionHash computes the hash of an action using same logic as reference SDK
+// It panics on a malformed action; SignL1Action and RecoverL1Signer return the error instead.
 func ActionHash(action interface{}, vaultAddress *string, nonce int64, expiresAfter *int64) []byte {
-	// Convert action to ordered format if it's a map
-	var actionToEncode interface{}
-	if actionMap, ok := action.(map[string]interface{}); ok {
-		switch actionMap["type"] {
-		case "order":
-			// Convert orders to ordered format - handle both []interface{} and []map[string]interface{}
-			var ordersArray []interface{}
-			if arr, ok := actionMap["orders"].([]interface{}); ok {
-				ordersArray = arr
-			} else if mapArr, ok := actionMap["orders"].([]map[string]interface{}); ok {
-				// Convert []map[string]interface{} to []interface{}
-				ordersArray = make([]interface{}, len(mapArr))
-				for i, m := range mapArr {
-					ordersArray[i] = m
-				}
-			} else {
-				panic(fmt.Sprintf("unexpected orders type: %T", actionMap["orders"]))
+	hash, err := actionHash(action, vaultAddress, nonce, expiresAfter)
+	if err != nil {
+		panic(err.Error())
+	}
+	return hash
+}
+
+// orderedAction converts the action types whose field order matters for msgpack into ordered structs
+// Other actions are returned as-is.
+func orderedAction(action interface{}) (interface{}, error) {
+	actionMap, ok := action.(map[string]interface{})
+	if !ok {
+		return action, nil
+	}
+
+	switch actionMap["type"] {
+	case "order":
+		orders, err := wireMaps(actionMap["orders"], "orders")
+		if err != nil {
+			return nil, err
+		}
+		orderedOrders := make([]OrderedOrderWire, len(orders))
+		
+		for i, orderMap := range orders {
+			orderedOrder, err := orderedOrderWire(orderMap)
+			if err != nil {
+				return nil, fmt.Errorf("order %d: %w", i, err)
+			}
+			orderedOrders[i] = orderedOrder
+		}
+		
+		grouping, err := wireString(actionMap, "grouping")
+		if err != nil {
+			return nil, err
+		}
+		orderedAction := OrderedActionMap{
+			Type:     "order",
+			Orders:   orderedOrders,
+			Grouping: grouping,
+		}
+		if builderMap, ok := actionMap["builder"].(map[string]interface{}); ok {
+			builder := &OrderedBuilderInfo{}
+			if builder.B, err = wireString(builderMap, "b"); err != nil {
+				return nil, fmt.Errorf("builder: %w", err)
+			}
+			if builder.F, err = wireInt(builderMap, "f"); err != nil {
+				return nil, fmt.Errorf("builder: %w", err)
+			}
+			orderedAction.Builder = builder
+		}
+		return orderedAction, nil
+		
+	case "cancel":
+		cancels, err := wireMaps(actionMap["cancels"], "cancels")
+		if err != nil {
+			return nil, err
+		}
+		orderedCancels := make([]OrderedCancelWire, len(cancels))
+		
+		for i, cancelMap := range cancels {
+			var orderedCancel OrderedCancelWire
+			if orderedCancel.A, err = wireInt(cancelMap, "a"); err != nil {
+				return nil, fmt.Errorf("cancel %d: %w", i, err)
 			}
-			orderedOrders := make([]OrderedOrderWire, len(ordersArray))
-			
-			for i, orderIntf := range ordersArray {
-				orderMap := orderIntf.(map[string]interface{})
-				
-				// Convert order type
-				orderType := OrderedOrderType{}
-				if tMap, ok := orderMap["t"].(map[string]interface{}); ok {
-					if limitMap, ok := tMap["limit"].(map[string]interface{}); ok {
-						orderType.Limit = &OrderedLimitOrderType{
-							Tif: limitMap["tif"].(string),
-						}
-					}
-				}
-				
-				orderedOrder := OrderedOrderWire{
-					A: orderMap["a"].(int),
-					B: orderMap["b"].(bool),
-					P: orderMap["p"].(string),
-					S: orderMap["s"].(string),
-					R: orderMap["r"].(bool),
-					T: orderType,
-				}
-				
-				// Add cloid if present
-				if cloid, ok := orderMap["c"]; ok && cloid != nil {
-					cloidStr := cloid.(string)
-					orderedOrder.C = &cloidStr
-				}
-				
-				orderedOrders[i] = orderedOrder
+			if orderedCancel.O, err = wireInt(cancelMap, "o"); err != nil {
+				return nil, fmt.Errorf("cancel %d: %w", i, err)
 			}
-			
-			orderedAction := OrderedActionMap{
-				Type:     actionMap["type"].(string),
-				Orders:   orderedOrders,
-				Grouping: actionMap["grouping"].(string),
+			orderedCancels[i] = orderedCancel
+		}
+		
+		return OrderedActionMap{
+			Type:    "cancel",
+			Cancels: orderedCancels,
+		}, nil
+		
+	case "cancelByCloid":
+		cancels, err := wireMaps(actionMap["cancels"], "cancels")
+		if err != nil {
+			return nil, err
+		}
+		orderedCancelsByCloid := make([]OrderedCancelByCloidWire, len(cancels))
+		
+		for i, cancelMap := range cancels {
+			var orderedCancel OrderedCancelByCloidWire
+			if orderedCancel.Asset, err = wireInt(cancelMap, "asset"); err != nil {
+				return nil, fmt.Errorf("cancel %d: %w", i, err)
 			}
-			actionToEncode = orderedAction
-			
-		case "cancel":
-			// Convert cancels to ordered format
-			var cancelsArray []interface{}
-			if arr, ok := actionMap["cancels"].([]interface{}); ok {
-				cancelsArray = arr
-			} else if mapArr, ok := actionMap["cancels"].([]map[string]interface{}); ok {
-				// Convert []map[string]interface{} to []interface{}
-				cancelsArray = make([]interface{}, len(mapArr))
-				for i, m := range mapArr {
-					cancelsArray[i] = m
-				}
-			} else {
-				panic(fmt.Sprintf("unexpected cancels type: %T", actionMap["cancels"]))
+			if orderedCancel.Cloid, err = wireString(cancelMap, "cloid"); err != nil {
+				return nil, fmt.Errorf("cancel %d: %w", i, err)
 			}
-			orderedCancels := make([]OrderedCancelWire, len(cancelsArray))
-			
-			for i, cancelIntf := range cancelsArray {
-				cancelMap := cancelIntf.(map[string]interface{})
-				orderedCancel := OrderedCancelWire{
-					A: cancelMap["a"].(int),
-					O: cancelMap["o"].(int),
-				}
-				orderedCancels[i] = orderedCancel
+			orderedCancelsByCloid[i] = orderedCancel
+		}
+		
+		return OrderedActionMap{
+			Type:    "cancelByCloid",
+			Cancels: orderedCancelsByCloid,
+		}, nil
+		
+	case "scheduleCancel":
+		// A nil Time is omitted entirely, which clears the scheduled cancel
+		scheduleCancel := types.ScheduleCancelAction{Type: "scheduleCancel"}
+		if _, ok := actionMap["time"]; ok && actionMap["time"] != nil {
+			cancelTime, err := wireInt(actionMap, "time")
+			if err != nil {
+				return nil, err
 			}
-			
-			orderedAction := OrderedActionMap{
-				Type:    actionMap["type"].(string),
-				Cancels: orderedCancels,
+			t := int64(cancelTime)
+			scheduleCancel.Time = &t
+		}
+		return scheduleCancel, nil
+
+	case "twapCancel":
+		asset, err := wireInt(actionMap, "a")
+		if err != nil {
+			return nil, err
+		}
+		twapID, err := wireInt(actionMap, "t")
+		if err != nil {
+			return nil, err
+		}
+		return OrderedTwapCancelAction{Type: "twapCancel", A: asset, T: twapID}, nil
+
+	case "registerReferrer":
+		code, err := wireString(actionMap, "code")
+		if err != nil {
+			return nil, err
+		}
+		return OrderedRegisterReferrerAction{Type: "registerReferrer", Code: code}, nil
+
+	default:
+		// For other action types, use as-is
+		return action, nil
+	}
+}
+
+// orderedOrderWire converts one order of an order action into its ordered form
+func orderedOrderWire(orderMap map[string]interface{}) (OrderedOrderWire, error) {
+	var (
+		order OrderedOrderWire
+		err   error
+	)
+	if order.A, err = wireInt(orderMap, "a"); err != nil {
+		return order, err
+	}
+	if order.B, err = wireBool(orderMap, "b"); err != nil {
+		return order, err
+	}
+	if order.P, err = wireString(orderMap, "p"); err != nil {
+		return order, err
+	}
+	if order.S, err = wireString(orderMap, "s"); err != nil {
+		return order, err
+	}
+	if order.R, err = wireBool(orderMap, "r"); err != nil {
+		return order, err
+	}
+	
+	if tMap, ok := orderMap["t"].(map[string]interface{}); ok {
+		if limitMap, ok := tMap["limit"].(map[string]interface{}); ok {
+			tif, err := wireString(limitMap, "tif")
+			if err != nil {
+				return order, fmt.Errorf("limit: %w", err)
 			}
-			actionToEncode = orderedAction
-			
-		case "cancelByCloid":
-			// Convert cancels by cloid to ordered format
-			var cancelsArray []interface{}
-			if arr, ok := actionMap["cancels"].([]interface{}); ok {
-				cancelsArray = arr
-			} else if mapArr, ok := actionMap["cancels"].([]map[string]interface{}); ok {
-				// Convert []map[string]interface{} to []interface{}
-				cancelsArray = make([]interface{}, len(mapArr))
-				for i, m := range mapArr {
-					cancelsArray[i] = m
-				}
-			} else {
-				panic(fmt.Sprintf("unexpected cancels type: %T", actionMap["cancels"]))
+			order.T.Limit = &OrderedLimitOrderType{Tif: tif}
+		}
+		if triggerMap, ok := tMap["trigger"].(map[string]interface{}); ok {
+			trigger := &OrderedTriggerOrderType{}
+			if trigger.IsMarket, err = wireBool(triggerMap, "isMarket"); err != nil {
+				return order, fmt.Errorf("trigger: %w", err)
 			}
-			orderedCancelsByCloid := make([]OrderedCancelByCloidWire, len(cancelsArray))
-			
-			for i, cancelIntf := range cancelsArray {
-				cancelMap := cancelIntf.(map[string]interface{})
-				orderedCancel := OrderedCancelByCloidWire{
-					Asset: cancelMap["asset"].(int),
-					Cloid: cancelMap["cloid"].(string),
-				}
-				orderedCancelsByCloid[i] = orderedCancel
+			if trigger.TriggerPx, err = wireString(triggerMap, "triggerPx"); err != nil {
+				return order, fmt.Errorf("trigger: %w", err)
 			}
-			
-			orderedAction := OrderedActionMap{
-				Type:    actionMap["type"].(string),
-				Cancels: orderedCancelsByCloid,
+			if trigger.Tpsl, err = wireString(triggerMap, "tpsl"); err != nil {
+				return order, fmt.Errorf("trigger: %w", err)
 			}
-			actionToEncode = orderedAction
-			
-		default:
-			// For other action types, use as-is
-			actionToEncode = action
+			order.T.Trigger = trigger
 		}
-	} else {
-		actionToEncode = action
+	}
+	
+	// Add cloid if present
+	if cloid, ok := orderMap["c"]; ok && cloid != nil {
+		cloidStr, err := wireString(orderMap, "c")
+		if err != nil {
+			return order, err
+		}
+		order.C = &cloidStr
+	}
+	
+	return order, nil
+}
+
+// wireMaps returns a list of wire objects given as either []map[string]interface{} or []interface{}
+func wireMaps(v interface{}, field string) ([]map[string]interface{}, error) {
+	switch items := v.(type) {
+	case []map[string]interface{}:
+		return items, nil
+	case []interface{}:
+		maps := make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected %s[%d] type: %T", field, i, item)
+			}
+			maps[i] = m
+		}
+		return maps, nil
+	default:
+		return nil, fmt.Errorf("unexpected %s type: %T", field, v)
+	}
+}
+
+// wireString returns m[key] as a string
+func wireString(m map[string]interface{}, key string) (string, error) {
+	value, ok := m[key].(string)
+	if !ok {
+		return "", fmt.Errorf("field %q must be a string, got %T", key, m[key])
+	}
+	return value, nil
+}
+
+// wireBool returns m[key] as a bool
+func wireBool(m map[string]interface{}, key string) (bool, error) {
+	value, ok := m[key].(bool)
+	if !ok {
+		return false, fmt.Errorf("field %q must be a bool, got %T", key, m[key])
+	}
+	return value, nil
+}
+
+// wireInt returns m[key] as an int, accepting any integer type and whole-number float64 values
+// as produced by encoding/json
+func wireInt(m map[string]interface{}, key string) (int, error) {
+	switch value := m[key].(type) {
+	case int:
+		return value, nil
+	case int32:
+		return int(value), nil
+	case int64:
+		return int(value), nil
+	case uint32:
+		return int(value), nil
+	case uint64:
+		return int(value), nil
+	case float64:
+		if value >= -(1<<53) && value <= 1<<53 && value == float64(int64(value)) {
+			return int(value), nil
+		}
+		return 0, fmt.Errorf("field %q must be an integer, got %v", key, value)
+	default:
+		return 0, fmt.Errorf("field %q must be an integer, got %T", key, m[key])
+	}
+}
+
+// actionHash is ActionHash returning an error for malformed actions
+func actionHash(action interface{}, vaultAddress *string, nonce int64, expiresAfter *int64) ([]byte, error) {
+	actionToEncode, err := orderedAction(action)
+	if err != nil {
+		return nil, fmt.Errorf("invalid action: %w", err)
 	}
 
 	// Pack action using msgpack with consistent settings
@@ -374,15 +547,14 @@ func ActionHash(action interface{}, vaultAddress *string, nonce int64, expiresAf
 	enc.SetSortMapKeys(true)
 	enc.UseCompactInts(true)
 
-	err := enc.Encode(actionToEncode)
-	if err != nil {
-		panic(fmt.Sprintf("failed to marshal action: %v", err))
+	if err := enc.Encode(actionToEncode); err != nil {
+		return nil, fmt.Errorf("failed to marshal action: %w", err)
 	}
 	data := buf.Bytes()
 
 	// Add nonce as 8 bytes big endian
 	if nonce < 0 {
-		panic(fmt.Sprintf("nonce cannot be negative: %d", nonce))
+		return nil, fmt.Errorf("nonce cannot be negative: %d", nonce)
 	}
 	nonceBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(nonceBytes, uint64(nonce))
@@ -399,7 +571,7 @@ func ActionHash(action interface{}, vaultAddress *string, nonce int64, expiresAf
 	// Add expires_after if provided
 	if expiresAfter != nil {
 		if *expiresAfter < 0 {
-			panic(fmt.Sprintf("expiresAfter cannot be negative: %d", *expiresAfter))
+			return nil, fmt.Errorf("expiresAfter cannot be negative: %d", *expiresAfter)
 		}
 		data = append(data, 0x00)
 		expiresAfterBytes := make([]byte, 8)
@@ -410,7 +582,7 @@ func ActionHash(action interface{}, vaultAddress *string, nonce int64, expiresAf
 	// Return keccak256 hash
 	hash := crypto.Keccak256(data)
 	// fmt.Printf("go action hash: %s\n", hex.EncodeToString(hash))
-	return hash
+	return hash, nil
 }
 
 // ConstructPhantomAgent creates a phantom agent from hash
@@ -510,7 +682,10 @@ func SignL1Action(
 	isMainnet bool,
 ) (SignatureResult, error) {
 
-	hash := ActionHash(action, vaultAddress, timestamp, expiresAfter)
+	hash, err := actionHash(action, vaultAddress, timestamp, expiresAfter)
+	if err != nil {
+		return SignatureResult{}, err
+	}
 
 	phantomAgent := ConstructPhantomAgent(hash, isMainnet)
 
@@ -574,25 +749,223 @@ func SignUserSignedAction(privateKey *ecdsa.PrivateKey, action map[string]interf
 	}, nil
 }
 
-func SignInner(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData) (SignatureResult, error) {
-
-	// Create EIP-712 hash
+// hashTypedData returns the EIP-712 digest of typedData
+func hashTypedData(typedData apitypes.TypedData) ([]byte, error) {
 	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
 	if err != nil {
-		return SignatureResult{}, fmt.Errorf("failed to hash domain: %w", err)
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
 	}
 
 	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
 	if err != nil {
-		return SignatureResult{}, fmt.Errorf("failed to hash typed data: %w", err)
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
 	}
 	rawData := []byte{0x19, 0x01}
 	rawData = append(rawData, domainSeparator...)
 	rawData = append(rawData, typedDataHash...)
 
-	msgHash := crypto.Keccak256Hash(rawData)
+	return crypto.Keccak256(rawData), nil
+}
+
+// recoverTypedDataSigner recovers the address that produced signature over typedData
+func recoverTypedDataSigner(typedData apitypes.TypedData, signature SignatureResult) (string, error) {
+	msgHash, err := hashTypedData(typedData)
+	if err != nil {
+		return "", err
+	}
+
+	if signature.V != 27 && signature.V != 28 {
+		return "", fmt.Errorf("invalid signature v: %d", signature.V)
+	}
+
+	sig, err := signatureBytes(signature.R, signature.S, int64(signature.V))
+	if err != nil {
+		return "", err
+	}
+
+	return recoverFromBytes(msgHash, sig)
+}
+
+// SignatureToBytes converts a {"r", "s", "v"} signature map, as sent to the exchange, into the
+// 65-byte r || s || recovery id form used by go-ethereum. v may be 27/28 or already 0/1.
+func SignatureToBytes(sig map[string]interface{}) ([]byte, error) {
+	r, ok := sig["r"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid signature: missing r")
+	}
+	s, ok := sig["s"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid signature: missing s")
+	}
+
+	var v int64
+	switch value := sig["v"].(type) {
+	case int:
+		v = int64(value)
+	case int64:
+		v = value
+	case uint8:
+		v = int64(value)
+	case float64:
+		// JSON-decoded signatures carry v as float64
+		v = int64(value)
+		if float64(v) != value {
+			return nil, fmt.Errorf("invalid signature v: %v", value)
+		}
+	default:
+		return nil, fmt.Errorf("invalid signature: missing v")
+	}
+
+	return signatureBytes(r, s, v)
+}
+
+// RecoverSigner recovers the address that produced sig over the 32-byte digest
+// For L1 actions and user-signed actions the digest is the EIP-712 hash of the typed data.
+func RecoverSigner(digest []byte, sig map[string]interface{}) (string, error) {
+	if len(digest) != 32 {
+		return "", fmt.Errorf("invalid digest length: %d", len(digest))
+	}
+
+	sigBytes, err := SignatureToBytes(sig)
+	if err != nil {
+		return "", err
+	}
+
+	return recoverFromBytes(digest, sigBytes)
+}
 
-	signature, err := crypto.Sign(msgHash.Bytes(), privateKey)
+// signatureBytes builds a 65-byte signature from hex r and s and a v of 27/28 or 0/1
+func signatureBytes(rHex string, sHex string, v int64) ([]byte, error) {
+	r, err := hexutil.DecodeBig(rHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature r: %w", err)
+	}
+	s, err := hexutil.DecodeBig(sHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature s: %w", err)
+	}
+	if r.BitLen() > 256 || s.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid signature: r or s exceeds 32 bytes")
+	}
+
+	if v >= 27 {
+		v -= 27
+	}
+	if v != 0 && v != 1 {
+		return nil, fmt.Errorf("invalid signature v: %d", v)
+	}
+
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(v)
+
+	return sig, nil
+}
+
+// recoverFromBytes recovers the signer address from a digest and a 65-byte signature
+func recoverFromBytes(digest []byte, sig []byte) (string, error) {
+	publicKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover signer: %w", err)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey).Hex(), nil
+}
+
+// L1ActionDigest returns the EIP-712 digest an L1 action with the given nonce, vault and expiry
+// is signed over, for use with RecoverSigner
+func L1ActionDigest(action any, vaultAddress *string, nonce int64, expiresAfter *int64, isMainnet bool) ([]byte, error) {
+	hash, err := actionHash(action, vaultAddress, nonce, expiresAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashTypedData(L1Payload(ConstructPhantomAgent(hash, isMainnet)))
+}
+
+// RecoverL1Signer recovers the address that signed an L1 action with the given nonce, vault and expiry
+func RecoverL1Signer(
+	action any,
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
+	isMainnet bool,
+	signature SignatureResult,
+) (string, error) {
+	hash, err := actionHash(action, vaultAddress, nonce, expiresAfter)
+	if err != nil {
+		return "", err
+	}
+
+	phantomAgent := ConstructPhantomAgent(hash, isMainnet)
+
+	return recoverTypedDataSigner(L1Payload(phantomAgent), signature)
+}
+
+// VerifyL1Signature checks that signature over an L1 action recovers to expectedSigner
+// Useful for confirming signatures produced by other SDKs verify under this package's hashing.
+// Actions decoded from JSON are accepted: whole-number float64 values are treated as integers.
+func VerifyL1Signature(
+	action map[string]interface{},
+	vaultAddress *string,
+	nonce int64,
+	expiresAfter *int64,
+	isMainnet bool,
+	signature SignatureResult,
+	expectedSigner string,
+) (bool, error) {
+	normalized, _ := normalizeJSONNumbers(action).(map[string]interface{})
+
+	signer, err := RecoverL1Signer(normalized, vaultAddress, nonce, expiresAfter, isMainnet, signature)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(signer, expectedSigner), nil
+}
+
+// normalizeJSONNumbers converts whole-number float64 values, as produced by encoding/json, to int
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			normalized[k] = normalizeJSONNumbers(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(value))
+		for idx, item := range value {
+			normalized[idx] = normalizeJSONNumbers(item)
+		}
+		return normalized
+	case []map[string]interface{}:
+		normalized := make([]interface{}, len(value))
+		for idx, item := range value {
+			normalized[idx] = normalizeJSONNumbers(item)
+		}
+		return normalized
+	case float64:
+		// Only integers up to 2^53 are exactly representable as float64
+		if value >= -(1<<53) && value <= 1<<53 && value == float64(int64(value)) {
+			return int(value)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+func SignInner(privateKey *ecdsa.PrivateKey, typedData apitypes.TypedData) (SignatureResult, error) {
+
+	// Create EIP-712 hash
+	msgHash, err := hashTypedData(typedData)
+	if err != nil {
+		return SignatureResult{}, err
+	}
+
+	signature, err := crypto.Sign(msgHash, privateKey)
 	if err != nil {
 		return SignatureResult{}, fmt.Errorf("failed to sign message: %w", err)
 	}
@@ -615,6 +988,11 @@ func OrderTypeToWire(orderType types.OrderType) (types.OrderTypeWire, error) {
 	var wire types.OrderTypeWire
 
 	if orderType.Limit != nil {
+		switch orderType.Limit.Tif {
+		case types.TifAlo, types.TifIoc, types.TifGtc:
+		default:
+			return wire, fmt.Errorf("invalid tif %q: must be one of %s, %s or %s", orderType.Limit.Tif, types.TifAlo, types.TifIoc, types.TifGtc)
+		}
 		wire.Limit = orderType.Limit
 	} else if orderType.Trigger != nil {
 		triggerPxWire, err := FloatToWire(orderType.Trigger.TriggerPx)
@@ -691,6 +1069,11 @@ func ConvertOrderTypeWireToMap(orderType types.OrderTypeWire) map[string]interfa
 
 // OrderWiresToOrderAction converts order wires to order action
 func OrderWiresToOrderAction(orderWires []types.OrderWire, builder *types.BuilderInfo) map[string]interface{} {
+	return OrderWiresToOrderActionWithGrouping(orderWires, builder, types.GroupingNa)
+}
+
+// OrderWiresToOrderActionWithGrouping converts order wires to an order action with the given grouping
+func OrderWiresToOrderActionWithGrouping(orderWires []types.OrderWire, builder *types.BuilderInfo, grouping types.Grouping) map[string]interface{} {
 	// Convert OrderWires to maps to ensure proper JSON serialization
 	// This matches the TypeScript SDK format exactly
 	orderMaps := make([]map[string]interface{}, len(orderWires))
@@ -719,7 +1102,7 @@ func OrderWiresToOrderAction(orderWires []types.OrderWire, builder *types.Builde
 	action := make(map[string]interface{})
 	action["type"] = "order"
 	action["orders"] = orderMaps
-	action["grouping"] = "na"
+	action["grouping"] = string(grouping)
 
 	if builder != nil {
 		action["builder"] = map[string]interface{}{
@@ -733,66 +1116,130 @@ func OrderWiresToOrderAction(orderWires []types.OrderWire, builder *types.Builde
 
 // Sign functions for different action types
 
-// SignUSDTransferAction signs a USD transfer action
-func SignUSDTransferAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	// Create a copy of the action for signing with proper time field handling
-	signAction := make(map[string]interface{})
+// prepareUserSignedNumericFields copies action for signing, converting the named uint64 fields
+// to *big.Int as required by the EIP712 encoder. Strings, int, int64, uint64 and *big.Int are
+// accepted; any other value, or one out of uint64 range, is an error naming the field. The 'type'
+// field is not part of any EIP712 schema and is dropped.
+func prepareUserSignedNumericFields(action map[string]interface{}, fields []string) (map[string]interface{}, error) {
+	signAction := make(map[string]interface{}, len(action))
 	for k, v := range action {
-		// Skip the 'type' field - it's not part of the EIP712 schema
 		if k == "type" {
 			continue
 		}
-		if k == "time" {
-			// Convert time string to uint64 for EIP712 signing
-			// EIP712 uint64 values need to be provided as *big.Int
-			if timeStr, ok := v.(string); ok {
-				if timestamp, err := strconv.ParseUint(timeStr, 10, 64); err == nil {
-					signAction[k] = new(big.Int).SetUint64(timestamp)
-				} else {
-					signAction[k] = v
-				}
-			} else if timestamp, ok := v.(int64); ok {
-				if timestamp >= 0 {
-					signAction[k] = new(big.Int).SetUint64(uint64(timestamp))
-				} else {
-					signAction[k] = v
-				}
-			} else if timestamp, ok := v.(uint64); ok {
-				signAction[k] = new(big.Int).SetUint64(timestamp)
-			} else {
-				signAction[k] = v
-			}
-		} else {
-			signAction[k] = v
+		signAction[k] = v
+	}
+
+	for _, field := range fields {
+		v, exists := signAction[field]
+		if !exists {
+			continue
+		}
+		n, err := NormalizeTimeField(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		signAction[field] = n
+	}
+
+	return signAction, nil
+}
+
+// NormalizeTimeField converts a time or nonce value to the *big.Int form used for EIP712 uint64 fields
+// Accepts decimal strings, int, int64, uint64 and *big.Int; negative values are rejected.
+func NormalizeTimeField(v interface{}) (*big.Int, error) {
+	switch value := v.(type) {
+	case string:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time value %q: %w", value, err)
+		}
+		return new(big.Int).SetUint64(n), nil
+	case int:
+		if value < 0 {
+			return nil, fmt.Errorf("time value cannot be negative: %d", value)
+		}
+		return new(big.Int).SetUint64(uint64(value)), nil
+	case int64:
+		if value < 0 {
+			return nil, fmt.Errorf("time value cannot be negative: %d", value)
 		}
+		return new(big.Int).SetUint64(uint64(value)), nil
+	case uint64:
+		return new(big.Int).SetUint64(value), nil
+	case *big.Int:
+		if value == nil || value.Sign() < 0 || !value.IsUint64() {
+			return nil, fmt.Errorf("time value out of range: %v", value)
+		}
+		return new(big.Int).Set(value), nil
+	default:
+		return nil, fmt.Errorf("unsupported time value type %T", v)
 	}
+}
 
+// NormalizeTimeFieldInt64 converts a time or nonce value to the int64 form sent in API payloads
+func NormalizeTimeFieldInt64(v interface{}) (int64, error) {
+	n, err := NormalizeTimeField(v)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("time value out of int64 range: %s", n)
+	}
+	return n.Int64(), nil
+}
+
+// SignUSDTransferAction signs a USD transfer action
+func SignUSDTransferAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
+	signAction, err := prepareUserSignedNumericFields(action, []string{"time"})
+	if err != nil {
+		return nil, err
+	}
 	return SignUserSignedAction(privateKey, signAction, USDSendSignTypes, "HyperliquidTransaction:UsdSend", isMainnet)
 }
 
 // SignSpotTransferAction signs a spot transfer action
 func SignSpotTransferAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, SpotTransferSignTypes, "HyperliquidTransaction:SpotSend", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"time"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, SpotTransferSignTypes, "HyperliquidTransaction:SpotSend", isMainnet)
 }
 
 // SignWithdrawFromBridgeAction signs a withdraw from bridge action
 func SignWithdrawFromBridgeAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, WithdrawSignTypes, "HyperliquidTransaction:Withdraw", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"time"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, WithdrawSignTypes, "HyperliquidTransaction:Withdraw", isMainnet)
 }
 
 // SignUSDClassTransferAction signs a USD class transfer action
 func SignUSDClassTransferAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, USDClassTransferSignTypes, "HyperliquidTransaction:UsdClassTransfer", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"nonce"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, USDClassTransferSignTypes, "HyperliquidTransaction:UsdClassTransfer", isMainnet)
 }
 
 // SignSendAssetAction signs a send asset action
 func SignSendAssetAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, SendAssetSignTypes, "HyperliquidTransaction:SendAsset", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"nonce"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, SendAssetSignTypes, "HyperliquidTransaction:SendAsset", isMainnet)
 }
 
 // SignConvertToMultiSigUserAction signs a convert to multi-sig user action
 func SignConvertToMultiSigUserAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, ConvertToMultiSigUserSignTypes, "HyperliquidTransaction:ConvertToMultiSigUser", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"nonce"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, ConvertToMultiSigUserSignTypes, "HyperliquidTransaction:ConvertToMultiSigUser", isMainnet)
 }
 
 // SignAgent signs an agent action
@@ -804,33 +1251,10 @@ func SignAgent(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMa
 		{Name: "nonce", Type: "uint64"},
 	}
 
-	// Create a copy of the action for signing with proper type conversions
-	signAction := make(map[string]interface{})
-	for k, v := range action {
-		if k == "nonce" {
-			// Convert nonce to *big.Int for EIP712 signing
-			if nonceStr, ok := v.(string); ok {
-				if nonce, err := strconv.ParseUint(nonceStr, 10, 64); err == nil {
-					signAction[k] = new(big.Int).SetUint64(nonce)
-				} else {
-					signAction[k] = v
-				}
-			} else if nonce, ok := v.(int64); ok {
-				if nonce >= 0 {
-					signAction[k] = new(big.Int).SetUint64(uint64(nonce))
-				} else {
-					signAction[k] = v
-				}
-			} else if nonce, ok := v.(uint64); ok {
-				signAction[k] = new(big.Int).SetUint64(nonce)
-			} else {
-				signAction[k] = v
-			}
-		} else {
-			signAction[k] = v
-		}
+	signAction, err := prepareUserSignedNumericFields(action, []string{"nonce"})
+	if err != nil {
+		return nil, err
 	}
-
 	return SignUserSignedAction(privateKey, signAction, agentSignTypes, "HyperliquidTransaction:ApproveAgent", isMainnet)
 }
 
@@ -842,10 +1266,18 @@ func SignApproveBuilderFee(privateKey *ecdsa.PrivateKey, action map[string]inter
 		{Name: "builder", Type: "address"},
 		{Name: "nonce", Type: "uint64"},
 	}
-	return SignUserSignedAction(privateKey, action, builderFeeSignTypes, "HyperliquidTransaction:ApproveBuilderFee", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"nonce"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, builderFeeSignTypes, "HyperliquidTransaction:ApproveBuilderFee", isMainnet)
 }
 
 // SignTokenDelegateAction signs a token delegate action
 func SignTokenDelegateAction(privateKey *ecdsa.PrivateKey, action map[string]interface{}, isMainnet bool) (map[string]interface{}, error) {
-	return SignUserSignedAction(privateKey, action, TokenDelegateTypes, "HyperliquidTransaction:TokenDelegate", isMainnet)
+	signAction, err := prepareUserSignedNumericFields(action, []string{"wei", "nonce"})
+	if err != nil {
+		return nil, err
+	}
+	return SignUserSignedAction(privateKey, signAction, TokenDelegateTypes, "HyperliquidTransaction:TokenDelegate", isMainnet)
 }
@@ -0,0 +1,458 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"hyperliquid-go-sdk/pkg/types"
+)
+
+const testPrivateKey = "0123456789012345678901234567890123456789012345678901234567890123"
+
+func tpslOrderAction() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "order",
+		"orders": []map[string]interface{}{
+			{
+				"a": 1,
+				"b": true,
+				"p": "100",
+				"s": "100",
+				"r": false,
+				"t": map[string]interface{}{
+					"trigger": map[string]interface{}{
+						"isMarket":  true,
+						"triggerPx": "103",
+						"tpsl":      "sl",
+					},
+				},
+			},
+		},
+		"grouping": "na",
+	}
+}
+
+func TestSignL1ActionTriggerOrderMatchesReference(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := SignL1Action(privateKey, tpslOrderAction(), nil, 0, nil, true)
+	if err != nil {
+		t.Fatalf("SignL1Action: %v", err)
+	}
+
+	// Mainnet vector from the Python SDK's tpsl signing test
+	if signature.R != "0x98343f2b5ae8e26bb2587daad3863bc70d8792b09af1841b6fdd530a2065a3f9" ||
+		signature.S != "0x6b5bb6bb0633b710aa22b721dd9dee6d083646a5f8e581a20b545be6c1feb405" ||
+		signature.V != 27 {
+		t.Fatalf("unexpected signature: %+v", signature)
+	}
+}
+
+func TestActionHashAcceptsOtherIntegerTypes(t *testing.T) {
+	want := ActionHash(tpslOrderAction(), nil, 0, nil)
+
+	// The same action with int64 fields, as well as the JSON round trip of it, hashes identically
+	action := tpslOrderAction()
+	action["orders"].([]map[string]interface{})[0]["a"] = int64(1)
+	if got := ActionHash(action, nil, 0, nil); string(got) != string(want) {
+		t.Fatal("int64 asset changed the action hash")
+	}
+
+	raw, err := json.Marshal(tpslOrderAction())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActionHash(decoded, nil, 0, nil); string(got) != string(want) {
+		t.Fatal("JSON-decoded action changed the action hash")
+	}
+
+	withBuilder := func(fee interface{}) []byte {
+		action := tpslOrderAction()
+		action["builder"] = map[string]interface{}{"b": "0x0000000000000000000000000000000000000001", "f": fee}
+		return ActionHash(action, nil, 0, nil)
+	}
+	if string(withBuilder(int64(10))) != string(withBuilder(10)) || string(withBuilder(float64(10))) != string(withBuilder(10)) {
+		t.Fatal("builder fee type changed the action hash")
+	}
+}
+
+func TestSignL1ActionRejectsMalformedOrder(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]interface{})
+		field  string
+	}{
+		{
+			name: "numeric triggerPx",
+			mutate: func(action map[string]interface{}) {
+				order := action["orders"].([]map[string]interface{})[0]
+				order["t"].(map[string]interface{})["trigger"].(map[string]interface{})["triggerPx"] = 103.0
+			},
+			field: "triggerPx",
+		},
+		{
+			name: "missing isMarket",
+			mutate: func(action map[string]interface{}) {
+				order := action["orders"].([]map[string]interface{})[0]
+				delete(order["t"].(map[string]interface{})["trigger"].(map[string]interface{}), "isMarket")
+			},
+			field: "isMarket",
+		},
+		{
+			name: "string builder fee",
+			mutate: func(action map[string]interface{}) {
+				action["builder"] = map[string]interface{}{"b": "0x0000000000000000000000000000000000000001", "f": "10"}
+			},
+			field: `"f"`,
+		},
+		{
+			name: "fractional asset",
+			mutate: func(action map[string]interface{}) {
+				action["orders"].([]map[string]interface{})[0]["a"] = 1.5
+			},
+			field: `"a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := tpslOrderAction()
+			tt.mutate(action)
+
+			_, err := SignL1Action(privateKey, action, nil, 0, nil, true)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.field) {
+				t.Fatalf("error %q does not mention %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestPrepareUserSignedNumericFields(t *testing.T) {
+	const want = uint64(1700000000123)
+	for _, value := range []interface{}{"1700000000123", int64(1700000000123), uint64(1700000000123), int(1700000000123)} {
+		action := map[string]interface{}{"type": "spotSend", "time": value, "nonce": value, "amount": "1.5"}
+
+		signAction, err := prepareUserSignedNumericFields(action, []string{"time", "nonce"})
+		if err != nil {
+			t.Fatalf("prepareUserSignedNumericFields(%T): %v", value, err)
+		}
+		for _, field := range []string{"time", "nonce"} {
+			got, ok := signAction[field].(*big.Int)
+			if !ok || !got.IsUint64() || got.Uint64() != want {
+				t.Errorf("%s from %T = %v, want *big.Int %d", field, value, signAction[field], want)
+			}
+		}
+		if signAction["amount"] != "1.5" {
+			t.Errorf("amount = %v, want it passed through", signAction["amount"])
+		}
+		if _, ok := signAction["type"]; ok {
+			t.Errorf("type field was not dropped for %T", value)
+		}
+		if action["time"] != value {
+			t.Errorf("the input action was modified: %v", action["time"])
+		}
+	}
+
+	// Values that are not valid uint64s are rejected with the field named
+	for field, value := range map[string]interface{}{"nonce": int64(-1), "time": "soon", "wei": 1.5} {
+		_, err := prepareUserSignedNumericFields(map[string]interface{}{field: value}, []string{field})
+		if err == nil || !strings.Contains(err.Error(), "invalid "+field) {
+			t.Errorf("%s = %v: expected an error naming the field, got %v", field, value, err)
+		}
+	}
+
+	// Sign functions return the error instead of signing
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignUSDTransferAction(privateKey, map[string]interface{}{"destination": "0x0", "amount": "1", "time": "soon"}, false); err == nil {
+		t.Error("SignUSDTransferAction signed an invalid time")
+	}
+	if _, err := SignAgent(privateKey, map[string]interface{}{"agentAddress": "0x0", "agentName": "", "nonce": -1}, false); err == nil {
+		t.Error("SignAgent signed a negative nonce")
+	}
+}
+
+func TestVerifyL1SignaturePythonFixtures(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	// Actions and mainnet signatures captured from the Python SDK's signing tests (nonce 0)
+	fixtures := []struct {
+		name      string
+		action    string
+		signature SignatureResult
+	}{
+		{
+			name:   "limit order",
+			action: `{"type":"order","orders":[{"a":1,"b":true,"p":"100","s":"100","r":false,"t":{"limit":{"tif":"Gtc"}}}],"grouping":"na"}`,
+			signature: SignatureResult{
+				R: "0xd65369825a9df5d80099e513cce430311d7d26ddf477f5b3a33d2806b100d78e",
+				S: "0x2b54116ff64054968aa237c20ca9ff68000f977c93289157748a3162b6ea940e",
+				V: 28,
+			},
+		},
+		{
+			name:   "tpsl order",
+			action: `{"type":"order","orders":[{"a":1,"b":true,"p":"100","s":"100","r":false,"t":{"trigger":{"isMarket":true,"triggerPx":"103","tpsl":"sl"}}}],"grouping":"na"}`,
+			signature: SignatureResult{
+				R: "0x98343f2b5ae8e26bb2587daad3863bc70d8792b09af1841b6fdd530a2065a3f9",
+				S: "0x6b5bb6bb0633b710aa22b721dd9dee6d083646a5f8e581a20b545be6c1feb405",
+				V: 27,
+			},
+		},
+	}
+
+	for _, fixture := range fixtures {
+		var action map[string]interface{}
+		if err := json.Unmarshal([]byte(fixture.action), &action); err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+
+		valid, err := VerifyL1Signature(action, nil, 0, nil, true, fixture.signature, strings.ToLower(signer))
+		if err != nil || !valid {
+			t.Errorf("%s: VerifyL1Signature = %v, %v; want valid", fixture.name, valid, err)
+		}
+
+		// The same signature does not verify on testnet, for another nonce or another signer
+		if valid, _ := VerifyL1Signature(action, nil, 0, nil, false, fixture.signature, signer); valid {
+			t.Errorf("%s: verified on testnet", fixture.name)
+		}
+		if valid, _ := VerifyL1Signature(action, nil, 1, nil, true, fixture.signature, signer); valid {
+			t.Errorf("%s: verified with another nonce", fixture.name)
+		}
+		if valid, _ := VerifyL1Signature(action, nil, 0, nil, true, fixture.signature, "0x0000000000000000000000000000000000000001"); valid {
+			t.Errorf("%s: verified for another signer", fixture.name)
+		}
+	}
+
+	malformed := map[string]interface{}{"type": "order", "orders": "not a list", "grouping": "na"}
+	if valid, err := VerifyL1Signature(malformed, nil, 0, nil, true, fixtures[0].signature, signer); valid || err == nil {
+		t.Errorf("malformed action: VerifyL1Signature = %v, %v; want an error", valid, err)
+	}
+}
+
+func TestOrderTypeToWireValidatesTif(t *testing.T) {
+	for _, tif := range []types.Tif{types.TifAlo, types.TifIoc, types.TifGtc} {
+		wire, err := OrderTypeToWire(types.OrderType{Limit: &types.LimitOrderType{Tif: tif}})
+		if err != nil {
+			t.Errorf("OrderTypeToWire(%s): %v", tif, err)
+			continue
+		}
+		if wire.Limit == nil || wire.Limit.Tif != tif {
+			t.Errorf("OrderTypeToWire(%s) = %+v", tif, wire)
+		}
+	}
+
+	for _, tif := range []types.Tif{"gtc", "GTC", "Fok", ""} {
+		order := types.OrderRequest{
+			Coin: "ETH", IsBuy: true, Sz: 0.1, LimitPx: 2000,
+			OrderType: types.OrderType{Limit: &types.LimitOrderType{Tif: tif}},
+		}
+		_, err := OrderRequestToOrderWire(order, 0)
+		if err == nil || !strings.Contains(err.Error(), "invalid tif") {
+			t.Errorf("OrderRequestToOrderWire with tif %q: got %v, want an invalid tif error", tif, err)
+		}
+	}
+}
+
+func TestScheduleCancelWithoutTimeOmitsKey(t *testing.T) {
+	decode := func(action map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		ordered, err := orderedAction(action)
+		if err != nil {
+			t.Fatalf("orderedAction: %v", err)
+		}
+		packed, err := msgpack.Marshal(ordered)
+		if err != nil {
+			t.Fatalf("msgpack.Marshal: %v", err)
+		}
+		var decoded map[string]interface{}
+		if err := msgpack.Unmarshal(packed, &decoded); err != nil {
+			t.Fatalf("msgpack.Unmarshal: %v", err)
+		}
+		return decoded
+	}
+
+	cleared := decode(map[string]interface{}{"type": "scheduleCancel"})
+	if _, ok := cleared["time"]; ok || len(cleared) != 1 || cleared["type"] != "scheduleCancel" {
+		t.Fatalf("clearing action encodes as %v, want only the type key", cleared)
+	}
+
+	for _, cancelTime := range []interface{}{int64(1700000005000), 1700000005000} {
+		scheduled := decode(map[string]interface{}{"type": "scheduleCancel", "time": cancelTime})
+		if got, ok := scheduled["time"]; !ok || got != int64(1700000005000) {
+			t.Errorf("scheduled action with %T time encodes as %v", cancelTime, scheduled)
+		}
+	}
+
+	// A nil time is omitted too rather than encoded as null, so it hashes like the clearing action
+	withNull := map[string]interface{}{"type": "scheduleCancel", "time": nil}
+	if !bytes.Equal(ActionHash(withNull, nil, 0, nil), ActionHash(map[string]interface{}{"type": "scheduleCancel"}, nil, 0, nil)) {
+		t.Fatal("a nil time changed the action hash")
+	}
+}
+
+func TestNormalizeTimeField(t *testing.T) {
+	const want = int64(1700000000123)
+	for _, value := range []interface{}{"1700000000123", int64(want), uint64(want), int(want), big.NewInt(want)} {
+		n, err := NormalizeTimeField(value)
+		if err != nil {
+			t.Errorf("NormalizeTimeField(%T): %v", value, err)
+			continue
+		}
+		if !n.IsInt64() || n.Int64() != want {
+			t.Errorf("NormalizeTimeField(%T) = %v, want %d", value, n, want)
+		}
+
+		apiValue, err := NormalizeTimeFieldInt64(value)
+		if err != nil || apiValue != want {
+			t.Errorf("NormalizeTimeFieldInt64(%T) = %d, %v; want %d", value, apiValue, err, want)
+		}
+	}
+
+	// The input big.Int is copied, not aliased
+	input := big.NewInt(want)
+	n, _ := NormalizeTimeField(input)
+	n.SetInt64(0)
+	if input.Int64() != want {
+		t.Error("NormalizeTimeField modified its *big.Int input")
+	}
+
+	for _, value := range []interface{}{"", "12.5", "-1", int64(-1), -1, big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 64), 1.5, nil} {
+		if n, err := NormalizeTimeField(value); err == nil {
+			t.Errorf("NormalizeTimeField(%#v) = %v, want an error", value, n)
+		}
+	}
+	if n, err := NormalizeTimeFieldInt64(uint64(1) << 63); err == nil {
+		t.Errorf("NormalizeTimeFieldInt64(2^63) = %d, want an error", n)
+	}
+}
+
+func TestL1ActionDigestRecoversSigner(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	vault := "0x0000000000000000000000000000000000000abc"
+	expiresAfter := int64(1700000060000)
+
+	signature, err := SignL1Action(privateKey, tpslOrderAction(), &vault, 1700000000000, &expiresAfter, false)
+	if err != nil {
+		t.Fatalf("SignL1Action: %v", err)
+	}
+	sig := map[string]interface{}{"r": signature.R, "s": signature.S, "v": signature.V}
+
+	digest, err := L1ActionDigest(tpslOrderAction(), &vault, 1700000000000, &expiresAfter, false)
+	if err != nil {
+		t.Fatalf("L1ActionDigest: %v", err)
+	}
+	if signer, err := RecoverSigner(digest, sig); err != nil || signer != want {
+		t.Fatalf("RecoverSigner = %s, %v; want %s", signer, err, want)
+	}
+
+	// The digest covers the network
+	digest, err = L1ActionDigest(tpslOrderAction(), &vault, 1700000000000, &expiresAfter, true)
+	if err != nil {
+		t.Fatalf("L1ActionDigest: %v", err)
+	}
+	if signer, err := RecoverSigner(digest, sig); err == nil && signer == want {
+		t.Fatal("mainnet digest recovered the testnet signer")
+	}
+}
+
+func TestRecoverSignerFromProducedSignature(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	signature, err := SignL1Action(privateKey, tpslOrderAction(), nil, 0, nil, true)
+	if err != nil {
+		t.Fatalf("SignL1Action: %v", err)
+	}
+	digest, err := hashTypedData(L1Payload(ConstructPhantomAgent(ActionHash(tpslOrderAction(), nil, 0, nil), true)))
+	if err != nil {
+		t.Fatalf("hashTypedData: %v", err)
+	}
+
+	// The signature as built in code, after a JSON round trip, and with a 0/1 recovery id
+	var decoded map[string]interface{}
+	encoded, _ := json.Marshal(map[string]interface{}{"r": signature.R, "s": signature.S, "v": signature.V})
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for name, sig := range map[string]map[string]interface{}{
+		"int":         {"r": signature.R, "s": signature.S, "v": signature.V},
+		"json":        decoded,
+		"recovery id": {"r": signature.R, "s": signature.S, "v": int64(signature.V - 27)},
+	} {
+		signer, err := RecoverSigner(digest, sig)
+		if err != nil {
+			t.Fatalf("RecoverSigner(%s): %v", name, err)
+		}
+		if signer != want {
+			t.Errorf("RecoverSigner(%s) = %s, want %s", name, signer, want)
+		}
+	}
+
+	sigBytes, err := SignatureToBytes(decoded)
+	if err != nil {
+		t.Fatalf("SignatureToBytes: %v", err)
+	}
+	if len(sigBytes) != 65 || sigBytes[64] != byte(signature.V-27) {
+		t.Fatalf("SignatureToBytes = %x, want 65 bytes ending in the recovery id", sigBytes)
+	}
+	if got := "0x" + common.Bytes2Hex(sigBytes[:32]); got != signature.R {
+		t.Errorf("r bytes = %s, want %s", got, signature.R)
+	}
+
+	// A different digest recovers someone else
+	other := crypto.Keccak256([]byte("other"))
+	if signer, err := RecoverSigner(other, decoded); err == nil && signer == want {
+		t.Error("signature recovered the signer over an unrelated digest")
+	}
+
+	for name, sig := range map[string]map[string]interface{}{
+		"v 29":       {"r": signature.R, "s": signature.S, "v": 29},
+		"fraction v": {"r": signature.R, "s": signature.S, "v": 27.5},
+		"missing v":  {"r": signature.R, "s": signature.S},
+		"missing r":  {"s": signature.S, "v": 27},
+		"bad s":      {"r": signature.R, "s": "0xzz", "v": 27},
+	} {
+		if _, err := SignatureToBytes(sig); err == nil {
+			t.Errorf("SignatureToBytes(%s) succeeded, want an error", name)
+		}
+	}
+	if _, err := RecoverSigner(digest[:31], decoded); err == nil {
+		t.Error("expected an error for a short digest")
+	}
+}